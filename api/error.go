@@ -7,8 +7,9 @@ import (
 
 // Error 自定义错误类型
 type Error struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int      `json:"code"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"` // 字段级错误详情
 }
 
 // Error 实现error接口
@@ -24,6 +25,15 @@ func NewError(code int, message string) *Error {
 	}
 }
 
+// WithDetails 返回携带错误详情的副本，预定义错误本身不会被修改
+func (e *Error) WithDetails(details ...string) *Error {
+	return &Error{
+		Code:    e.Code,
+		Message: e.Message,
+		Details: details,
+	}
+}
+
 // 预定义错误
 var (
 	ErrSystem                  = NewError(constant.ErrSystem, constant.ErrMsgSystem)
@@ -37,4 +47,22 @@ var (
 	ErrPrizeStockEmpty         = NewError(constant.ErrPrizeStockEmpty, constant.ErrMsgPrizeStockEmpty)
 	ErrUserNotPosted           = NewError(constant.ErrUserNotPosted, constant.ErrMsgUserNotPosted)
 	ErrUserNotCheckedIn        = NewError(constant.ErrUserNotCheckedIn, constant.ErrMsgUserNotCheckedIn)
+	ErrDiscountCodeInvalid     = NewError(constant.ErrDiscountCodeInvalid, constant.ErrMsgDiscountCodeInvalid)
+	ErrUnauthorized            = NewError(constant.ErrUnauthorized, constant.ErrMsgUnauthorized)
+	ErrForbidden               = NewError(constant.ErrForbidden, constant.ErrMsgForbidden)
+	ErrActivityFull            = NewError(constant.ErrActivityFull, constant.ErrMsgActivityFull)
+	ErrNotEligible             = NewError(constant.ErrNotEligible, constant.ErrMsgNotEligible)
+	ErrNotEnrolled             = NewError(constant.ErrNotEnrolled, constant.ErrMsgNotEnrolled)
+	ErrPreconditionRequired    = NewError(constant.ErrPreconditionRequired, constant.ErrMsgPreconditionRequired)
+	ErrActivityModified        = NewError(constant.ErrActivityModified, constant.ErrMsgActivityModified)
+	ErrOnlineChangeForbidden   = NewError(constant.ErrOnlineChangeForbidden, constant.ErrMsgOnlineChangeForbidden)
+	ErrActivityNotPublished    = NewError(constant.ErrActivityNotPublished, constant.ErrMsgActivityNotPublished)
+	ErrActivityPaused          = NewError(constant.ErrActivityPaused, constant.ErrMsgActivityPaused)
+	ErrInvalidTransition       = NewError(constant.ErrInvalidTransition, constant.ErrMsgInvalidTransition)
+	ErrTransitionGuardFailed   = NewError(constant.ErrTransitionGuardFailed, constant.ErrMsgTransitionGuardFailed)
+	ErrRequestInProgress       = NewError(constant.ErrRequestInProgress, constant.ErrMsgRequestInProgress)
+	ErrIdempotencyKeyReused    = NewError(constant.ErrIdempotencyKeyReused, constant.ErrMsgIdempotencyKeyReused)
+	ErrTooManyRequests         = NewError(constant.ErrTooManyRequests, constant.ErrMsgTooManyRequests)
+	ErrRiskDenied              = NewError(constant.ErrRiskDenied, constant.ErrMsgRiskDenied)
+	ErrRiskChallenge           = NewError(constant.ErrRiskChallenge, constant.ErrMsgRiskChallenge)
 )
@@ -1,11 +1,10 @@
 package api
 
 import (
-	"Activity/constant"
-	"Activity/models"
-	"net/http"
+	"Activity/auth"
+	"fmt"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,111 +12,148 @@ import (
 type Handler struct {
 	gameService     GameService
 	activityService ActivityService
+	auditService    AuditService
+	verifier        auth.Verifier
+	rateLimiter     *RateLimiter
 }
 
-func NewHandler(gameService GameService, activityService ActivityService) *Handler {
+// NewHandler 创建处理器，rateLimiter为nil时参与接口不限流
+func NewHandler(gameService GameService, activityService ActivityService, auditService AuditService, verifier auth.Verifier, rateLimiter *RateLimiter) *Handler {
 	return &Handler{
 		gameService:     gameService,
 		activityService: activityService,
+		auditService:    auditService,
+		verifier:        verifier,
+		rateLimiter:     rateLimiter,
 	}
 }
 
 // RegisterRoutes 注册所有API路由
 func (h *Handler) RegisterRoutes(r *gin.Engine) {
+	// 用户相关接口需要认证，uid以令牌为准
+	authed := AuthRequired(h.verifier)
+
 	// API版本分组
 	v1 := r.Group("")
 	{
 		// 活动相关接口
 		activity := v1.Group("/activity")
 		{
-			activity.POST("", h.CreateActivity)
-			activity.PUT("/:id", h.UpdateActivity)
 			activity.GET("/:id", h.GetActivity)
-			activity.POST("/:id/participate", h.Participate)
-			activity.GET("/:id/participation", h.GetParticipation)
+			activity.POST("/:id/participate", authed, RateLimit(h.rateLimiter, h.gameService, activityIDParam), h.Participate)
+			activity.GET("/:id/participation", authed, h.GetParticipation)
+		}
+
+		// 折扣码相关接口
+		discountCode := v1.Group("/discount-code", authed)
+		{
+			discountCode.POST("/redeem", h.RedeemDiscountCode)
 		}
 
 		// 游戏相关接口
-		game := v1.Group("/game")
+		game := v1.Group("/game", authed)
 		{
-			game.POST("/participate", h.ParticipateGame)
+			game.POST("/participate", RateLimit(h.rateLimiter, h.gameService, activityIDBody), h.ParticipateGame)
 			game.GET("/status", h.GetGameStatus)
 			game.GET("/prize", h.GetUserPrize)
 		}
+
+		// 后台管理接口，角色取自令牌声明，每个接口在注册时声明所需权限
+		admin := v1.Group("/admin", authed, AuditMutations(h.auditService))
+		{
+			adminActivity := admin.Group("/activity")
+			{
+				adminActivity.POST("", RequirePermission(auth.PermActivityWrite), h.CreateActivity)
+				adminActivity.PUT("/:id", RequirePermission(auth.PermActivityWrite), h.UpdateActivity)
+				adminActivity.GET("/:id", RequirePermission(auth.PermActivityRead), h.AdminGetActivity)
+				adminActivity.POST("/:id/transitions", RequirePermission(auth.PermActivityWrite), h.TransitionActivity)
+				adminActivity.GET("/:id/transitions", RequirePermission(auth.PermActivityRead), h.ListStatusHistory)
+				adminActivity.POST("/:id/discount-codes/import", RequirePermission(auth.PermPrizeWrite), h.ImportDiscountCodes)
+				adminActivity.POST("/:id/discount-codes/generate", RequirePermission(auth.PermPrizeWrite), h.GenerateDiscountCodes)
+			}
+
+			admin.GET("/audit-logs", RequirePermission(auth.PermAuditRead), h.ListAuditLogs)
+		}
 	}
 }
 
 // @Summary		创建活动
-// @Description	创建一个新的活动
+// @Description	创建一个新的活动，新建的活动为草稿状态，通过状态变更接口提交审核和上线
 // @Tags			活动管理
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			activity	body		CreateActivityRequest	true	"活动信息"
 // @Success		200			{object}	BaseResp{data=CreateActivityResponse}
 // @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
+// @Failure		403			{object}	BaseResp
 // @Failure		500			{object}	BaseResp
-// @Router			/activity [post]
+// @Router			/admin/activity [post]
 func (h *Handler) CreateActivity(c *gin.Context) {
 	var req CreateActivityRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid params",
-		})
+		writeError(c, ErrInvalidParam)
 		return
 	}
 
 	resp, err := h.activityService.CreateActivity(c, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    resp,
-	})
+	writeSuccess(c, resp)
 }
 
 // @Summary		更新活动
-// @Description	更新指定ID的活动信息
+// @Description	部分更新指定ID的活动，只修改传入的字段；需携带If-Match（取自获取活动时的ETag）防止覆盖他人的修改
+// @Description	已发布的活动只允许延长结束时间和增加奖品库存，状态通过状态变更接口修改
 // @Tags			活动管理
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			id			path		string					true	"活动ID"
+// @Param			If-Match	header		string					true	"活动的ETag，传*表示不校验修订号"
 // @Param			activity	body		UpdateActivityRequest	true	"活动信息"
-// @Success		200			{object}	BaseResp{data=UpdateActivityResponse}
+// @Success		200			{object}	BaseResp{data=ActivityResponse}
+// @Header			200			{string}	ETag	"更新后活动的ETag"
 // @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
+// @Failure		403			{object}	BaseResp
+// @Failure		404			{object}	BaseResp
+// @Failure		409			{object}	BaseResp
+// @Failure		412			{object}	BaseResp
+// @Failure		428			{object}	BaseResp
 // @Failure		500			{object}	BaseResp
-// @Router			/activity/{id} [put]
+// @Router			/admin/activity/{id} [put]
 func (h *Handler) UpdateActivity(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
+		return
+	}
+
+	revision, err := parseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
 	var req UpdateActivityRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid params",
-		})
+		writeError(c, ErrInvalidParam)
 		return
 	}
 
-	resp, err := h.activityService.UpdateActivity(c, &req)
+	resp, err := h.activityService.UpdateActivity(c, id, revision, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    resp,
-	})
+	c.Header("ETag", activityETag(resp.Revision))
+	writeSuccess(c, resp)
 }
 
 // @Summary		获取活动信息
@@ -126,240 +162,285 @@ func (h *Handler) UpdateActivity(c *gin.Context) {
 // @Accept			json
 // @Produce		json
 // @Param			id	path		string	true	"活动ID"
-// @Success		200	{object}	BaseResp{data=GetActivityResponse}
+// @Success		200	{object}	BaseResp{data=ActivityResponse}
+// @Header			200	{string}	ETag	"活动的ETag，更新活动时作为If-Match传入"
 // @Failure		400	{object}	BaseResp
+// @Failure		404	{object}	BaseResp
 // @Failure		500	{object}	BaseResp
 // @Router			/activity/{id} [get]
 func (h *Handler) GetActivity(c *gin.Context) {
 	activityID := c.Param("id")
 	if activityID == "" {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "activity_id is required",
-		})
+		writeError(c, ErrInvalidParam.WithDetails("activity_id is required"))
 		return
 	}
 
 	// 将字符串ID转换为int64
 	id, err := strconv.ParseInt(activityID, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid activity_id",
-		})
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
 		return
 	}
 
 	resp, err := h.activityService.GetActivity(c, id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
+		return
+	}
+
+	c.Header("ETag", activityETag(resp.Revision))
+	writeSuccess(c, resp)
+}
+
+// @Summary		后台获取活动信息
+// @Description	后台查看指定ID的活动详细信息，需要activity:read权限
+// @Tags			后台管理
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id	path		string	true	"活动ID"
+// @Success		200	{object}	BaseResp{data=ActivityResponse}
+// @Header			200	{string}	ETag	"活动的ETag，更新活动时作为If-Match传入"
+// @Failure		400	{object}	BaseResp
+// @Failure		401	{object}	BaseResp
+// @Failure		403	{object}	BaseResp
+// @Failure		500	{object}	BaseResp
+// @Router			/admin/activity/{id} [get]
+func (h *Handler) AdminGetActivity(c *gin.Context) {
+	h.GetActivity(c)
+}
+
+// @Summary		变更活动状态
+// @Description	按操作推进活动生命周期：草稿 → 审核中 → 已排期 → 进行中 ⇄ 已暂停 → 已结束 → 已归档
+// @Description	当前状态不允许该操作返回409，不满足前置条件（配置有效、折扣码已导入、活动时间）返回422；暂停后立即停止参与
+// @Tags			活动管理
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id			path		string						true	"活动ID"
+// @Param			transition	body		TransitionActivityRequest	true	"状态变更操作"
+// @Success		200			{object}	BaseResp{data=ActivityResponse}
+// @Header			200			{string}	ETag	"变更后活动的ETag"
+// @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
+// @Failure		403			{object}	BaseResp
+// @Failure		404			{object}	BaseResp
+// @Failure		409			{object}	BaseResp
+// @Failure		412			{object}	BaseResp
+// @Failure		422			{object}	BaseResp
+// @Failure		500			{object}	BaseResp
+// @Router			/admin/activity/{id}/transitions [post]
+func (h *Handler) TransitionActivity(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
+		return
+	}
+
+	var req TransitionActivityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, ErrInvalidParam)
+		return
+	}
+
+	resp, err := h.activityService.TransitionActivity(c, id, currentUser(c).Uid, &req)
+	if err != nil {
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    resp,
-	})
+	c.Header("ETag", activityETag(resp.Revision))
+	writeSuccess(c, resp)
+}
+
+// @Summary		查询活动状态变更记录
+// @Description	按时间顺序返回活动的状态变更记录
+// @Tags			活动管理
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id	path		string	true	"活动ID"
+// @Success		200	{object}	BaseResp{data=ListStatusHistoryResponse}
+// @Failure		400	{object}	BaseResp
+// @Failure		401	{object}	BaseResp
+// @Failure		403	{object}	BaseResp
+// @Failure		404	{object}	BaseResp
+// @Failure		500	{object}	BaseResp
+// @Router			/admin/activity/{id}/transitions [get]
+func (h *Handler) ListStatusHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
+		return
+	}
+
+	resp, err := h.activityService.ListStatusHistory(c, id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeSuccess(c, resp)
 }
 
 // @Summary		参与活动
-// @Description	用户参与指定活动
+// @Description	报名参加指定活动，重复报名返回已有记录；返回活动下的玩法及当前用户是否可参与
 // @Tags			活动管理
 // @Accept			json
 // @Produce		json
-// @Param			id				path		string				true	"活动ID"
-// @Param			participation	body		ParticipateRequest	true	"参与信息"
-// @Success		200				{object}	BaseResp{data=ParticipateResponse}
-// @Failure		400				{object}	BaseResp
-// @Failure		500				{object}	BaseResp
+// @Security		ApiKeyAuth
+// @Param			id	path		string	true	"活动ID"
+// @Success		200	{object}	BaseResp{data=ParticipateResponse}
+// @Failure		400	{object}	BaseResp
+// @Failure		401	{object}	BaseResp
+// @Failure		403	{object}	BaseResp
+// @Failure		404	{object}	BaseResp
+// @Failure		409	{object}	BaseResp
+// @Failure		429	{object}	BaseResp
+// @Failure		500	{object}	BaseResp
 // @Router			/activity/{id}/participate [post]
 func (h *Handler) Participate(c *gin.Context) {
-	var req ParticipateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid params",
-		})
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
 		return
 	}
 
-	resp, err := h.activityService.Participate(c, &req)
+	req := ParticipateRequest{
+		UserID: currentUser(c).Uid,
+	}
+
+	resp, err := h.activityService.Participate(c, id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    resp,
-	})
+	writeSuccess(c, resp)
 }
 
 // @Summary		获取参与记录
-// @Description	获取用户在指定活动中的参与记录
+// @Description	分页获取用户在指定活动中的参与记录，按参与时间倒序
 // @Tags			活动管理
 // @Accept			json
 // @Produce		json
-// @Param			id		path		string	true	"活动ID"
-// @Param			user_id	query		string	true	"用户ID"
-// @Success		200		{object}	BaseResp{data=GetParticipationResponse}
-// @Failure		400		{object}	BaseResp
-// @Failure		500		{object}	BaseResp
+// @Security		ApiKeyAuth
+// @Param			id			path		string	true	"活动ID"
+// @Param			page		query		int		false	"页码，默认1"
+// @Param			page_size	query		int		false	"每页数量，默认20，最大100"
+// @Success		200			{object}	BaseResp{data=GetParticipationResponse}
+// @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
+// @Failure		500			{object}	BaseResp
 // @Router			/activity/{id}/participation [get]
 func (h *Handler) GetParticipation(c *gin.Context) {
 	activityID := c.Param("id")
 	if activityID == "" {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "activity_id is required",
-		})
+		writeError(c, ErrInvalidParam.WithDetails("activity_id is required"))
 		return
 	}
 
 	// 将字符串ID转换为int64
 	id, err := strconv.ParseInt(activityID, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid activity_id",
-		})
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
 		return
 	}
 
-	userID := c.Query("user_id")
-	if userID == "" {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "user_id is required",
-		})
+	var req GetParticipationReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeError(c, ErrInvalidParam)
 		return
 	}
+	req.UserID = currentUser(c).Uid
 
-	resp, err := h.activityService.GetParticipation(c, id, userID)
+	resp, err := h.activityService.GetParticipation(c, id, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    resp,
-	})
+	writeSuccess(c, resp)
 }
 
 // @Summary		参与玩法
-// @Description	用户参与指定玩法
+// @Description	用户参与指定玩法；携带Idempotency-Key请求头或request_id时，相同用户和幂等键的重试直接返回首次成功的响应，不会再次执行玩法；执行玩法前进行风控检查，被拒绝或需要验证时返回403；响应中result的结构由game_type决定，见与玩法类型同名的定义
 // @Tags			玩法管理
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
+// @Param			Idempotency-Key	header		string				false	"幂等键，最长64个字符"
+// @Param			X-Device-ID		header		string				false	"设备指纹，用于风控"
 // @Param			participation	body		ParticipateGameReq	true	"参与信息"
 // @Success		200				{object}	BaseResp{data=ParticipateGameResponse}
 // @Failure		400				{object}	BaseResp
+// @Failure		401				{object}	BaseResp
+// @Failure		403				{object}	BaseResp
+// @Failure		404				{object}	BaseResp
+// @Failure		409				{object}	BaseResp
+// @Failure		422				{object}	BaseResp
+// @Failure		429				{object}	BaseResp
 // @Failure		500				{object}	BaseResp
 // @Router			/game/participate [post]
 func (h *Handler) ParticipateGame(c *gin.Context) {
 	var req ParticipateGameReq
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid params",
-		})
+		writeError(c, ErrInvalidParam)
 		return
 	}
 
-	// 获取用户信息（实际项目中应该从中间件获取）
-	user := models.User{
-		Uid: c.GetString("uid"),
+	// 幂等键以请求头为准，未携带时使用请求体中的request_id
+	requestID := c.GetHeader("Idempotency-Key")
+	if requestID == "" {
+		requestID = req.RequestID
 	}
-
-	// 根据玩法类型创建对应的动作
-	var action models.ActionInterface
-	switch req.GameName {
-	case "post":
-		action = &models.CommunityPostAction{
-			// PostID: req.PostID,
-		}
-	case "checkin":
-		action = &models.CheckinAction{
-			CheckinTime: time.Now(),
-		}
-	default:
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "unsupported game type",
-		})
+	if len(requestID) > maxIdempotencyKeyLen {
+		writeError(c, ErrInvalidParam.WithDetails("idempotency key is too long"))
 		return
 	}
 
-	// 执行玩法逻辑
-	result, err := h.gameService.ParticipateGame(c, user, req.ActivityID, req.GameName, action)
+	// 用户身份由认证中间件注入
+	user := currentUser(c)
+
+	// 执行玩法逻辑，动作由服务按玩法声明的类型解析
+	result, err := h.gameService.ParticipateGame(c, user, req.ActivityID, req.GameName, requestID, req.Action)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    result,
-	})
+	writeSuccess(c, result)
 }
 
 // @Summary		获取玩法状态
-// @Description	获取用户在指定玩法中的状态
+// @Description	获取玩法状态以及当前用户在该玩法中的参与状态和参与次数
 // @Tags			玩法管理
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			activity_id	query		string	true	"活动ID"
 // @Param			game_name	query		string	true	"玩法名称"
-// @Success		200			{object}	BaseResp{data=GetGameStatusResponse}
+// @Success		200			{object}	BaseResp{data=GameStatusResp}
 // @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
 // @Failure		500			{object}	BaseResp
 // @Router			/game/status [get]
 func (h *Handler) GetGameStatus(c *gin.Context) {
 	var req GetGameStatusReq
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid params",
-		})
+		writeError(c, ErrInvalidParam)
 		return
 	}
 
-	// 获取用户信息（实际项目中应该从中间件获取）
-	user := models.User{
-		Uid: c.GetString("uid"),
-	}
+	// 用户身份由认证中间件注入
+	user := currentUser(c)
 
 	resp, err := h.gameService.GetGameStatus(c, user, req.ActivityID, req.GameName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    resp,
-	})
+	writeSuccess(c, resp)
 }
 
 // @Summary		获取用户奖品
@@ -367,39 +448,196 @@ func (h *Handler) GetGameStatus(c *gin.Context) {
 // @Tags			玩法管理
 // @Accept			json
 // @Produce		json
+// @Security		ApiKeyAuth
 // @Param			activity_id	query		string	true	"活动ID"
 // @Param			game_name	query		string	true	"玩法名称"
 // @Success		200			{object}	BaseResp{data=GetUserPrizeResponse}
 // @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
 // @Failure		500			{object}	BaseResp
 // @Router			/game/prize [get]
 func (h *Handler) GetUserPrize(c *gin.Context) {
 	var req GetUserPrizeReq
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, BaseResp{
-			Code:    constant.ErrInvalidParam,
-			Message: "invalid params",
-		})
+		writeError(c, ErrInvalidParam)
 		return
 	}
 
-	// 获取用户信息（实际项目中应该从中间件获取）
-	user := models.User{
-		Uid: c.GetString("uid"),
-	}
+	// 用户身份由认证中间件注入
+	user := currentUser(c)
 
 	resp, err := h.gameService.GetUserPrize(c, user, req.ActivityID, req.GameName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, BaseResp{
-			Code:    constant.ErrSystem,
-			Message: err.Error(),
-		})
+		writeError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, BaseResp{
-		Code:    0,
-		Message: "success",
-		Data:    resp,
-	})
+	writeSuccess(c, resp)
+}
+
+// @Summary		导入折扣码
+// @Description	从CSV文件导入折扣码到奖品的码池，每行第一列为折扣码
+// @Tags			奖品管理
+// @Accept			multipart/form-data
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id			path		string	true	"活动ID"
+// @Param			prize_key	formData	string	true	"奖品库存标识"
+// @Param			expires_at	formData	int		false	"过期时间戳"
+// @Param			file		formData	file	true	"折扣码CSV文件"
+// @Success		200			{object}	BaseResp{data=DiscountCodePoolResponse}
+// @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
+// @Failure		403			{object}	BaseResp
+// @Failure		500			{object}	BaseResp
+// @Router			/admin/activity/{id}/discount-codes/import [post]
+func (h *Handler) ImportDiscountCodes(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
+		return
+	}
+
+	prizeKey := c.PostForm("prize_key")
+	expiresAt, _ := strconv.ParseInt(c.DefaultPostForm("expires_at", "0"), 10, 64)
+	fileHeader, err := c.FormFile("file")
+	if err != nil || prizeKey == "" {
+		writeError(c, ErrInvalidParam)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		writeError(c, ErrInvalidParam.WithDetails("invalid file"))
+		return
+	}
+	defer file.Close()
+
+	resp, err := h.activityService.ImportDiscountCodes(c, id, prizeKey, file, expiresAt)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeSuccess(c, resp)
+}
+
+// @Summary		生成折扣码
+// @Description	按奖品配置的折扣码前缀生成随机折扣码到码池
+// @Tags			奖品管理
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			id		path		string							true	"活动ID"
+// @Param			request	body		GenerateDiscountCodesRequest	true	"生成参数"
+// @Success		200		{object}	BaseResp{data=DiscountCodePoolResponse}
+// @Failure		400		{object}	BaseResp
+// @Failure		401		{object}	BaseResp
+// @Failure		403		{object}	BaseResp
+// @Failure		500		{object}	BaseResp
+// @Router			/admin/activity/{id}/discount-codes/generate [post]
+func (h *Handler) GenerateDiscountCodes(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		writeError(c, ErrInvalidParam.WithDetails("invalid activity_id"))
+		return
+	}
+
+	var req GenerateDiscountCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, ErrInvalidParam)
+		return
+	}
+
+	resp, err := h.activityService.GenerateDiscountCodes(c, id, &req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeSuccess(c, resp)
+}
+
+// @Summary		核销折扣码
+// @Description	核销用户已获得的折扣码
+// @Tags			奖品管理
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			request	body		RedeemDiscountCodeRequest	true	"核销参数"
+// @Success		200		{object}	BaseResp{data=RedeemDiscountCodeResponse}
+// @Failure		400		{object}	BaseResp
+// @Failure		401		{object}	BaseResp
+// @Failure		500		{object}	BaseResp
+// @Router			/discount-code/redeem [post]
+func (h *Handler) RedeemDiscountCode(c *gin.Context) {
+	var req RedeemDiscountCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, ErrInvalidParam)
+		return
+	}
+
+	resp, err := h.activityService.RedeemDiscountCode(c, currentUser(c).Uid, req.Code)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeSuccess(c, resp)
+}
+
+// @Summary		查询后台操作日志
+// @Description	分页查询后台操作日志，按时间倒序，需要audit:read权限
+// @Tags			后台管理
+// @Accept			json
+// @Produce		json
+// @Security		ApiKeyAuth
+// @Param			actor		query		string	false	"操作人用户ID"
+// @Param			page		query		int		false	"页码，默认1"
+// @Param			page_size	query		int		false	"每页数量，默认20，最大100"
+// @Success		200			{object}	BaseResp{data=ListAuditLogsResponse}
+// @Failure		400			{object}	BaseResp
+// @Failure		401			{object}	BaseResp
+// @Failure		403			{object}	BaseResp
+// @Failure		500			{object}	BaseResp
+// @Router			/admin/audit-logs [get]
+func (h *Handler) ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeError(c, ErrInvalidParam)
+		return
+	}
+
+	resp, err := h.auditService.ListAuditLogs(c, &req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	writeSuccess(c, resp)
+}
+
+// activityETag 根据修订号生成活动的ETag
+func activityETag(revision int64) string {
+	return fmt.Sprintf("%q", strconv.FormatInt(revision, 10))
+}
+
+// maxIdempotencyKeyLen 幂等键的最大长度，与参与请求表的字段长度一致
+const maxIdempotencyKeyLen = 64
+
+// parseIfMatch 解析If-Match中的修订号，"*" 返回nil表示不校验修订号
+func parseIfMatch(header string) (*int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil, ErrPreconditionRequired
+	}
+	if header == "*" {
+		return nil, nil
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	revision, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil {
+		return nil, ErrActivityModified
+	}
+	return &revision, nil
 }
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"Activity/auth"
+	"Activity/models"
+	"Activity/ratelimit"
+	"Activity/storage/mysql/entity"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ContextKeyUID 认证通过后写入gin上下文的用户ID
+	ContextKeyUID = "uid"
+	// ContextKeyClaims 认证通过后写入gin上下文的身份声明
+	ContextKeyClaims = "claims"
+	// HeaderDeviceID 客户端上报设备指纹的请求头
+	HeaderDeviceID = "X-Device-ID"
+)
+
+// AuthRequired 认证中间件，从Authorization头解析令牌，校验失败时返回401
+func AuthRequired(verifier auth.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimSpace(c.GetHeader("Authorization"))
+		// 兼容 "Bearer <token>" 与直接传入令牌两种写法
+		if len(token) > 7 && strings.EqualFold(token[:7], "Bearer ") {
+			token = strings.TrimSpace(token[7:])
+		}
+		if token == "" {
+			abortUnauthorized(c)
+			return
+		}
+
+		claims, err := verifier.Verify(c, token)
+		if err != nil {
+			abortUnauthorized(c)
+			return
+		}
+
+		c.Set(ContextKeyUID, claims.Subject)
+		c.Set(ContextKeyClaims, claims)
+		c.Next()
+	}
+}
+
+// RequirePermission 权限校验中间件，需在AuthRequired之后使用，角色取自令牌声明
+func RequirePermission(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := currentClaims(c)
+		if claims == nil {
+			abortUnauthorized(c)
+			return
+		}
+		if !claims.HasPermission(perm) {
+			writeError(c, ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuditMutations 后台操作日志中间件，记录所有非GET请求的操作人和处理结果
+func AuditMutations(auditService AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			return
+		}
+		entry := &entity.AdminAuditLog{
+			Actor:      c.GetString(ContextKeyUID),
+			Method:     c.Request.Method,
+			Path:       c.FullPath(),
+			ResourceID: c.Param("id"),
+			StatusCode: c.Writer.Status(),
+			ClientIP:   c.ClientIP(),
+		}
+		if claims := currentClaims(c); claims != nil {
+			entry.Roles = strings.Join(claims.Roles, ",")
+		}
+		// 日志写入失败不影响已经完成的操作
+		if err := auditService.Record(c, entry); err != nil {
+			log.Printf("failed to record admin audit log: actor=%s method=%s path=%s err=%v", entry.Actor, entry.Method, entry.Path, err)
+		}
+	}
+}
+
+// RateLimiter 参与接口的限流器，按活动配置的规则检查用户、IP和活动三个令牌桶
+type RateLimiter struct {
+	limiter  ratelimit.Limiter
+	defaults *models.RateLimitConfig
+}
+
+// NewRateLimiter 创建参与接口的限流器，defaults为活动未配置时使用的默认规则
+func NewRateLimiter(limiter ratelimit.Limiter, defaults *models.RateLimitConfig) *RateLimiter {
+	return &RateLimiter{limiter: limiter, defaults: defaults}
+}
+
+// RateLimit 参与接口的限流中间件，需在AuthRequired之后使用，activityID从请求中取出活动ID
+// 令牌不足时返回429，并在Retry-After中给出建议的重试秒数；限流器为nil时不限流，限流器出错时放行并记录日志
+func RateLimit(limiter *RateLimiter, gameService GameService, activityID func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := activityID(c)
+		if limiter == nil || id == "" {
+			c.Next()
+			return
+		}
+
+		// 活动不存在等错误由处理器返回，这里按默认规则限流
+		config, err := gameService.GetRateLimit(c, id)
+		if err != nil {
+			config = nil
+		}
+		rules := config.Merge(limiter.defaults)
+
+		// 先检查用户和IP，被拒绝的请求不消耗整个活动的令牌
+		scopes := []struct {
+			key  string
+			rule *models.RateLimitRule
+		}{
+			{fmt.Sprintf("activity:{%s}:user:%s", id, c.GetString(ContextKeyUID)), rules.User},
+			{fmt.Sprintf("activity:{%s}:ip:%s", id, c.ClientIP()), rules.IP},
+			{fmt.Sprintf("activity:{%s}", id), rules.Activity},
+		}
+		for _, scope := range scopes {
+			if !scope.rule.Enabled() {
+				continue
+			}
+			allowed, wait, err := limiter.limiter.Allow(c, scope.key, ratelimit.Rule{Rate: scope.rule.Rate, Burst: scope.rule.Burst})
+			if err != nil {
+				log.Printf("rate limit check failed: key=%s err=%v", scope.key, err)
+				continue
+			}
+			if !allowed {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeError(c, ErrTooManyRequests)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// activityIDParam 从路径参数中取活动ID
+func activityIDParam(c *gin.Context) string {
+	return c.Param("id")
+}
+
+// activityIDBody 从请求体的activity_id中取活动ID，读取后恢复请求体，供处理器再次绑定
+func activityIDBody(c *gin.Context) string {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var req struct {
+		ActivityID string `json:"activity_id"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.ActivityID
+}
+
+// abortUnauthorized 中断请求并返回未认证错误
+func abortUnauthorized(c *gin.Context) {
+	writeError(c, ErrUnauthorized)
+}
+
+// currentClaims 返回认证中间件写入的身份声明，未认证时返回nil
+func currentClaims(c *gin.Context) *auth.Claims {
+	value, ok := c.Get(ContextKeyClaims)
+	if !ok {
+		return nil
+	}
+	claims, _ := value.(*auth.Claims)
+	return claims
+}
+
+// currentUser 返回认证中间件写入的当前用户，附带风控使用的设备指纹、IP和注册时间
+func currentUser(c *gin.Context) models.User {
+	user := models.User{
+		Uid:      c.GetString(ContextKeyUID),
+		DeviceID: c.GetHeader(HeaderDeviceID),
+		IP:       c.ClientIP(),
+	}
+	if claims := currentClaims(c); claims != nil && claims.RegisteredAt > 0 {
+		user.RegisteredAt = time.Unix(claims.RegisteredAt, 0)
+	}
+	return user
+}
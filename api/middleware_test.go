@@ -0,0 +1,131 @@
+package api
+
+import (
+	"Activity/auth"
+	"Activity/models"
+	"Activity/ratelimit"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthRequired(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	verifier := auth.NewHMACVerifier([]byte("local-secret"), "activity")
+	sign := func(v *auth.HMACVerifier, claims auth.Claims) string {
+		token, err := v.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	now := time.Now()
+	valid := sign(verifier, auth.Claims{Subject: "u1", ExpiresAt: now.Add(time.Hour).Unix()})
+
+	r := gin.New()
+	r.GET("/me", AuthRequired(verifier), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextKeyUID))
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{"有效令牌", "Bearer " + valid, http.StatusOK},
+		{"不带Bearer前缀", valid, http.StatusOK},
+		{"缺少令牌", "", http.StatusUnauthorized},
+		{"只有Bearer前缀", "Bearer ", http.StatusUnauthorized},
+		{"令牌已过期", "Bearer " + sign(verifier, auth.Claims{Subject: "u1", ExpiresAt: now.Add(-time.Minute).Unix()}), http.StatusUnauthorized},
+		{"缺少exp", "Bearer " + sign(verifier, auth.Claims{Subject: "u1"}), http.StatusUnauthorized},
+		{"签名密钥不一致", "Bearer " + sign(auth.NewHMACVerifier([]byte("other-secret"), "activity"), auth.Claims{Subject: "u1", ExpiresAt: now.Add(time.Hour).Unix()}), http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if w.Body.String() != "u1" {
+					t.Errorf("uid = %q, want u1", w.Body.String())
+				}
+				return
+			}
+			var resp BaseResp
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if resp.Code != ErrUnauthorized.Code {
+				t.Errorf("code = %d, want %d", resp.Code, ErrUnauthorized.Code)
+			}
+		})
+	}
+}
+
+// defaultRateLimit 所有活动都使用默认限流规则的玩法服务
+type defaultRateLimit struct {
+	GameService
+}
+
+func (defaultRateLimit) GetRateLimit(ctx context.Context, activityID string) (*models.RateLimitConfig, error) {
+	return nil, nil
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantStatus     int
+	}{
+		// 未配置可信代理时按连接的对端地址计数，伪造的请求头不会换到新的令牌桶
+		{"未配置可信代理", nil, http.StatusTooManyRequests},
+		// 请求来自可信代理时按转发的客户端IP计数
+		{"来自可信代理", []string{"10.0.0.1"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(ratelimit.NewMemoryLimiter(), &models.RateLimitConfig{
+				IP: &models.RateLimitRule{Rate: 0.001, Burst: 1},
+			})
+			r := gin.New()
+			if err := r.SetTrustedProxies(tt.trustedProxies); err != nil {
+				t.Fatal(err)
+			}
+			r.POST("/activity/:id/participate",
+				func(c *gin.Context) { c.Set(ContextKeyUID, c.GetHeader("X-Forwarded-For")) },
+				RateLimit(limiter, defaultRateLimit{}, activityIDParam),
+				func(c *gin.Context) { c.Status(http.StatusOK) },
+			)
+
+			participate := func(forwardedFor string) int {
+				req := httptest.NewRequest(http.MethodPost, "/activity/1/participate", nil)
+				req.RemoteAddr = "10.0.0.1:40000"
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				return w.Code
+			}
+			if status := participate("203.0.113.1"); status != http.StatusOK {
+				t.Fatalf("first request status = %d, want 200", status)
+			}
+			if status := participate("203.0.113.2"); status != tt.wantStatus {
+				t.Errorf("spoofed request status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
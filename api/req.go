@@ -1,6 +1,8 @@
 package api
 
 import (
+	"Activity/models"
+	"encoding/json"
 	"time"
 )
 
@@ -23,8 +25,12 @@ type CreateActivityRequest struct {
 	StartAt int64 `json:"start_at" binding:"required"`
 	// @Description 活动结束时间
 	EndAt int64 `json:"end_at" binding:"required"`
-	// @Description 活动状态
-	Status int `json:"status" binding:"required"`
+	// @Description 玩法配置列表，结构同活动配置中的games
+	Games []models.GameConfig `json:"games" binding:"required"`
+	// @Description 报名配置，为空表示无需报名即可参与玩法
+	Enrollment *models.EnrollmentConfig `json:"enrollment"`
+	// @Description 参与接口的限流配置，为空时使用默认规则
+	RateLimit *models.RateLimitConfig `json:"rate_limit"`
 }
 
 // CreateActivityResponse 创建活动响应
@@ -34,21 +40,48 @@ type CreateActivityResponse struct {
 	ID int64 `json:"id"`
 }
 
-// UpdateActivityRequest 更新活动请求
-// @Description 更新活动请求参数
+// UpdateActivityRequest 更新活动请求，只更新传入的字段
+// @Description 更新活动请求参数，未传入的字段保持不变；已发布的活动只允许延长结束时间和增加奖品库存，状态通过状态变更接口修改
 type UpdateActivityRequest struct {
 	// @Description 活动名称
-	Name string `json:"name"`
-	// @Description 活动类型
-	Category string `json:"category"`
-	// @Description 活动版本
-	Version string `json:"version"`
+	Name *string `json:"name"`
 	// @Description 活动开始时间
-	StartAt int64 `json:"start_at"`
+	StartAt *int64 `json:"start_at"`
 	// @Description 活动结束时间
-	EndAt int64 `json:"end_at"`
-	// @Description 活动状态
-	Status int `json:"status"`
+	EndAt *int64 `json:"end_at"`
+	// @Description 玩法配置列表，为空时保留原配置
+	Games []models.GameConfig `json:"games"`
+	// @Description 报名配置，为空时保留原配置
+	Enrollment *models.EnrollmentConfig `json:"enrollment"`
+	// @Description 参与接口的限流配置，为空时保留原配置
+	RateLimit *models.RateLimitConfig `json:"rate_limit"`
+}
+
+// TransitionActivityRequest 变更活动状态请求
+// @Description 变更活动状态请求参数
+type TransitionActivityRequest struct {
+	// @Description 操作：submit-提交审核，reject-驳回，approve-审核通过，publish-上线，pause-暂停，resume-恢复，end-结束，archive-归档
+	Event string `json:"event" binding:"required,oneof=submit reject approve publish pause resume end archive"`
+	// @Description 变更原因
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// ListStatusHistoryResponse 查询活动状态变更记录响应
+// @Description 查询活动状态变更记录响应数据
+type ListStatusHistoryResponse struct {
+	// @Description 状态变更记录，按时间顺序
+	List []*StatusHistoryResponse `json:"list"`
+}
+
+// StatusHistoryResponse 活动状态变更记录
+type StatusHistoryResponse struct {
+	ID         int64     `json:"id"`
+	Event      string    `json:"event"`       // 触发变更的操作
+	FromStatus string    `json:"from_status"` // 变更前状态
+	ToStatus   string    `json:"to_status"`   // 变更后状态
+	Operator   string    `json:"operator"`    // 操作人用户ID，系统触发时为空
+	Reason     string    `json:"reason"`      // 变更原因
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // UpdateActivityResponse 更新活动响应
@@ -80,26 +113,62 @@ type GetActivityResponse struct {
 // ParticipateRequest 参与活动请求
 // @Description 参与活动请求参数
 type ParticipateRequest struct {
-	// @Description 用户ID
-	UserID string `json:"user_id" binding:"required"`
+	// 用户ID，由认证中间件注入，不接受客户端传入
+	UserID string `json:"-"`
 }
 
 // ParticipateResponse 参与活动响应
 // @Description 参与活动响应数据
 type ParticipateResponse struct {
-	// @Description 参与ID
-	ParticipationID int64 `json:"participation_id"`
+	// @Description 报名记录ID
+	EnrollmentID int64 `json:"enrollment_id"`
+	// @Description 活动ID
+	ActivityID int64 `json:"activity_id"`
+	// @Description 是否此前已经报名，重复报名不会占用名额
+	AlreadyEnrolled bool `json:"already_enrolled"`
+	// @Description 报名时间
+	EnrolledAt time.Time `json:"enrolled_at"`
+	// @Description 活动下的玩法及当前用户可参与的状态
+	Games []*PlayableGameResponse `json:"games"`
+}
+
+// PlayableGameResponse 用户可参与的玩法
+// @Description 玩法状态及当前用户是否可参与
+type PlayableGameResponse struct {
+	// @Description 玩法名称
+	Name string `json:"name"`
+	// @Description 玩法类型
+	Type string `json:"type"`
+	// @Description 玩法状态
+	GameState string `json:"game_state"`
+	// @Description 当前用户状态
+	UserState string `json:"user_state"`
+	// @Description 当前用户是否可以参与
+	Playable bool `json:"playable"`
+}
+
+// GetParticipationReq 获取参与记录请求
+// @Description 获取参与记录请求参数
+type GetParticipationReq struct {
+	// 用户ID，由认证中间件注入，不接受客户端传入
+	UserID string `form:"-"`
+	// @Description 页码，从1开始
+	Page int `form:"page,default=1" binding:"min=1"`
+	// @Description 每页数量
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
 }
 
 // GetParticipationResponse 获取参与记录响应
 // @Description 获取参与记录响应数据
 type GetParticipationResponse struct {
-	// @Description 参与ID
-	ParticipationID int64 `json:"participation_id"`
-	// @Description 用户ID
-	UserID string `json:"user_id"`
-	// @Description 参与时间
-	CreatedAt int64 `json:"created_at"`
+	// @Description 参与记录，按参与时间倒序
+	List []*ParticipationResponse `json:"list"`
+	// @Description 记录总数
+	Total int64 `json:"total"`
+	// @Description 页码
+	Page int `json:"page"`
+	// @Description 每页数量
+	PageSize int `json:"page_size"`
 }
 
 // ParticipateGameReq 参与玩法请求
@@ -107,21 +176,27 @@ type GetParticipationResponse struct {
 type ParticipateGameReq struct {
 	// @Description 活动ID
 	ActivityID string `json:"activity_id" binding:"required"`
-	// @Description 玩法名称
+	// @Description 玩法名称，活动内唯一，不是玩法类型
 	GameName string `json:"game_name" binding:"required"`
-	// @Description 用户ID
-	UserID string `json:"user_id" binding:"required"`
+	// @Description 客户端请求ID，作为幂等键使用，Idempotency-Key请求头优先
+	RequestID string `json:"request_id" binding:"max=64"`
+	// @Description 玩法动作，结构由玩法类型决定，如发帖玩法为 {"post_id": "..."}，签到和抽奖可不传
+	Action json.RawMessage `json:"action" swaggertype:"object"`
 }
 
-// ParticipateGameResponse 参与玩法响应
-// @Description 参与玩法响应数据
+// ParticipateGameResponse 参与玩法响应，result的结构由game_type决定
+// @Description 参与玩法响应数据，按game_type区分result的结构
 type ParticipateGameResponse struct {
-	// @Description 是否参与成功
-	Success bool `json:"success"`
+	// @Description 玩法名称
+	GameName string `json:"game_name"`
+	// @Description 玩法类型，决定result的结构
+	GameType string `json:"game_type"`
 	// @Description 是否获得奖品
-	HasPrize bool `json:"has_prize"`
-	// @Description 奖品信息
+	Won bool `json:"won"`
+	// @Description 获得的奖品，未获得奖品时为空
 	Prize *PrizeInfo `json:"prize,omitempty"`
+	// @Description 玩法结果，结构由玩法的Results声明
+	Result interface{} `json:"result" swaggertype:"object"`
 }
 
 // GetGameStatusReq 获取玩法状态请求
@@ -154,34 +229,73 @@ type GetUserPrizeReq struct {
 // GetUserPrizeResponse 获取用户奖品响应
 // @Description 获取用户奖品响应数据
 type GetUserPrizeResponse struct {
-	// @Description 奖品列表
-	Prizes []*PrizeInfo `json:"prizes"`
+	// @Description 奖品列表，按获得时间倒序
+	Prizes []*UserPrizeResp `json:"prizes"`
+}
+
+// GenerateDiscountCodesRequest 生成折扣码请求
+// @Description 按奖品配置的前缀生成折扣码并放入码池
+type GenerateDiscountCodesRequest struct {
+	// @Description 奖品库存标识，单奖品玩法为玩法名称
+	PrizeKey string `json:"prize_key" binding:"required"`
+	// @Description 生成数量
+	Count int `json:"count" binding:"required,min=1,max=100000"`
+	// @Description 过期时间戳，为0表示不过期
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// DiscountCodePoolResponse 折扣码池变更响应
+// @Description 折扣码导入或生成结果
+type DiscountCodePoolResponse struct {
+	// @Description 本次写入码池的数量
+	Imported int64 `json:"imported"`
+	// @Description 因重复被跳过的数量
+	Skipped int64 `json:"skipped"`
+	// @Description 码池中当前可分配的数量
+	Available int64 `json:"available"`
+}
+
+// RedeemDiscountCodeRequest 核销折扣码请求
+// @Description 核销折扣码请求参数
+type RedeemDiscountCodeRequest struct {
+	// @Description 折扣码
+	Code string `json:"code" binding:"required"`
+}
+
+// RedeemDiscountCodeResponse 核销折扣码响应
+// @Description 核销折扣码响应数据
+type RedeemDiscountCodeResponse struct {
+	// @Description 是否核销成功
+	Success bool `json:"success"`
 }
 
 // ActivityResponse 活动响应
 type ActivityResponse struct {
-	ID        int64     `json:"id"`
-	Category  string    `json:"category"`
-	Version   string    `json:"version"`
-	Name      string    `json:"name"`
-	Config    string    `json:"config"`
-	StartAt   int64     `json:"start_at"`
-	EndAt     int64     `json:"end_at"`
-	Status    int64     `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int64     `json:"id"`
+	Category   string    `json:"category"`
+	Version    string    `json:"version"`
+	Name       string    `json:"name"`
+	Config     string    `json:"config"`
+	StartAt    int64     `json:"start_at"`
+	EndAt      int64     `json:"end_at"`
+	Status     int64     `json:"status"`      // 活动状态：0-草稿，1-进行中，2-审核中，3-已排期，4-已暂停，5-已结束，6-已归档
+	StatusName string    `json:"status_name"` // 活动状态名称
+	Revision   int64     `json:"revision"`    // 修订号，同时通过ETag响应头返回
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // ParticipationResponse 参与记录响应
 type ParticipationResponse struct {
-	ID         int64     `json:"id"`
-	ActivityID int64     `json:"activity_id"`
-	UserID     string    `json:"user_id"`
-	GameType   string    `json:"game_type"`
-	GameTarget string    `json:"game_target"`
-	State      string    `json:"state"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID         int64           `json:"id"`
+	ActivityID int64           `json:"activity_id"`
+	UserID     string          `json:"user_id"`
+	GameType   string          `json:"game_type"`
+	GameTarget string          `json:"game_target"`
+	State      string          `json:"state"`
+	Extra      json.RawMessage `json:"extra" swaggertype:"object"` // 玩法返回的结果
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
 }
 
 // PrizeResponse 奖品响应
@@ -207,12 +321,13 @@ type (
 
 	// GameStatusResp 玩法状态响应
 	GameStatusResp struct {
-		GameState string    `json:"game_state"` // 玩法状态
-		UserState string    `json:"user_state"` // 用户状态
-		StartTime time.Time `json:"start_time"` // 开始时间
-		EndTime   time.Time `json:"end_time"`   // 结束时间
-		RemainNum int64     `json:"remain_num"` // 剩余奖品数量
-		TotalNum  int64     `json:"total_num"`  // 总奖品数量
+		GameState          string    `json:"game_state"`          // 玩法状态
+		UserState          string    `json:"user_state"`          // 当前用户状态
+		ParticipationCount int64     `json:"participation_count"` // 当前用户已参与次数
+		StartTime          time.Time `json:"start_time"`          // 开始时间
+		EndTime            time.Time `json:"end_time"`            // 结束时间
+		RemainNum          int64     `json:"remain_num"`          // 剩余奖品数量
+		TotalNum           int64     `json:"total_num"`           // 总奖品数量
 	}
 
 	// PrizeInfo 奖品信息
@@ -230,3 +345,40 @@ type (
 		CreatedAt time.Time `json:"created_at"` // 获得时间
 	}
 )
+
+// ListAuditLogsReq 查询后台操作日志请求
+// @Description 查询后台操作日志请求参数
+type ListAuditLogsReq struct {
+	// @Description 操作人用户ID，为空时查询全部
+	Actor string `form:"actor"`
+	// @Description 页码，从1开始
+	Page int `form:"page,default=1" binding:"min=1"`
+	// @Description 每页数量
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ListAuditLogsResponse 查询后台操作日志响应
+// @Description 查询后台操作日志响应数据
+type ListAuditLogsResponse struct {
+	// @Description 操作日志，按时间倒序
+	List []*AuditLogResponse `json:"list"`
+	// @Description 记录总数
+	Total int64 `json:"total"`
+	// @Description 页码
+	Page int `json:"page"`
+	// @Description 每页数量
+	PageSize int `json:"page_size"`
+}
+
+// AuditLogResponse 后台操作日志
+type AuditLogResponse struct {
+	ID         int64     `json:"id"`
+	Actor      string    `json:"actor"`       // 操作人用户ID
+	Roles      string    `json:"roles"`       // 操作人角色，逗号分隔
+	Method     string    `json:"method"`      // HTTP方法
+	Path       string    `json:"path"`        // 路由模板
+	ResourceID string    `json:"resource_id"` // 操作的资源ID
+	StatusCode int       `json:"status_code"` // HTTP响应状态码
+	ClientIP   string    `json:"client_ip"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"Activity/constant"
+	"Activity/i18n"
+	"Activity/models"
+	"Activity/storage/mysql/repository"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusByCode 错误码对应的HTTP状态码，未列出的错误码按500处理
+var statusByCode = map[int]int{
+	constant.ErrInvalidParam:            http.StatusBadRequest,
+	constant.ErrActivityNotFound:        http.StatusNotFound,
+	constant.ErrActivityEnded:           http.StatusConflict,
+	constant.ErrActivityNotStarted:      http.StatusConflict,
+	constant.ErrGameNotFound:            http.StatusNotFound,
+	constant.ErrGameClosed:              http.StatusConflict,
+	constant.ErrUserAlreadyParticipated: http.StatusConflict,
+	constant.ErrPrizeStockEmpty:         http.StatusConflict,
+	constant.ErrUserNotPosted:           http.StatusUnprocessableEntity,
+	constant.ErrUserNotCheckedIn:        http.StatusUnprocessableEntity,
+	constant.ErrDiscountCodeInvalid:     http.StatusBadRequest,
+	constant.ErrUnauthorized:            http.StatusUnauthorized,
+	constant.ErrForbidden:               http.StatusForbidden,
+	constant.ErrActivityFull:            http.StatusConflict,
+	constant.ErrNotEligible:             http.StatusForbidden,
+	constant.ErrNotEnrolled:             http.StatusForbidden,
+	constant.ErrPreconditionRequired:    http.StatusPreconditionRequired,
+	constant.ErrActivityModified:        http.StatusPreconditionFailed,
+	constant.ErrOnlineChangeForbidden:   http.StatusConflict,
+	constant.ErrActivityNotPublished:    http.StatusConflict,
+	constant.ErrActivityPaused:          http.StatusConflict,
+	constant.ErrInvalidTransition:       http.StatusConflict,
+	constant.ErrTransitionGuardFailed:   http.StatusUnprocessableEntity,
+	constant.ErrRequestInProgress:       http.StatusConflict,
+	constant.ErrIdempotencyKeyReused:    http.StatusUnprocessableEntity,
+	constant.ErrTooManyRequests:         http.StatusTooManyRequests,
+	constant.ErrRiskDenied:              http.StatusForbidden,
+	constant.ErrRiskChallenge:           http.StatusForbidden,
+}
+
+// domainErrors 领域层和存储层哨兵错误与接口错误的对应关系，按顺序匹配
+var domainErrors = []struct {
+	target error
+	apiErr *Error
+}{
+	{repository.ErrActivityNotFound, ErrActivityNotFound},
+	{repository.ErrRevisionConflict, ErrActivityModified},
+	{models.ErrActivityNotStarted, ErrActivityNotStarted},
+	{models.ErrActivityEnded, ErrActivityEnded},
+	{models.ErrActivityNotPublished, ErrActivityNotPublished},
+	{models.ErrActivityPaused, ErrActivityPaused},
+	{models.ErrInvalidTransition, ErrInvalidTransition},
+	{models.ErrGameNotFound, ErrGameNotFound},
+	{models.ErrGameNotOpen, ErrGameClosed},
+	{models.ErrUserCannotParticipate, ErrUserAlreadyParticipated},
+	{models.ErrAlreadyCheckedIn, ErrUserAlreadyParticipated},
+	{models.ErrPrizeStockEmpty, ErrPrizeStockEmpty},
+	{models.ErrDiscountCodeUnavailable, ErrDiscountCodeInvalid},
+	{models.ErrActivityFull, ErrActivityFull},
+	{models.ErrNotEligible, ErrNotEligible},
+	{models.ErrNotEnrolled, ErrNotEnrolled},
+	{models.ErrUserNotPosted, ErrUserNotPosted},
+	{models.ErrInvalidAction, ErrInvalidParam},
+}
+
+// toAPIError 将任意错误转换为接口错误，无法识别的错误统一视为系统错误
+func toAPIError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var configErrs models.ConfigErrors
+	if errors.As(err, &configErrs) {
+		details := make([]string, 0, len(configErrs))
+		for _, fieldErr := range configErrs {
+			details = append(details, fieldErr.String())
+		}
+		return ErrInvalidParam.WithDetails(details...)
+	}
+
+	for _, m := range domainErrors {
+		if errors.Is(err, m.target) {
+			return m.apiErr
+		}
+	}
+	return ErrSystem
+}
+
+// writeError 统一写出错误响应，内部错误只记录日志，不返回给客户端
+func writeError(c *gin.Context, err error) {
+	apiErr := toAPIError(err)
+	status, ok := statusByCode[apiErr.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+	if status == http.StatusInternalServerError {
+		log.Printf("internal error: method=%s path=%s err=%v", c.Request.Method, c.FullPath(), err)
+	}
+
+	// 错误码保持不变，消息按请求语言本地化，目录中缺失时使用预定义消息
+	message := i18n.Message(requestLocale(c), apiErr.Code)
+	if message == "" {
+		message = apiErr.Message
+	}
+	resp := BaseResp{
+		Code:    apiErr.Code,
+		Message: message,
+	}
+	if len(apiErr.Details) > 0 {
+		resp.Data = apiErr.Details
+	}
+	c.AbortWithStatusJSON(status, resp)
+}
+
+// requestLocale 选择响应语言：优先使用令牌中的用户偏好，其次按Accept-Language协商
+func requestLocale(c *gin.Context) string {
+	if claims := currentClaims(c); claims != nil && claims.Locale != "" {
+		if locale, ok := i18n.Match(claims.Locale); ok {
+			return locale
+		}
+	}
+	return i18n.Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// writeSuccess 统一写出成功响应
+func writeSuccess(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, BaseResp{
+		Code:    0,
+		Message: "success",
+		Data:    data,
+	})
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"Activity/auth"
+	"Activity/constant"
+	"Activity/models"
+	"Activity/storage/mysql/repository"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestToAPIError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *Error
+	}{
+		{"接口错误原样返回", ErrRequestInProgress, ErrRequestInProgress},
+		{"包装的接口错误", fmt.Errorf("failed to participate: %w", ErrTooManyRequests), ErrTooManyRequests},
+		{"领域错误", models.ErrPrizeStockEmpty, ErrPrizeStockEmpty},
+		{"存储层错误", repository.ErrRevisionConflict, ErrActivityModified},
+		{"多层包装的领域错误", fmt.Errorf("failed to perform game: %w", fmt.Errorf("issue: %w", models.ErrUserCannotParticipate)), ErrUserAlreadyParticipated},
+		{"同一领域错误的不同来源", models.ErrAlreadyCheckedIn, ErrUserAlreadyParticipated},
+		{"配置错误带字段详情", fmt.Errorf("invalid config: %w", models.ConfigErrors{
+			{Field: "games[0].config", Message: "max_draws must be positive"},
+			{Field: "end_at", Message: "must be after start_at"},
+		}), ErrInvalidParam.WithDetails("games[0].config: max_draws must be positive", "end_at: must be after start_at")},
+		{"未知错误", errors.New("connection reset"), ErrSystem},
+		{"包装的未知错误", fmt.Errorf("failed to get activity: %w", errors.New("connection reset")), ErrSystem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := toAPIError(tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("toAPIError() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		locale         string // 令牌中的用户偏好
+		wantStatus     int
+		wantCode       int
+		wantMessage    string
+		wantDetails    []string
+	}{
+		{"默认中文", models.ErrPrizeStockEmpty, "", "", http.StatusConflict, constant.ErrPrizeStockEmpty, constant.ErrMsgPrizeStockEmpty, nil},
+		{"按Accept-Language协商", models.ErrPrizeStockEmpty, "en-US,zh;q=0.5", "", http.StatusConflict, constant.ErrPrizeStockEmpty, "Prizes are out of stock", nil},
+		{"不支持的语言使用默认语言", models.ErrPrizeStockEmpty, "fr-FR", "", http.StatusConflict, constant.ErrPrizeStockEmpty, constant.ErrMsgPrizeStockEmpty, nil},
+		{"令牌偏好优先于Accept-Language", repository.ErrRevisionConflict, "zh-CN", "en-US", http.StatusPreconditionFailed, constant.ErrActivityModified, "The activity was modified by someone else, please reload and try again", nil},
+		{"未知错误返回500且不暴露原因", errors.New("dial tcp: connection refused"), "en", "", http.StatusInternalServerError, constant.ErrSystem, "Something went wrong, please try again later", nil},
+		{"错误详情放在data中", models.ConfigErrors{{Field: "end_at", Message: "must be after start_at"}}, "", "", http.StatusBadRequest, constant.ErrInvalidParam, constant.ErrMsgInvalidParam, []string{"end_at: must be after start_at"}},
+		{"目录中缺失的错误码使用预定义消息", NewError(99999, "自定义错误"), "en", "", http.StatusInternalServerError, 99999, "自定义错误", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				c.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			if tt.locale != "" {
+				c.Set(ContextKeyClaims, &auth.Claims{Subject: "u1", Locale: tt.locale})
+			}
+			writeError(c, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			var resp struct {
+				Code    int      `json:"code"`
+				Message string   `json:"message"`
+				Data    []string `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if resp.Code != tt.wantCode || resp.Message != tt.wantMessage {
+				t.Errorf("response = %d %q, want %d %q", resp.Code, resp.Message, tt.wantCode, tt.wantMessage)
+			}
+			if !reflect.DeepEqual(resp.Data, tt.wantDetails) {
+				t.Errorf("details = %v, want %v", resp.Data, tt.wantDetails)
+			}
+			if !c.IsAborted() {
+				t.Error("writeError did not abort the request")
+			}
+		})
+	}
+}
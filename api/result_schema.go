@@ -0,0 +1,245 @@
+package api
+
+import (
+	"Activity/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-openapi/spec"
+	"sigs.k8s.io/yaml"
+)
+
+// participateResponseDefinition 参与玩法响应在文档中的定义名称，作为玩法结果联合类型的基类
+const participateResponseDefinition = "api.ParticipateGameResponse"
+
+// docTemplateSchemes swag生成的docs.go模板中schemes的占位内容，不是合法的JSON，处理前后需要去掉和加回
+const docTemplateSchemes = "\n    \"schemes\": {{ marshal .Schemes }},"
+
+// WriteGameResults 在dir下swag生成的swagger.json、swagger.yaml和docs.go中加入玩法结果的可辨识联合类型，供客户端生成强类型SDK
+// 每次执行swag init后运行，重复执行结果不变
+func WriteGameResults(dir string) error {
+	jsonPath := filepath.Join(dir, "swagger.json")
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		return err
+	}
+	doc, err := formatDoc(data)
+	if err != nil {
+		return err
+	}
+	yamlDoc, err := yaml.JSONToYAML(doc)
+	if err != nil {
+		return fmt.Errorf("failed to convert swagger doc to yaml: %w", err)
+	}
+
+	// docs.go中的模板与swagger.json只有info、host等字段不同，单独处理以保留占位符
+	goPath := filepath.Join(dir, "docs.go")
+	source, err := os.ReadFile(goPath)
+	if err != nil {
+		return err
+	}
+	const templateStart, templateEnd = "const docTemplate = `", "`\n"
+	start := bytes.Index(source, []byte(templateStart))
+	if start < 0 {
+		return fmt.Errorf("docTemplate not found in %s", goPath)
+	}
+	start += len(templateStart)
+	end := bytes.Index(source[start:], []byte(templateEnd))
+	if end < 0 {
+		return fmt.Errorf("docTemplate not terminated in %s", goPath)
+	}
+	end += start
+	template := bytes.Replace(source[start:end], []byte(docTemplateSchemes), nil, 1)
+	template, err = formatDoc(template)
+	if err != nil {
+		return err
+	}
+	template = append([]byte("{"+docTemplateSchemes), template[1:]...)
+	source = append(source[:start:start], append(template, source[end:]...)...)
+
+	if err := os.WriteFile(jsonPath, doc, 0o644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "swagger.yaml"), yamlDoc, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(goPath, source, 0o644)
+}
+
+// formatDoc 返回加入玩法结果定义后的文档，格式与swag生成的一致
+func formatDoc(doc []byte) ([]byte, error) {
+	doc, err := withGameResults(doc)
+	if err != nil {
+		return nil, err
+	}
+	var swagger spec.Swagger
+	if err := json.Unmarshal(doc, &swagger); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger doc: %w", err)
+	}
+	return json.MarshalIndent(&swagger, "", "    ")
+}
+
+// withGameResults 返回加入玩法结果定义后的文档
+func withGameResults(doc []byte) ([]byte, error) {
+	var spec map[string]interface{}
+	if err := json.Unmarshal(doc, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger doc: %w", err)
+	}
+	definitions, _ := spec["definitions"].(map[string]interface{})
+	base, ok := definitions[participateResponseDefinition].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("definition %s not found", participateResponseDefinition)
+	}
+	base["discriminator"] = "game_type"
+	required, _ := base["required"].([]interface{})
+	if !containsValue(required, "game_type") {
+		base["required"] = append(required, "game_type")
+	}
+
+	ctx := context.Background()
+	builder := &schemaBuilder{definitions: definitions}
+	for _, gameType := range models.GameTypes() {
+		factory, _ := models.GetGameFactory(gameType)
+		game, err := factory.Create(models.GameConfig{Type: gameType, Name: gameType, Config: json.RawMessage("{}")})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create game %s: %w", gameType, err)
+		}
+
+		// 声明了多种结果的玩法无法在Swagger 2.0中表达为oneOf，通过扩展字段列出
+		var result map[string]interface{}
+		switch results := game.Results(ctx); len(results) {
+		case 0:
+			result = map[string]interface{}{"type": "object"}
+		case 1:
+			result = builder.schema(reflect.TypeOf(results[0]))
+		default:
+			refs := make([]interface{}, 0, len(results))
+			for _, r := range results {
+				refs = append(refs, builder.schema(reflect.TypeOf(r)))
+			}
+			result = map[string]interface{}{"type": "object", "x-oneOf": refs}
+		}
+
+		definitions[gameType] = map[string]interface{}{
+			"description": fmt.Sprintf("game_type为%s时的参与玩法响应", gameType),
+			"allOf": []interface{}{
+				map[string]interface{}{"$ref": "#/definitions/" + participateResponseDefinition},
+				map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"result": result},
+				},
+			},
+		}
+	}
+	return json.Marshal(spec)
+}
+
+// containsValue 列表中是否包含value
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaBuilder 通过反射生成Go类型的Swagger定义，已存在的定义（如swag生成的）保持不变
+type schemaBuilder struct {
+	definitions map[string]interface{}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schema 返回类型的Schema，具名结构体加入定义后返回引用
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{"type": "object"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return map[string]interface{}{"type": "object", "properties": b.properties(t)}
+		}
+		name := path.Base(t.PkgPath()) + "." + t.Name()
+		if _, exists := b.definitions[name]; !exists {
+			// 先占位，避免递归类型无限展开
+			definition := map[string]interface{}{"type": "object"}
+			b.definitions[name] = definition
+			definition["properties"] = b.properties(t)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + name}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{"type": "object"}
+}
+
+// properties 按encoding/json的规则返回结构体序列化后的字段，匿名嵌入的结构体字段提升到外层
+func (b *schemaBuilder) properties(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name = tag[:comma]
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for k, v := range b.properties(fieldType) {
+				if _, exists := properties[k]; !exists {
+					properties[k] = v
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type)
+	}
+	return properties
+}
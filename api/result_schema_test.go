@@ -0,0 +1,110 @@
+package api
+
+import (
+	"Activity/docs"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+// TestGeneratedDocsHaveGameResults 检查提交的docs中已包含玩法结果定义，且与重新执行WriteGameResults的结果一致
+func TestGeneratedDocsHaveGameResults(t *testing.T) {
+	swaggerJSON, err := os.ReadFile("../docs/swagger.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	swaggerYAML, err := os.ReadFile("../docs/swagger.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlJSON, err := yaml.YAMLToJSON(swaggerYAML)
+	if err != nil {
+		t.Fatalf("invalid swagger.yaml: %v", err)
+	}
+
+	files := map[string][]byte{
+		"swagger.json": swaggerJSON,
+		"swagger.yaml": yamlJSON,
+		"docs.go":      []byte(docs.SwaggerInfo.ReadDoc()),
+	}
+	for name, doc := range files {
+		t.Run(name, func(t *testing.T) {
+			checkGameResults(t, doc)
+
+			// 文档已是最新，重新加入玩法结果不会产生变化
+			updated, err := withGameResults(doc)
+			if err != nil {
+				t.Fatalf("withGameResults() error = %v", err)
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(updated, &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(doc, &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Error("game results are out of date, run make swagger")
+			}
+		})
+	}
+}
+
+// checkGameResults 检查文档中参与玩法响应的discriminator和各玩法类型的结果定义
+func checkGameResults(t *testing.T, data []byte) {
+	t.Helper()
+	var doc struct {
+		Definitions map[string]struct {
+			Discriminator string                     `json:"discriminator"`
+			Required      []string                   `json:"required"`
+			Properties    map[string]json.RawMessage `json:"properties"`
+			AllOf         []struct {
+				Ref        string `json:"$ref"`
+				Properties struct {
+					Result struct {
+						Ref string `json:"$ref"`
+					} `json:"result"`
+				} `json:"properties"`
+			} `json:"allOf"`
+		} `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid swagger doc: %v", err)
+	}
+
+	base := doc.Definitions[participateResponseDefinition]
+	if base.Discriminator != "game_type" || !reflect.DeepEqual(base.Required, []string{"game_type"}) {
+		t.Errorf("%s discriminator = %q, required = %v, want game_type", participateResponseDefinition, base.Discriminator, base.Required)
+	}
+	want := map[string]string{
+		"checkin": "#/definitions/models.CheckinResult",
+		"lottery": "#/definitions/models.LotteryResult",
+		"post":    "#/definitions/models.CommunityPostResult",
+	}
+	for gameType, ref := range want {
+		definition, ok := doc.Definitions[gameType]
+		if !ok || len(definition.AllOf) != 2 {
+			t.Fatalf("definition %s = %+v, want allOf of base and result", gameType, definition)
+		}
+		if definition.AllOf[0].Ref != "#/definitions/"+participateResponseDefinition {
+			t.Errorf("%s base = %q", gameType, definition.AllOf[0].Ref)
+		}
+		if got := definition.AllOf[1].Properties.Result.Ref; got != ref {
+			t.Errorf("%s result = %q, want %q", gameType, got, ref)
+		}
+	}
+
+	// 结果中的字段按json标签生成，不导出的字段不出现
+	lottery := doc.Definitions["models.LotteryResult"].Properties
+	for _, field := range []string{"game_name", "slot_index", "won", "grant", "fallback_slots"} {
+		if _, ok := lottery[field]; !ok {
+			t.Errorf("models.LotteryResult missing %s", field)
+		}
+	}
+	if _, ok := lottery["prize"]; ok {
+		t.Error("unexported field exposed in models.LotteryResult")
+	}
+}
@@ -2,99 +2,375 @@ package api
 
 import (
 	"Activity/models"
+	"Activity/risk"
 	"Activity/storage/mysql/entity"
 	"Activity/storage/mysql/repository"
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // GameService 玩法服务接口
 type GameService interface {
-	ParticipateGame(ctx context.Context, user models.User, activityID, gameName string, action models.ActionInterface) (interface{}, error)
+	// ParticipateGame 参与玩法，action按玩法声明的动作类型解析；requestID非空时相同用户和requestID的重试直接返回首次成功的响应
+	ParticipateGame(ctx context.Context, user models.User, activityID, gameName, requestID string, action json.RawMessage) (*ParticipateGameResponse, error)
 	GetGameStatus(ctx context.Context, user models.User, activityID, gameName string) (*GameStatusResp, error)
-	GetUserPrize(ctx context.Context, user models.User, activityID, gameName string) (*UserPrizeResp, error)
+	GetUserPrize(ctx context.Context, user models.User, activityID, gameName string) (*GetUserPrizeResponse, error)
+	GetRateLimit(ctx context.Context, activityID string) (*models.RateLimitConfig, error)
 }
 
 // ActivityService 活动服务接口
 type ActivityService interface {
 	// 活动管理
 	CreateActivity(ctx context.Context, req *CreateActivityRequest) (*ActivityResponse, error)
-	UpdateActivity(ctx context.Context, req *UpdateActivityRequest) (*ActivityResponse, error)
+	UpdateActivity(ctx context.Context, activityID int64, revision *int64, req *UpdateActivityRequest) (*ActivityResponse, error)
 	GetActivity(ctx context.Context, activityID int64) (*ActivityResponse, error)
 
+	// 活动生命周期
+	TransitionActivity(ctx context.Context, activityID int64, operator string, req *TransitionActivityRequest) (*ActivityResponse, error)
+	ListStatusHistory(ctx context.Context, activityID int64) (*ListStatusHistoryResponse, error)
+
 	// 活动参与
-	Participate(ctx context.Context, req *ParticipateRequest) (*ParticipationResponse, error)
-	GetParticipation(ctx context.Context, activityID int64, userID string) (*ParticipationResponse, error)
+	Participate(ctx context.Context, activityID int64, req *ParticipateRequest) (*ParticipateResponse, error)
+	GetParticipation(ctx context.Context, activityID int64, req *GetParticipationReq) (*GetParticipationResponse, error)
 
 	// 奖品管理
 	DistributePrize(ctx context.Context, activityID int64, userID string, prizeType string, prizeID string) (*PrizeResponse, error)
+
+	// 折扣码池管理
+	ImportDiscountCodes(ctx context.Context, activityID int64, prizeKey string, csvFile io.Reader, expiresAt int64) (*DiscountCodePoolResponse, error)
+	GenerateDiscountCodes(ctx context.Context, activityID int64, req *GenerateDiscountCodesRequest) (*DiscountCodePoolResponse, error)
+	RedeemDiscountCode(ctx context.Context, userID, code string) (*RedeemDiscountCodeResponse, error)
+}
+
+// AuditService 后台操作日志服务接口
+type AuditService interface {
+	Record(ctx context.Context, entry *entity.AdminAuditLog) error
+	ListAuditLogs(ctx context.Context, req *ListAuditLogsReq) (*ListAuditLogsResponse, error)
+}
+
+// RiskChecker 参与玩法前的风控检查，决策为拒绝或需要验证时不执行玩法
+type RiskChecker interface {
+	Check(ctx context.Context, subject risk.Subject) *risk.Verdict
+	// ReportWin 记录放行的请求获得了奖品
+	ReportWin(ctx context.Context, verdict *risk.Verdict) error
 }
 
+// participationRequestLease 带幂等键的参与请求的处理租约，超过该时间未完成的请求可以由重试接管
+const participationRequestLease = 30 * time.Second
+
 // gameService 玩法服务实现
 type gameService struct {
-	activityRepo ActivityRepository
+	activityRepo      ActivityRepository
+	prizeRepo         repository.PrizeRepository
+	participationRepo repository.ParticipationRepository
+	enrollmentRepo    repository.EnrollmentRepository
+	requestRepo       repository.ParticipationRequestRepository
+	transactor        models.Transactor // 玩法执行与参与记录共用的事务，为nil时不开启事务
+	riskChecker       RiskChecker       // 为nil时不做风控检查
 }
 
 // activityService 活动服务实现
 type activityService struct {
-	activityRepo repository.ActivityRepository
+	activityRepo      repository.ActivityRepository
+	codeRepo          repository.DiscountCodeRepository
+	participationRepo repository.ParticipationRepository
+	enrollmentRepo    repository.EnrollmentRepository
 }
 
-func NewGameService(activityRepo ActivityRepository) GameService {
+// auditService 后台操作日志服务实现
+type auditService struct {
+	auditRepo repository.AuditLogRepository
+}
+
+// NewGameService 创建玩法服务实例，transactor用于在同一事务中执行玩法并写入参与记录，riskChecker为nil时不做风控检查
+func NewGameService(activityRepo ActivityRepository, prizeRepo repository.PrizeRepository, participationRepo repository.ParticipationRepository, enrollmentRepo repository.EnrollmentRepository, requestRepo repository.ParticipationRequestRepository, transactor models.Transactor, riskChecker RiskChecker) GameService {
 	return &gameService{
-		activityRepo: activityRepo,
+		activityRepo:      activityRepo,
+		prizeRepo:         prizeRepo,
+		participationRepo: participationRepo,
+		enrollmentRepo:    enrollmentRepo,
+		requestRepo:       requestRepo,
+		transactor:        transactor,
+		riskChecker:       riskChecker,
+	}
+}
+
+// NewAuditService 创建后台操作日志服务实例
+func NewAuditService(auditRepo repository.AuditLogRepository) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
 	}
 }
 
 // NewActivityService 创建活动服务实例
-func NewActivityService(activityRepo repository.ActivityRepository) ActivityService {
+func NewActivityService(activityRepo repository.ActivityRepository, codeRepo repository.DiscountCodeRepository, participationRepo repository.ParticipationRepository, enrollmentRepo repository.EnrollmentRepository) ActivityService {
 	return &activityService{
-		activityRepo: activityRepo,
+		activityRepo:      activityRepo,
+		codeRepo:          codeRepo,
+		participationRepo: participationRepo,
+		enrollmentRepo:    enrollmentRepo,
 	}
 }
 
 // ParticipateGame 参与玩法
-func (s *gameService) ParticipateGame(ctx context.Context, user models.User, activityID, gameName string, action models.ActionInterface) (interface{}, error) {
+func (s *gameService) ParticipateGame(ctx context.Context, user models.User, activityID, gameName, requestID string, action json.RawMessage) (*ParticipateGameResponse, error) {
 	// 1. 获取活动信息
 	activity, err := s.activityRepo.GetActivity(ctx, activityID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get activity: %w", err)
 	}
 
-	// 2. 检查活动状态
-	if err := s.checkActivityStatus(activity); err != nil {
+	if requestID == "" {
+		return s.participate(ctx, user, activity, gameName, action)
+	}
+	return s.participateOnce(ctx, user, activity, gameName, requestID, action)
+}
+
+// participateOnce 按幂等键参与玩法，相同幂等键的重试直接返回首次执行的结果，不会再次执行玩法
+// 玩法执行前的检查失败时删除请求记录，客户端可以使用相同的幂等键重试；玩法开始执行后的失败不会删除请求记录
+func (s *gameService) participateOnce(ctx context.Context, user models.User, activity models.ActivityInterface, gameName, requestID string, action json.RawMessage) (*ParticipateGameResponse, error) {
+	now := time.Now()
+	request, created, err := s.requestRepo.Begin(ctx, &entity.ParticipationRequest{
+		UserID:         user.Uid,
+		IdempotencyKey: requestID,
+		ActivityID:     activity.Meta().ID,
+		GameName:       gameName,
+		Status:         entity.ParticipationRequestStatusPending,
+		ExpiresAt:      now.Add(participationRequestLease),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin participation request: %w", err)
+	}
+	if !created {
+		if request.ActivityID != activity.Meta().ID || request.GameName != gameName {
+			return nil, ErrIdempotencyKeyReused
+		}
+		if request.Status != entity.ParticipationRequestStatusPending && request.Response != nil {
+			return decodeParticipationRequest(request)
+		}
+		// 首次请求仍在处理时拒绝，处理请求的实例宕机、租约到期后由本次重试接管
+		taken, err := s.requestRepo.Takeover(ctx, request.ID, now, participationRequestLease)
+		if err != nil {
+			return nil, fmt.Errorf("failed to take over participation request: %w", err)
+		}
+		if !taken {
+			return nil, ErrRequestInProgress
+		}
+	}
+
+	p, err := s.prepare(ctx, user, activity, gameName, action)
+	if err != nil {
+		if abortErr := s.requestRepo.Abort(context.Background(), request.ID); abortErr != nil {
+			log.Printf("failed to abort participation request %d: %v", request.ID, abortErr)
+		}
 		return nil, err
 	}
+	defer p.release()
 
-	// 3. 获取玩法
+	// 玩法已开始执行，可能已产生副作用，不再删除请求记录：
+	// 业务错误保存为失败，重试返回相同的错误；系统错误保持处理中，租约到期后由重试接管
+	result, err := s.perform(ctx, user, activity, p)
+	if err != nil {
+		if apiErr := toAPIError(err); apiErr != ErrSystem {
+			s.finishRequest(request.ID, apiErr, s.requestRepo.Fail)
+		}
+		return nil, err
+	}
+
+	// 保存响应失败时只记录日志，请求记录保持处理中，租约到期前的重试会被拒绝
+	s.finishRequest(request.ID, result, s.requestRepo.Complete)
+	return result, nil
+}
+
+// finishRequest 保存请求的最终响应，失败只记录日志
+func (s *gameService) finishRequest(id int64, response interface{}, finish func(ctx context.Context, id int64, response string) error) {
+	data, err := json.Marshal(response)
+	if err == nil {
+		err = finish(context.Background(), id, string(data))
+	}
+	if err != nil {
+		log.Printf("failed to finish participation request %d: %v", id, err)
+	}
+}
+
+// decodeParticipationRequest 返回已结束请求保存的响应或错误
+func decodeParticipationRequest(request *entity.ParticipationRequest) (*ParticipateGameResponse, error) {
+	if request.Status != entity.ParticipationRequestStatusFailed {
+		return decodeParticipateGameResponse(*request.Response)
+	}
+	var apiErr Error
+	if err := json.Unmarshal([]byte(*request.Response), &apiErr); err != nil {
+		return nil, fmt.Errorf("failed to decode saved error: %w", err)
+	}
+	return nil, &apiErr
+}
+
+// participate 检查活动、玩法和用户状态后执行玩法并保存参与记录
+func (s *gameService) participate(ctx context.Context, user models.User, activity models.ActivityInterface, gameName string, rawAction json.RawMessage) (*ParticipateGameResponse, error) {
+	p, err := s.prepare(ctx, user, activity, gameName, rawAction)
+	if err != nil {
+		return nil, err
+	}
+	defer p.release()
+	return s.perform(ctx, user, activity, p)
+}
+
+// participation 参与前检查通过后执行玩法所需的数据
+type participation struct {
+	game    models.GameInterface
+	action  models.ActionInterface
+	verdict *risk.Verdict
+	release func() // 释放并发去重的占位
+}
+
+// prepare 执行玩法前的检查，不产生任何写入；返回错误时调用方可以安全地重试
+func (s *gameService) prepare(ctx context.Context, user models.User, activity models.ActivityInterface, gameName string, rawAction json.RawMessage) (*participation, error) {
+	// 2. 检查活动状态，活动要求报名时用户需已报名
+	if err := checkActivityStatus(activity); err != nil {
+		return nil, err
+	}
+	if err := s.checkEnrollment(ctx, activity, user); err != nil {
+		return nil, err
+	}
+
+	// 3. 获取玩法，并按玩法声明的动作类型解析请求中的动作
 	game, err := s.getGameByName(activity, gameName)
 	if err != nil {
 		return nil, err
 	}
+	action, err := models.DecodeAction(ctx, game, rawAction)
+	if err != nil {
+		return nil, err
+	}
 
 	// 4. 检查玩法状态
 	if game.GameState(ctx) != models.GameStateOPEN {
-		return nil, fmt.Errorf("game is closed")
+		return nil, models.ErrGameNotOpen
+	}
+
+	// 5. 仓储支持并发去重时先占住用户的参与，同一用户的并发请求只有一个能继续，其余视为已参与
+	p := &participation{game: game, action: action, release: func() {}}
+	if deduper, ok := s.participationRepo.(repository.ParticipationDeduper); ok {
+		acquired, err := deduper.Acquire(ctx, activity.Meta().ID, gameName, user.Uid)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire participation: %w", err)
+		}
+		if !acquired {
+			return nil, models.ErrUserCannotParticipate
+		}
+		p.release = func() {
+			deduper.Release(context.Background(), activity.Meta().ID, gameName, user.Uid)
+		}
 	}
 
-	// 5. 检查用户状态
-	if game.UserState(ctx) != models.UserStateOPEN {
-		return nil, fmt.Errorf("user cannot participate")
+	// 6. 检查用户状态
+	switch state := game.UserState(ctx, user); {
+	case state == models.UserStateCLOSED:
+		p.release()
+		return nil, models.ErrUserCannotParticipate
+	case !models.CanParticipate(state):
+		p.release()
+		return nil, fmt.Errorf("failed to get user state: %s", state)
 	}
 
-	// 6. 执行玩法逻辑
-	result, err := game.Perform(ctx, user, action)
+	// 7. 风控检查
+	if p.verdict, err = s.checkRisk(ctx, user, activity, gameName); err != nil {
+		p.release()
+		return nil, err
+	}
+	return p, nil
+}
+
+// perform 执行玩法并保存参与记录
+func (s *gameService) perform(ctx context.Context, user models.User, activity models.ActivityInterface, p *participation) (*ParticipateGameResponse, error) {
+	// 8. 执行玩法逻辑并保存用户参与记录，两者在同一事务中提交，参与记录写入失败时发放的奖品一并回滚
+	// 领域错误原样向上传递，由响应层统一转换
+	var result models.ResultInterface
+	err := s.transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if result, err = p.game.Perform(ctx, user, p.action); err != nil {
+			return fmt.Errorf("failed to perform game: %w", err)
+		}
+		if err := s.saveUserGameRecord(ctx, user, activity, p.game, result); err != nil {
+			return fmt.Errorf("failed to save user game record: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to perform game: %w", err)
+		return nil, err
 	}
 
-	// 7. 保存用户参与记录
-	if err := s.saveUserGameRecord(ctx, user, activityID, gameName, result); err != nil {
-		return nil, fmt.Errorf("failed to save user game record: %w", err)
+	// 9. 组装统一的响应，获得奖品时上报风控用于统计设备的中奖频率，失败只记录日志
+	resp := newParticipateGameResponse(ctx, p.game, result)
+	if p.verdict != nil && resp.Won {
+		if err := s.riskChecker.ReportWin(context.Background(), p.verdict); err != nil {
+			log.Printf("failed to report risk win: uid=%s err=%v", user.Uid, err)
+		}
 	}
 
-	return result, nil
+	return resp, nil
+}
+
+// newParticipateGameResponse 将玩法结果包装为统一的响应，奖品详情由结果中的奖品生成
+func newParticipateGameResponse(ctx context.Context, game models.GameInterface, result models.ResultInterface) *ParticipateGameResponse {
+	resp := &ParticipateGameResponse{
+		GameName: game.Name(ctx),
+		GameType: game.Type(ctx),
+		Result:   result,
+	}
+	if r, ok := result.(models.PrizeResult); ok {
+		if prize, grant := r.WonPrize(); prize != nil {
+			info := newPrizeInfoFromDetail(prize.Detail(grant))
+			resp.Won = true
+			resp.Prize = &info
+		}
+	}
+	return resp
+}
+
+// decodeParticipateGameResponse 解析保存的响应，玩法结果保持原始JSON
+func decodeParticipateGameResponse(data string) (*ParticipateGameResponse, error) {
+	var stored struct {
+		ParticipateGameResponse
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode saved response: %w", err)
+	}
+	resp := stored.ParticipateGameResponse
+	resp.Result = stored.Result
+	return &resp, nil
+}
+
+// checkRisk 执行风控检查，拒绝和需要验证时返回对应的错误；未配置风控时返回nil
+func (s *gameService) checkRisk(ctx context.Context, user models.User, activity models.ActivityInterface, gameName string) (*risk.Verdict, error) {
+	if s.riskChecker == nil {
+		return nil, nil
+	}
+	verdict := s.riskChecker.Check(ctx, risk.Subject{
+		UserID:       user.Uid,
+		DeviceID:     user.DeviceID,
+		IP:           user.IP,
+		RegisteredAt: user.RegisteredAt,
+		ActivityID:   activity.Meta().ID,
+		GameName:     gameName,
+	})
+	switch verdict.Decision {
+	case risk.DecisionDeny:
+		return nil, ErrRiskDenied
+	case risk.DecisionChallenge:
+		return nil, ErrRiskChallenge
+	}
+	return verdict, nil
 }
 
 // GetGameStatus 获取玩法状态
@@ -106,7 +382,7 @@ func (s *gameService) GetGameStatus(ctx context.Context, user models.User, activ
 	}
 
 	// 2. 检查活动状态
-	if err := s.checkActivityStatus(activity); err != nil {
+	if err := checkActivityStatus(activity); err != nil {
 		return nil, err
 	}
 
@@ -116,33 +392,31 @@ func (s *gameService) GetGameStatus(ctx context.Context, user models.User, activ
 		return nil, err
 	}
 
-	// 4. 获取奖品信息
-	var remainNum, totalNum int64
-	switch g := game.(type) {
-	case *models.CommunityPostGame:
-		if g.Prize != nil {
-			remainNum = g.Prize.RemainNum
-			totalNum = g.Prize.TotalNum
-		}
-	case *models.CheckinGame:
-		if g.Prize != nil {
-			remainNum = g.Prize.RemainNum
-			totalNum = g.Prize.TotalNum
-		}
+	// 4. 获取奖品信息，剩余数量以库存表为准
+	remainNum, totalNum, err := gamePrizeStock(ctx, game)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prize stock: %w", err)
+	}
+
+	// 5. 统计当前用户的参与次数
+	count, err := s.participationRepo.CountParticipations(ctx, activity.Meta().ID, gameName, user.Uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count participations: %w", err)
 	}
 
 	return &GameStatusResp{
-		GameState: string(game.GameState(ctx)),
-		UserState: string(game.UserState(ctx)),
-		StartTime: time.Unix(activity.StartAt(), 0),
-		EndTime:   time.Unix(activity.EndAt(), 0),
-		RemainNum: remainNum,
-		TotalNum:  totalNum,
+		GameState:          string(game.GameState(ctx)),
+		UserState:          string(game.UserState(ctx, user)),
+		ParticipationCount: count,
+		StartTime:          time.Unix(activity.StartAt(), 0),
+		EndTime:            time.Unix(activity.EndAt(), 0),
+		RemainNum:          remainNum,
+		TotalNum:           totalNum,
 	}, nil
 }
 
 // GetUserPrize 获取用户奖品
-func (s *gameService) GetUserPrize(ctx context.Context, user models.User, activityID, gameName string) (*UserPrizeResp, error) {
+func (s *gameService) GetUserPrize(ctx context.Context, user models.User, activityID, gameName string) (*GetUserPrizeResponse, error) {
 	// 1. 获取活动信息
 	activity, err := s.activityRepo.GetActivity(ctx, activityID)
 	if err != nil {
@@ -150,56 +424,163 @@ func (s *gameService) GetUserPrize(ctx context.Context, user models.User, activi
 	}
 
 	// 2. 检查活动状态
-	if err := s.checkActivityStatus(activity); err != nil {
+	if err := checkActivityStatus(activity); err != nil {
 		return nil, err
 	}
 
 	// 3. 获取玩法
-	_, err = s.getGameByName(activity, gameName)
+	game, err := s.getGameByName(activity, gameName)
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. 获取用户奖品信息
-	// TODO: 从数据库获取用户奖品记录
-	prize := &PrizeInfo{
-		Type:         "discount_code",
-		DiscountCode: "COMMUNITY_2024",
-		PriceRuleID:  123,
+	// 4. 获取用户奖品发放记录，折扣码为实际分配给用户的码
+	records, err := s.prizeRepo.FindUserPrizes(ctx, activity.Meta().ID, gameName, user.Uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user prizes: %w", err)
 	}
 
-	return &UserPrizeResp{
-		Prize:     *prize,
-		CreatedAt: time.Now(),
-	}, nil
+	var prizes map[string]models.PrizeInterface
+	if provider, ok := game.(models.PrizeProvider); ok {
+		prizes = provider.Prizes(ctx)
+	}
+
+	resp := &GetUserPrizeResponse{
+		Prizes: make([]*UserPrizeResp, 0, len(records)),
+	}
+	for _, record := range records {
+		resp.Prizes = append(resp.Prizes, &UserPrizeResp{
+			Prize:     newPrizeInfo(record, prizes[record.PrizeKey]),
+			CreatedAt: record.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// gamePrizeStock 汇总玩法下所有奖品的剩余库存和总库存
+func gamePrizeStock(ctx context.Context, game models.GameInterface) (remainNum, totalNum int64, err error) {
+	provider, ok := game.(models.PrizeProvider)
+	if !ok {
+		return 0, 0, nil
+	}
+	for _, prize := range provider.Prizes(ctx) {
+		var remain, total int64
+		switch p := prize.(type) {
+		case *models.DiscountCodePrize:
+			total = p.TotalNum
+			remain, err = p.RemainNum(ctx)
+		case *models.ProductPrize:
+			total = p.TotalNum
+			remain, err = p.RemainNum(ctx)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		remainNum += remain
+		totalNum += total
+	}
+	return remainNum, totalNum, nil
 }
 
-// checkActivityStatus 检查活动状态
-func (s *gameService) checkActivityStatus(activity models.ActivityInterface) error {
-	now := time.Now().Unix()
-	if now < activity.StartAt() {
-		return fmt.Errorf("activity not started")
+// newPrizeInfo 根据发放记录和奖品配置组装奖品信息，奖品已从配置中移除时只返回记录中的信息
+func newPrizeInfo(record *entity.PrizeRecord, prize models.PrizeInterface) PrizeInfo {
+	if prize != nil {
+		return newPrizeInfoFromDetail(prize.Detail(&models.PrizeGrant{
+			RecordID:  record.ID,
+			PrizeType: record.PrizeType,
+			PrizeID:   record.PrizeID,
+		}))
 	}
-	if now > activity.EndAt() {
-		return fmt.Errorf("activity ended")
+	info := PrizeInfo{Type: record.PrizeType}
+	switch record.PrizeType {
+	case models.PrizeTypeDiscountCode:
+		info.DiscountCode = record.PrizeID
+	case models.PrizeTypeProduct:
+		info.SKU = record.PrizeID
+	}
+	return info
+}
+
+// newPrizeInfoFromDetail 将奖品详情转换为接口返回的奖品信息
+func newPrizeInfoFromDetail(detail models.PrizeDetail) PrizeInfo {
+	return PrizeInfo{
+		Type:         detail.Type,
+		DiscountCode: detail.DiscountCode,
+		PriceRuleID:  detail.PriceRuleID,
+		SKU:          detail.SKU,
+		Title:        detail.Title,
+	}
+}
+
+// GetRateLimit 返回活动配置的限流规则，活动未配置时返回nil
+func (s *gameService) GetRateLimit(ctx context.Context, activityID string) (*models.RateLimitConfig, error) {
+	activity, err := s.activityRepo.GetActivity(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity: %w", err)
+	}
+	return activity.Meta().RateLimit, nil
+}
+
+// checkEnrollment 活动要求先报名时，检查用户是否已报名
+func (s *gameService) checkEnrollment(ctx context.Context, activity models.ActivityInterface, user models.User) error {
+	enrollment := activity.Meta().Enrollment
+	if enrollment == nil || !enrollment.Required {
+		return nil
+	}
+	enrolled, err := s.enrollmentRepo.IsEnrolled(ctx, activity.Meta().ID, user.Uid)
+	if err != nil {
+		return fmt.Errorf("failed to check enrollment: %w", err)
+	}
+	if !enrolled {
+		return models.ErrNotEnrolled
 	}
 	return nil
 }
 
+// checkActivityStatus 检查活动状态，只有进行中且在活动时间内的活动可以参与，其他状态返回对应的错误
+func checkActivityStatus(activity models.ActivityInterface) error {
+	return models.CheckActivityOpen(activity.Status(), activity.StartAt(), activity.EndAt(), time.Now().Unix())
+}
+
 // getGameByName 根据名称获取玩法
 func (s *gameService) getGameByName(activity models.ActivityInterface, gameName string) (models.GameInterface, error) {
-	for _, game := range activity.Games() {
-		if game.Name(context.Background()) == gameName {
-			return game, nil
-		}
+	if game, ok := models.FindGame(context.Background(), activity, gameName); ok {
+		return game, nil
 	}
-	return nil, fmt.Errorf("game not found")
+	return nil, fmt.Errorf("%w: %s", models.ErrGameNotFound, gameName)
 }
 
-// saveUserGameRecord 保存用户参与记录
-func (s *gameService) saveUserGameRecord(ctx context.Context, user models.User, activityID, gameName string, result models.ResultInterface) error {
-	// TODO: 实现用户参与记录的保存
-	return nil
+// saveUserGameRecord 保存用户参与记录，结果序列化后写入extra便于追溯
+// 参与后的用户状态优先使用玩法结果中携带的状态，记录只写入一次
+func (s *gameService) saveUserGameRecord(ctx context.Context, user models.User, activity models.ActivityInterface, game models.GameInterface, result models.ResultInterface) error {
+	extra, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal game result: %w", err)
+	}
+
+	var state models.UserState
+	if r, ok := result.(models.StateResult); ok {
+		state = r.StateAfter()
+	} else {
+		state = game.UserState(ctx, user)
+	}
+
+	return s.participationRepo.Create(ctx, &entity.ActivityParticipation{
+		ActivityID: activity.Meta().ID,
+		UserID:     user.Uid,
+		GameType:   game.Type(ctx),
+		GameTarget: result.Target(ctx),
+		State:      state,
+		Extra:      string(extra),
+	})
+}
+
+// transaction 在事务中执行fn，未配置事务管理时直接执行
+func (s *gameService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.transactor == nil {
+		return fn(ctx)
+	}
+	return s.transactor.Transaction(ctx, fn)
 }
 
 // ActivityRepository 活动仓库接口
@@ -209,9 +590,19 @@ type ActivityRepository interface {
 
 // CreateActivity 创建活动
 func (s *activityService) CreateActivity(ctx context.Context, req *CreateActivityRequest) (*ActivityResponse, error) {
-	// 验证时间范围
-	if req.StartAt >= req.EndAt {
-		return nil, ErrInvalidParam
+	// 校验并序列化活动配置
+	config, err := buildActivityConfig(ctx, models.ActivityConfigJSON{
+		Category:   req.Category,
+		Version:    req.Version,
+		Name:       req.Name,
+		StartAt:    req.StartAt,
+		EndAt:      req.EndAt,
+		Games:      req.Games,
+		Enrollment: req.Enrollment,
+		RateLimit:  req.RateLimit,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建活动实体
@@ -219,62 +610,117 @@ func (s *activityService) CreateActivity(ctx context.Context, req *CreateActivit
 		Category: req.Category,
 		Version:  req.Version,
 		Name:     req.Name,
-		// Config:   req.Config,
-		StartAt: req.StartAt,
-		EndAt:   req.EndAt,
-		// Status:   req.Status,
+		Config:   config,
+		StartAt:  req.StartAt,
+		EndAt:    req.EndAt,
+		Status:   models.ActivityStatusDraft,
 	}
 
 	// 保存到数据库
 	if err := s.activityRepo.Create(ctx, activity); err != nil {
-		return nil, ErrSystem
+		return nil, fmt.Errorf("failed to create activity: %w", err)
 	}
 
 	// 转换为响应
-	return &ActivityResponse{
-		ID:        activity.ID,
-		Category:  activity.Category,
-		Version:   activity.Version,
-		Name:      activity.Name,
-		Config:    activity.Config,
-		StartAt:   activity.StartAt,
-		EndAt:     activity.EndAt,
-		Status:    activity.Status,
-		CreatedAt: activity.CreatedAt,
-		UpdatedAt: activity.UpdatedAt,
-	}, nil
+	return newActivityResponse(activity), nil
 }
 
-// UpdateActivity 更新活动
-func (s *activityService) UpdateActivity(ctx context.Context, req *UpdateActivityRequest) (*ActivityResponse, error) {
-	// 获取活动
-	activity, err := s.activityRepo.FindByID(ctx, 0)
+// UpdateActivity 按ID部分更新活动，只修改请求中传入的字段
+// revision为If-Match中的修订号，为nil时不限定修订号，保存时仍以读取到的修订号防止并发覆盖
+func (s *activityService) UpdateActivity(ctx context.Context, activityID int64, revision *int64, req *UpdateActivityRequest) (*ActivityResponse, error) {
+	// 1. 获取活动并校验前置条件
+	activity, err := s.activityRepo.FindByID(ctx, activityID)
 	if err != nil {
-		return nil, ErrActivityNotFound
+		return nil, fmt.Errorf("failed to find activity: %w", err)
+	}
+	if revision != nil && *revision != activity.Revision {
+		return nil, ErrActivityModified
 	}
 
-	// // 更新活动信息
-	// activity.Config = req.Config
-	// activity.Status = req.Status
+	// 2. 在当前配置上应用本次修改
+	var current models.ActivityConfigJSON
+	if err := json.Unmarshal([]byte(activity.Config), &current); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal activity config: %w", err)
+	}
+	updated := *activity
+	if req.Name != nil {
+		updated.Name = *req.Name
+	}
+	if req.StartAt != nil {
+		updated.StartAt = *req.StartAt
+	}
+	if req.EndAt != nil {
+		updated.EndAt = *req.EndAt
+	}
 
-	// 保存更新
-	if err := s.activityRepo.Update(ctx, activity); err != nil {
-		return nil, ErrSystem
+	next := current
+	next.Category = updated.Category
+	next.Version = updated.Version
+	next.Name = updated.Name
+	next.StartAt = updated.StartAt
+	next.EndAt = updated.EndAt
+	if len(req.Games) > 0 {
+		next.Games = req.Games
+	}
+	if req.Enrollment != nil {
+		next.Enrollment = req.Enrollment
+	}
+	if req.RateLimit != nil {
+		next.RateLimit = req.RateLimit
 	}
 
-	// 转换为响应
-	return &ActivityResponse{
-		ID:        activity.ID,
-		Category:  activity.Category,
-		Version:   activity.Version,
-		Name:      activity.Name,
-		Config:    activity.Config,
-		StartAt:   activity.StartAt,
-		EndAt:     activity.EndAt,
-		Status:    activity.Status,
-		CreatedAt: activity.CreatedAt,
-		UpdatedAt: activity.UpdatedAt,
-	}, nil
+	// 3. 校验并序列化配置
+	if updated.Config, err = buildActivityConfig(ctx, next); err != nil {
+		return nil, err
+	}
+
+	// 4. 已发布的活动只允许安全的修改，奖品库存的变化同步到库存表
+	oldGames, err := models.NewGamesFromConfig(current.Games)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build current games: %w", err)
+	}
+	newGames, err := models.NewGamesFromConfig(next.Games)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build updated games: %w", err)
+	}
+	if models.IsActivityPublished(activity.Status) {
+		if details := onlineChangeViolations(ctx, activity, &updated, current, next, oldGames, newGames); len(details) > 0 {
+			return nil, ErrOnlineChangeForbidden.WithDetails(details...)
+		}
+	}
+	stockDeltas := models.PrizeStockDeltas(ctx, oldGames, newGames)
+
+	// 5. 保存更新，修订号不匹配时返回冲突
+	if err := s.activityRepo.Update(ctx, &updated, stockDeltas); err != nil {
+		return nil, fmt.Errorf("failed to update activity: %w", err)
+	}
+
+	saved, err := s.activityRepo.FindByID(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find activity: %w", err)
+	}
+	return newActivityResponse(saved), nil
+}
+
+// onlineChangeViolations 已发布的活动只允许延长结束时间和增加奖品库存，返回不允许的修改
+func onlineChangeViolations(ctx context.Context, before, after *entity.Activity, oldConfig, newConfig models.ActivityConfigJSON, oldGames, newGames []models.GameInterface) []string {
+	var details []string
+	if after.Name != before.Name {
+		details = append(details, "name: cannot be changed while online")
+	}
+	if after.StartAt != before.StartAt {
+		details = append(details, "start_at: cannot be changed while online")
+	}
+	if after.EndAt < before.EndAt {
+		details = append(details, "end_at: can only be extended while online")
+	}
+	if !reflect.DeepEqual(oldConfig.Enrollment, newConfig.Enrollment) {
+		details = append(details, "enrollment: cannot be changed while online")
+	}
+	if err := models.CheckStockOnlyChange(ctx, oldGames, newGames); err != nil {
+		details = append(details, fmt.Sprintf("games: %v", err))
+	}
+	return details
 }
 
 // GetActivity 获取活动信息
@@ -282,55 +728,224 @@ func (s *activityService) GetActivity(ctx context.Context, activityID int64) (*A
 	// 获取活动
 	activity, err := s.activityRepo.FindByID(ctx, activityID)
 	if err != nil {
-		return nil, ErrActivityNotFound
+		return nil, fmt.Errorf("failed to find activity: %w", err)
 	}
 
 	// 转换为响应
-	return &ActivityResponse{
-		ID:        activity.ID,
-		Category:  activity.Category,
-		Version:   activity.Version,
-		Name:      activity.Name,
-		Config:    activity.Config,
-		StartAt:   activity.StartAt,
-		EndAt:     activity.EndAt,
-		Status:    activity.Status,
-		CreatedAt: activity.CreatedAt,
-		UpdatedAt: activity.UpdatedAt,
-	}, nil
+	return newActivityResponse(activity), nil
 }
 
-// Participate 参与活动
-func (s *activityService) Participate(ctx context.Context, req *ParticipateRequest) (*ParticipationResponse, error) {
-	// 获取活动
-	activity, err := s.activityRepo.FindByID(ctx, 0)
+// TransitionActivity 按操作变更活动状态，检查状态机和前置条件后写入状态变更记录
+func (s *activityService) TransitionActivity(ctx context.Context, activityID int64, operator string, req *TransitionActivityRequest) (*ActivityResponse, error) {
+	// 1. 获取活动并计算目标状态
+	activity, err := s.activityRepo.FindByID(ctx, activityID)
 	if err != nil {
-		return nil, ErrActivityNotFound
+		return nil, fmt.Errorf("failed to find activity: %w", err)
+	}
+	to, err := models.NextActivityStatus(activity.Status, req.Event)
+	if err != nil {
+		return nil, ErrInvalidTransition.WithDetails(err.Error())
 	}
 
-	// 检查活动状态
-	now := time.Now().Unix()
-	if now < activity.StartAt {
-		return nil, ErrActivityNotStarted
+	// 2. 检查前置条件
+	details, err := s.transitionGuardViolations(ctx, activity, req.Event, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	if len(details) > 0 {
+		return nil, ErrTransitionGuardFailed.WithDetails(details...)
 	}
-	if now > activity.EndAt {
-		return nil, ErrActivityEnded
+
+	// 3. 变更状态，期间活动被修改时返回冲突
+	history := &entity.ActivityStatusHistory{
+		Event:    req.Event,
+		ToStatus: to,
+		Operator: operator,
+		Reason:   req.Reason,
 	}
-	if activity.Status != 1 {
-		return nil, ErrGameClosed
+	if err := s.activityRepo.Transition(ctx, activity, history); err != nil {
+		return nil, fmt.Errorf("failed to transition activity: %w", err)
 	}
 
-	// TODO: 实现具体的参与逻辑
-	// 这里需要根据不同的活动类型和玩法类型实现不同的参与逻辑
-	// 建议将这部分逻辑抽象到独立的GameService中
+	saved, err := s.activityRepo.FindByID(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find activity: %w", err)
+	}
+	return newActivityResponse(saved), nil
+}
 
-	return nil, nil
+// transitionGuardViolations 检查状态变更的前置条件，返回不满足的条件
+// 提交审核及之后的上线操作要求配置有效；审核通过要求开始时间在未来，上线和恢复要求活动未过结束时间；
+// 审核通过和上线还要求折扣码奖品的码池已导入足够的码
+func (s *activityService) transitionGuardViolations(ctx context.Context, activity *entity.Activity, event models.ActivityEvent, now int64) ([]string, error) {
+	switch event {
+	case models.ActivityEventSubmit, models.ActivityEventApprove, models.ActivityEventPublish, models.ActivityEventResume:
+	default:
+		return nil, nil
+	}
+
+	// 配置有效
+	var config models.ActivityConfigJSON
+	if err := json.Unmarshal([]byte(activity.Config), &config); err != nil {
+		return []string{fmt.Sprintf("config: %v", err)}, nil
+	}
+	if err := config.Validate(ctx); err != nil {
+		var configErrs models.ConfigErrors
+		if !errors.As(err, &configErrs) {
+			return []string{fmt.Sprintf("config: %v", err)}, nil
+		}
+		details := make([]string, 0, len(configErrs))
+		for _, fieldErr := range configErrs {
+			details = append(details, "config."+fieldErr.String())
+		}
+		return details, nil
+	}
+
+	// 活动时间
+	var details []string
+	switch event {
+	case models.ActivityEventApprove:
+		if activity.StartAt <= now {
+			details = append(details, "start_at: must be in the future")
+		}
+	case models.ActivityEventPublish, models.ActivityEventResume:
+		if activity.EndAt <= now {
+			details = append(details, "end_at: activity window has passed")
+		}
+	}
+
+	// 库存就绪
+	if event != models.ActivityEventApprove && event != models.ActivityEventPublish {
+		return details, nil
+	}
+	games, err := models.NewGamesFromConfig(config.Games)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build games: %w", err)
+	}
+	for key, prize := range models.StockPrizes(ctx, games) {
+		if _, ok := prize.(*models.DiscountCodePrize); !ok {
+			continue
+		}
+		available, err := s.codeRepo.CountAvailable(ctx, activity.ID, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count discount codes: %w", err)
+		}
+		if available < prize.StockTotal() {
+			details = append(details, fmt.Sprintf("prize %q: %d discount codes loaded, %d required", key, available, prize.StockTotal()))
+		}
+	}
+	return details, nil
+}
+
+// ListStatusHistory 查询活动的状态变更记录
+func (s *activityService) ListStatusHistory(ctx context.Context, activityID int64) (*ListStatusHistoryResponse, error) {
+	if _, err := s.activityRepo.FindByID(ctx, activityID); err != nil {
+		return nil, fmt.Errorf("failed to find activity: %w", err)
+	}
+
+	histories, err := s.activityRepo.ListStatusHistory(ctx, activityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list status history: %w", err)
+	}
+
+	resp := &ListStatusHistoryResponse{List: make([]*StatusHistoryResponse, 0, len(histories))}
+	for _, history := range histories {
+		resp.List = append(resp.List, &StatusHistoryResponse{
+			ID:         history.ID,
+			Event:      history.Event,
+			FromStatus: models.ActivityStatusName(history.FromStatus),
+			ToStatus:   models.ActivityStatusName(history.ToStatus),
+			Operator:   history.Operator,
+			Reason:     history.Reason,
+			CreatedAt:  history.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// Participate 报名参加活动，报名后返回活动下的玩法及当前用户可参与的状态
+func (s *activityService) Participate(ctx context.Context, activityID int64, req *ParticipateRequest) (*ParticipateResponse, error) {
+	// 1. 获取活动
+	activity, err := s.activityRepo.GetActivity(ctx, strconv.FormatInt(activityID, 10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity: %w", err)
+	}
+
+	// 2. 检查活动状态
+	if err := checkActivityStatus(activity); err != nil {
+		return nil, err
+	}
+
+	// 3. 检查报名条件
+	user := models.User{Uid: req.UserID}
+	enrollment := activity.Meta().Enrollment
+	if !enrollment.Eligible(user) {
+		return nil, models.ErrNotEligible
+	}
+	var maxParticipants int64
+	if enrollment != nil {
+		maxParticipants = enrollment.MaxParticipants
+	}
+
+	// 4. 报名，重复报名返回已有记录
+	record, created, err := s.enrollmentRepo.Enroll(ctx, activityID, user.Uid, maxParticipants)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enroll: %w", err)
+	}
+
+	// 5. 汇总玩法状态
+	resp := &ParticipateResponse{
+		EnrollmentID:    record.ID,
+		ActivityID:      record.ActivityID,
+		AlreadyEnrolled: !created,
+		EnrolledAt:      record.CreatedAt,
+		Games:           make([]*PlayableGameResponse, 0, len(activity.Games())),
+	}
+	for _, game := range activity.Games() {
+		gameState := game.GameState(ctx)
+		userState := game.UserState(ctx, user)
+		resp.Games = append(resp.Games, &PlayableGameResponse{
+			Name:      game.Name(ctx),
+			Type:      game.Type(ctx),
+			GameState: string(gameState),
+			UserState: userState,
+			Playable:  gameState == models.GameStateOPEN && models.CanParticipate(userState),
+		})
+	}
+	return resp, nil
 }
 
 // GetParticipation 获取参与记录
-func (s *activityService) GetParticipation(ctx context.Context, activityID int64, userID string) (*ParticipationResponse, error) {
-	// TODO: 实现获取参与记录的逻辑
-	return nil, nil
+func (s *activityService) GetParticipation(ctx context.Context, activityID int64, req *GetParticipationReq) (*GetParticipationResponse, error) {
+	offset := (req.Page - 1) * req.PageSize
+	participations, total, err := s.participationRepo.FindByUser(ctx, activityID, req.UserID, offset, req.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find participations: %w", err)
+	}
+
+	resp := &GetParticipationResponse{
+		List:     make([]*ParticipationResponse, 0, len(participations)),
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	for _, p := range participations {
+		item := &ParticipationResponse{
+			ID:         p.ID,
+			ActivityID: p.ActivityID,
+			UserID:     p.UserID,
+			GameType:   p.GameType,
+			GameTarget: p.GameTarget,
+			State:      p.State,
+			CreatedAt:  p.CreatedAt,
+			UpdatedAt:  p.UpdatedAt,
+		}
+		if p.Extra != "" {
+			item.Extra = json.RawMessage(p.Extra)
+		}
+		resp.List = append(resp.List, item)
+	}
+	return resp, nil
 }
 
 // DistributePrize 发放奖品
@@ -338,3 +953,205 @@ func (s *activityService) DistributePrize(ctx context.Context, activityID int64,
 	// TODO: 实现奖品发放逻辑
 	return nil, nil
 }
+
+// ImportDiscountCodes 从CSV导入折扣码，每行第一列为折扣码，可带 code 表头
+func (s *activityService) ImportDiscountCodes(ctx context.Context, activityID int64, prizeKey string, csvFile io.Reader, expiresAt int64) (*DiscountCodePoolResponse, error) {
+	if _, err := s.findDiscountCodePrize(ctx, activityID, prizeKey); err != nil {
+		return nil, err
+	}
+
+	codes, err := parseDiscountCodeCSV(csvFile)
+	if err != nil {
+		return nil, ErrInvalidParam.WithDetails(err.Error())
+	}
+	if len(codes) == 0 {
+		return nil, ErrInvalidParam.WithDetails("file: no discount codes found")
+	}
+
+	imported, err := s.codeRepo.Import(ctx, activityID, prizeKey, codes, unixTimePtr(expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to import discount codes: %w", err)
+	}
+	return s.discountCodePoolResponse(ctx, activityID, prizeKey, imported, int64(len(codes))-imported)
+}
+
+// GenerateDiscountCodes 按奖品配置的前缀生成折扣码
+func (s *activityService) GenerateDiscountCodes(ctx context.Context, activityID int64, req *GenerateDiscountCodesRequest) (*DiscountCodePoolResponse, error) {
+	prize, err := s.findDiscountCodePrize(ctx, activityID, req.PrizeKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// 随机码可能与已有的码冲突，冲突的部分重新生成
+	var imported int64
+	for attempt := 0; attempt < 3 && imported < int64(req.Count); attempt++ {
+		codes, err := models.GenerateDiscountCodes(prize.DiscountCode, req.Count-int(imported))
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate discount codes: %w", err)
+		}
+		n, err := s.codeRepo.Import(ctx, activityID, req.PrizeKey, codes, unixTimePtr(req.ExpiresAt))
+		if err != nil {
+			return nil, fmt.Errorf("failed to import discount codes: %w", err)
+		}
+		imported += n
+	}
+	return s.discountCodePoolResponse(ctx, activityID, req.PrizeKey, imported, int64(req.Count)-imported)
+}
+
+// RedeemDiscountCode 核销用户获得的折扣码
+func (s *activityService) RedeemDiscountCode(ctx context.Context, userID, code string) (*RedeemDiscountCodeResponse, error) {
+	if err := s.codeRepo.Redeem(ctx, code, userID); err != nil {
+		return nil, fmt.Errorf("failed to redeem discount code: %w", err)
+	}
+	return &RedeemDiscountCodeResponse{Success: true}, nil
+}
+
+// findDiscountCodePrize 查找活动中指定库存标识的折扣码奖品
+func (s *activityService) findDiscountCodePrize(ctx context.Context, activityID int64, prizeKey string) (*models.DiscountCodePrize, error) {
+	activity, err := s.activityRepo.GetActivity(ctx, strconv.FormatInt(activityID, 10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity: %w", err)
+	}
+	for _, game := range activity.Games() {
+		provider, ok := game.(models.PrizeProvider)
+		if !ok {
+			continue
+		}
+		if prize, ok := provider.Prizes(ctx)[prizeKey].(*models.DiscountCodePrize); ok {
+			return prize, nil
+		}
+	}
+	return nil, ErrInvalidParam.WithDetails(fmt.Sprintf("prize_key: no discount code prize %q in activity", prizeKey))
+}
+
+// discountCodePoolResponse 组装码池变更响应
+func (s *activityService) discountCodePoolResponse(ctx context.Context, activityID int64, prizeKey string, imported, skipped int64) (*DiscountCodePoolResponse, error) {
+	available, err := s.codeRepo.CountAvailable(ctx, activityID, prizeKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count available discount codes: %w", err)
+	}
+	return &DiscountCodePoolResponse{
+		Imported:  imported,
+		Skipped:   skipped,
+		Available: available,
+	}, nil
+}
+
+// parseDiscountCodeCSV 解析折扣码CSV，忽略空行和文件内重复的码
+func parseDiscountCodeCSV(r io.Reader) ([]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var codes []string
+	seen := make(map[string]struct{})
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("file: line %d: %v", line, err)
+		}
+		if len(row) == 0 {
+			continue
+		}
+		code := strings.TrimSpace(row[0])
+		if code == "" || (line == 1 && strings.EqualFold(code, "code")) {
+			continue
+		}
+		if len(code) > models.DiscountCodeMaxLen {
+			return nil, fmt.Errorf("file: line %d: discount code is longer than %d characters", line, models.DiscountCodeMaxLen)
+		}
+		if _, dup := seen[code]; dup {
+			continue
+		}
+		seen[code] = struct{}{}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// Record 写入后台操作日志
+func (s *auditService) Record(ctx context.Context, entry *entity.AdminAuditLog) error {
+	return s.auditRepo.Create(ctx, entry)
+}
+
+// ListAuditLogs 分页查询后台操作日志
+func (s *auditService) ListAuditLogs(ctx context.Context, req *ListAuditLogsReq) (*ListAuditLogsResponse, error) {
+	offset := (req.Page - 1) * req.PageSize
+	logs, total, err := s.auditRepo.List(ctx, req.Actor, offset, req.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+
+	resp := &ListAuditLogsResponse{
+		List:     make([]*AuditLogResponse, 0, len(logs)),
+		Total:    total,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	for _, l := range logs {
+		resp.List = append(resp.List, &AuditLogResponse{
+			ID:         l.ID,
+			Actor:      l.Actor,
+			Roles:      l.Roles,
+			Method:     l.Method,
+			Path:       l.Path,
+			ResourceID: l.ResourceID,
+			StatusCode: l.StatusCode,
+			ClientIP:   l.ClientIP,
+			CreatedAt:  l.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// newActivityResponse 将活动实体转换为响应
+func newActivityResponse(activity *entity.Activity) *ActivityResponse {
+	return &ActivityResponse{
+		ID:         activity.ID,
+		Category:   activity.Category,
+		Version:    activity.Version,
+		Name:       activity.Name,
+		Config:     activity.Config,
+		StartAt:    activity.StartAt,
+		EndAt:      activity.EndAt,
+		Status:     activity.Status,
+		StatusName: models.ActivityStatusName(activity.Status),
+		Revision:   activity.Revision,
+		CreatedAt:  activity.CreatedAt,
+		UpdatedAt:  activity.UpdatedAt,
+	}
+}
+
+// unixTimePtr 将时间戳转换为时间指针，0 表示不设置
+func unixTimePtr(ts int64) *time.Time {
+	if ts <= 0 {
+		return nil
+	}
+	t := time.Unix(ts, 0)
+	return &t
+}
+
+// buildActivityConfig 校验活动配置并序列化为存储格式
+// 配置不合法时返回携带字段错误详情的ErrInvalidParam
+func buildActivityConfig(ctx context.Context, config models.ActivityConfigJSON) (string, error) {
+	if err := config.Validate(ctx); err != nil {
+		var configErrs models.ConfigErrors
+		if errors.As(err, &configErrs) {
+			details := make([]string, 0, len(configErrs))
+			for _, fieldErr := range configErrs {
+				details = append(details, fieldErr.String())
+			}
+			return "", ErrInvalidParam.WithDetails(details...)
+		}
+		return "", ErrInvalidParam.WithDetails(err.Error())
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal activity config: %w", err)
+	}
+	return string(data), nil
+}
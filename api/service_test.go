@@ -0,0 +1,510 @@
+package api
+
+import (
+	"Activity/config"
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeActivities 总是返回同一个活动的活动仓储
+type fakeActivities struct {
+	activity models.ActivityInterface
+}
+
+func (r fakeActivities) GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error) {
+	return r.activity, nil
+}
+
+// fakeParticipations 写入参与记录时返回指定错误的参与记录仓储
+type fakeParticipations struct {
+	repository.ParticipationRepository
+	created int64
+	err     error
+}
+
+func (r *fakeParticipations) Create(ctx context.Context, participation *entity.ActivityParticipation) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.created++
+	return nil
+}
+
+func (r *fakeParticipations) CountParticipations(ctx context.Context, activityID int64, gameName, uid string) (int64, error) {
+	return r.created, nil
+}
+
+// countDraws 记录抽奖次数，用于判断玩法是否被再次执行
+type countDraws struct {
+	draws int
+}
+
+func (d *countDraws) RecordDraw(ctx context.Context, record models.DrawRecord) error {
+	d.draws++
+	return nil
+}
+
+// fakeRequests 内存中的参与请求仓储
+type fakeRequests struct {
+	requests map[string]*entity.ParticipationRequest
+}
+
+func (r *fakeRequests) Begin(ctx context.Context, request *entity.ParticipationRequest) (*entity.ParticipationRequest, bool, error) {
+	if existing, ok := r.requests[request.IdempotencyKey]; ok {
+		copied := *existing
+		return &copied, false, nil
+	}
+	request.ID = int64(len(r.requests) + 1)
+	stored := *request
+	r.requests[request.IdempotencyKey] = &stored
+	return request, true, nil
+}
+
+func (r *fakeRequests) Takeover(ctx context.Context, id int64, now time.Time, lease time.Duration) (bool, error) {
+	for _, request := range r.requests {
+		if request.ID == id && request.Status == entity.ParticipationRequestStatusPending && request.ExpiresAt.Before(now) {
+			request.ExpiresAt = now.Add(lease)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRequests) Complete(ctx context.Context, id int64, response string) error {
+	return r.finish(id, entity.ParticipationRequestStatusCompleted, response)
+}
+
+func (r *fakeRequests) Fail(ctx context.Context, id int64, response string) error {
+	return r.finish(id, entity.ParticipationRequestStatusFailed, response)
+}
+
+func (r *fakeRequests) finish(id int64, status int64, response string) error {
+	for _, request := range r.requests {
+		if request.ID == id {
+			request.Status = status
+			request.Response = &response
+		}
+	}
+	return nil
+}
+
+func (r *fakeRequests) Abort(ctx context.Context, id int64) error {
+	for key, request := range r.requests {
+		if request.ID == id && request.Status == entity.ParticipationRequestStatusPending {
+			delete(r.requests, key)
+		}
+	}
+	return nil
+}
+
+func TestParticipateOnceAfterPerformFailure(t *testing.T) {
+	ctx := context.Background()
+	user := models.User{Uid: "user-1"}
+	now := time.Now().Unix()
+
+	newService := func() (GameService, *fakeParticipations, *countDraws, *fakeRequests) {
+		participations := &fakeParticipations{}
+		draws := &countDraws{}
+		requests := &fakeRequests{requests: map[string]*entity.ParticipationRequest{}}
+		activity := &models.CommunityActivity{
+			MetaActivity: models.MetaActivity{ID: 1, StartAt: now - 60, EndAt: now + 3600, Status: models.ActivityStatusOnline},
+			GameList: []models.GameInterface{&models.LotteryGame{
+				Name_:    "幸运抽奖",
+				State:    models.GameStateOPEN,
+				MaxDraws: 3,
+				Slots:    []models.LotterySlot{{Type: models.LotterySlotThanks, Name: "谢谢参与", Probability: 100}},
+			}},
+		}
+		models.BindRuntime(activity, &models.Runtime{ActivityID: 1, Participations: participations, Draws: draws})
+		service := NewGameService(fakeActivities{activity: activity}, nil, participations, nil, requests, nil, nil)
+		return service, participations, draws, requests
+	}
+
+	t.Run("执行前失败时删除请求记录", func(t *testing.T) {
+		service, _, draws, requests := newService()
+		if _, err := service.ParticipateGame(ctx, user, "1", "幸运抽奖", "key-1", []byte(`{"type":"unknown"}`)); err == nil {
+			t.Fatal("ParticipateGame() error = nil, want decode error")
+		}
+		if len(requests.requests) != 0 || draws.draws != 0 {
+			t.Fatalf("requests = %d, draws = %d, want the request aborted before drawing", len(requests.requests), draws.draws)
+		}
+		if _, err := service.ParticipateGame(ctx, user, "1", "幸运抽奖", "key-1", []byte(`{}`)); err != nil {
+			t.Fatalf("ParticipateGame() retry error = %v", err)
+		}
+		if draws.draws != 1 {
+			t.Errorf("draws = %d, want 1", draws.draws)
+		}
+	})
+
+	t.Run("执行后业务错误在重试时原样返回", func(t *testing.T) {
+		service, participations, draws, requests := newService()
+		participations.err = models.ErrUserCannotParticipate
+		_, err := service.ParticipateGame(ctx, user, "1", "幸运抽奖", "key-1", []byte(`{}`))
+		if !errors.Is(err, models.ErrUserCannotParticipate) {
+			t.Fatalf("ParticipateGame() error = %v, want ErrUserCannotParticipate", err)
+		}
+		if request := requests.requests["key-1"]; request == nil || request.Status != entity.ParticipationRequestStatusFailed {
+			t.Fatalf("request = %+v, want failed", request)
+		}
+
+		// 即使参与记录恢复可写，重试也不会再次执行玩法
+		participations.err = nil
+		_, err = service.ParticipateGame(ctx, user, "1", "幸运抽奖", "key-1", []byte(`{}`))
+		var apiErr *Error
+		if !errors.As(err, &apiErr) || apiErr.Code != ErrUserAlreadyParticipated.Code {
+			t.Fatalf("ParticipateGame() replay error = %v, want %v", err, ErrUserAlreadyParticipated)
+		}
+		if draws.draws != 1 {
+			t.Errorf("draws = %d, want 1", draws.draws)
+		}
+	})
+
+	t.Run("执行后系统错误保持处理中", func(t *testing.T) {
+		service, participations, draws, requests := newService()
+		participations.err = errors.New("connection reset")
+		if _, err := service.ParticipateGame(ctx, user, "1", "幸运抽奖", "key-1", []byte(`{}`)); toAPIError(err) != ErrSystem {
+			t.Fatalf("ParticipateGame() error = %v, want system error", err)
+		}
+		if request := requests.requests["key-1"]; request == nil || request.Status != entity.ParticipationRequestStatusPending {
+			t.Fatalf("request = %+v, want pending", request)
+		}
+
+		// 租约到期前的重试被拒绝，到期后由重试接管并重新执行
+		participations.err = nil
+		if _, err := service.ParticipateGame(ctx, user, "1", "幸运抽奖", "key-1", []byte(`{}`)); !errors.Is(err, ErrRequestInProgress) {
+			t.Fatalf("ParticipateGame() retry error = %v, want ErrRequestInProgress", err)
+		}
+		requests.requests["key-1"].ExpiresAt = time.Now().Add(-time.Second)
+		if _, err := service.ParticipateGame(ctx, user, "1", "幸运抽奖", "key-1", []byte(`{}`)); err != nil {
+			t.Fatalf("ParticipateGame() takeover error = %v", err)
+		}
+		if draws.draws != 2 || requests.requests["key-1"].Status != entity.ParticipationRequestStatusCompleted {
+			t.Errorf("draws = %d, request = %+v, want 2 draws and completed", draws.draws, requests.requests["key-1"])
+		}
+	})
+}
+
+// fakeActivityStore 内存中的单个活动，更新以修订号为前置条件并记录库存变化
+type fakeActivityStore struct {
+	repository.ActivityRepository
+	activity *entity.Activity
+	deltas   map[string]int64
+}
+
+func (r *fakeActivityStore) FindByID(ctx context.Context, id int64) (*entity.Activity, error) {
+	copied := *r.activity
+	return &copied, nil
+}
+
+func (r *fakeActivityStore) Create(ctx context.Context, activity *entity.Activity) error {
+	activity.ID = 1
+	copied := *activity
+	r.activity = &copied
+	return nil
+}
+
+func (r *fakeActivityStore) Update(ctx context.Context, activity *entity.Activity, stockDeltas map[string]int64) error {
+	if activity.Revision != r.activity.Revision {
+		return repository.ErrRevisionConflict
+	}
+	copied := *activity
+	copied.Revision++
+	r.activity = &copied
+	r.deltas = stockDeltas
+	return nil
+}
+
+// lotteryGames 返回示例抽奖活动的玩法配置，modify用于修改抽奖玩法的配置
+func lotteryGames(t *testing.T, modify func(game map[string]interface{})) []models.GameConfig {
+	t.Helper()
+	var activity models.ActivityConfigJSON
+	if err := json.Unmarshal([]byte(config.LotteryActivityExample), &activity); err != nil {
+		t.Fatal(err)
+	}
+	var game map[string]interface{}
+	if err := json.Unmarshal(activity.Games[0].Config, &game); err != nil {
+		t.Fatal(err)
+	}
+	modify(game)
+	data, err := json.Marshal(game)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activity.Games[0].Config = data
+	return activity.Games
+}
+
+// lotterySlot 返回抽奖玩法配置中第i个槽位
+func lotterySlot(game map[string]interface{}, i int) map[string]interface{} {
+	return game["slots"].([]interface{})[i].(map[string]interface{})
+}
+
+func TestUpdateActivity(t *testing.T) {
+	ctx := context.Background()
+	var example models.ActivityConfigJSON
+	if err := json.Unmarshal([]byte(config.LotteryActivityExample), &example); err != nil {
+		t.Fatal(err)
+	}
+	int64Ptr := func(v int64) *int64 { return &v }
+	stringPtr := func(v string) *string { return &v }
+
+	tests := []struct {
+		name        string
+		status      int64
+		revision    *int64 // If-Match中的修订号，为空表示不校验
+		req         UpdateActivityRequest
+		wantErr     *Error
+		wantDetails []string
+		wantDeltas  map[string]int64
+	}{
+		{
+			name:     "修订号不一致",
+			status:   models.ActivityStatusDraft,
+			revision: int64Ptr(2),
+			req:      UpdateActivityRequest{Name: stringPtr("新名称")},
+			wantErr:  ErrActivityModified,
+		},
+		{
+			name:     "草稿可以修改任意配置",
+			status:   models.ActivityStatusDraft,
+			revision: int64Ptr(3),
+			req: UpdateActivityRequest{
+				Name:  stringPtr("新名称"),
+				EndAt: int64Ptr(example.EndAt - 60),
+				Games: lotteryGames(t, func(game map[string]interface{}) {
+					lotterySlot(game, 1)["product"].(map[string]interface{})["total_num"] = 10
+				}),
+			},
+			wantDeltas: map[string]int64{"幸运抽奖#1": -10},
+		},
+		{
+			name:   "上线后增加库存并延长结束时间",
+			status: models.ActivityStatusOnline,
+			req: UpdateActivityRequest{
+				EndAt: int64Ptr(example.EndAt + 3600),
+				Games: lotteryGames(t, func(game map[string]interface{}) {
+					lotterySlot(game, 0)["discount_code"].(map[string]interface{})["total_num"] = 600
+				}),
+			},
+			wantDeltas: map[string]int64{"幸运抽奖#0": 100},
+		},
+		{
+			name:        "上线后缩短结束时间",
+			status:      models.ActivityStatusOnline,
+			req:         UpdateActivityRequest{EndAt: int64Ptr(example.EndAt - 60)},
+			wantErr:     ErrOnlineChangeForbidden,
+			wantDetails: []string{"end_at: can only be extended while online"},
+		},
+		{
+			name:        "上线后重命名",
+			status:      models.ActivityStatusOnline,
+			req:         UpdateActivityRequest{Name: stringPtr("新名称")},
+			wantErr:     ErrOnlineChangeForbidden,
+			wantDetails: []string{"name: cannot be changed while online"},
+		},
+		{
+			name:   "上线后减少库存",
+			status: models.ActivityStatusPaused,
+			req: UpdateActivityRequest{Games: lotteryGames(t, func(game map[string]interface{}) {
+				lotterySlot(game, 1)["product"].(map[string]interface{})["total_num"] = 10
+			})},
+			wantErr:     ErrOnlineChangeForbidden,
+			wantDetails: []string{`games: games[0]: prize "幸运抽奖#1": stock cannot be reduced`},
+		},
+		{
+			name:   "上线后删除奖品",
+			status: models.ActivityStatusOnline,
+			req: UpdateActivityRequest{Games: lotteryGames(t, func(game map[string]interface{}) {
+				slots := game["slots"].([]interface{})
+				lotterySlot(game, 2)["probability"] = 70
+				game["slots"] = append([]interface{}{slots[0]}, slots[2])
+			})},
+			wantErr:     ErrOnlineChangeForbidden,
+			wantDetails: []string{"games: games[0]: prizes cannot be added or removed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeActivityStore{activity: &entity.Activity{
+				ID:       1,
+				Category: example.Category,
+				Version:  example.Version,
+				Name:     example.Name,
+				Config:   config.LotteryActivityExample,
+				StartAt:  example.StartAt,
+				EndAt:    example.EndAt,
+				Status:   tt.status,
+				Revision: 3,
+			}}
+			service := NewActivityService(store, nil, nil, nil)
+
+			resp, err := service.UpdateActivity(ctx, 1, tt.revision, &tt.req)
+			if tt.wantErr != nil {
+				var apiErr *Error
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantErr.Code {
+					t.Fatalf("UpdateActivity() error = %v, want %v", err, tt.wantErr)
+				}
+				if !reflect.DeepEqual(apiErr.Details, tt.wantDetails) {
+					t.Errorf("details = %q, want %q", apiErr.Details, tt.wantDetails)
+				}
+				if store.activity.Revision != 3 {
+					t.Error("rejected update was saved")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateActivity() error = %v", err)
+			}
+			if resp.Revision != 4 {
+				t.Errorf("revision = %d, want 4", resp.Revision)
+			}
+			if !reflect.DeepEqual(store.deltas, tt.wantDeltas) {
+				t.Errorf("stock deltas = %v, want %v", store.deltas, tt.wantDeltas)
+			}
+		})
+	}
+}
+
+// fakeCodePool 各奖品码池中可用的折扣码数量
+type fakeCodePool struct {
+	repository.DiscountCodeRepository
+	available map[string]int64
+}
+
+func (p fakeCodePool) CountAvailable(ctx context.Context, activityID int64, prizeKey string) (int64, error) {
+	return p.available[prizeKey], nil
+}
+
+func TestTransitionGuardViolations(t *testing.T) {
+	ctx := context.Background()
+	const now = 1678000000
+	loaded := map[string]int64{"幸运抽奖#0": 500}
+
+	tests := []struct {
+		name      string
+		event     models.ActivityEvent
+		config    string
+		startAt   int64
+		endAt     int64
+		available map[string]int64
+		want      []string
+	}{
+		{"提交审核", models.ActivityEventSubmit, config.LotteryActivityExample, now - 60, now - 30, nil, nil},
+		{"提交审核时配置无效", models.ActivityEventSubmit, "{", now + 60, now + 3600, nil, []string{"config: unexpected end of JSON input"}},
+		{"审核通过", models.ActivityEventApprove, config.LotteryActivityExample, now + 60, now + 3600, loaded, nil},
+		{"审核通过时开始时间已过", models.ActivityEventApprove, config.LotteryActivityExample, now, now + 3600, loaded, []string{"start_at: must be in the future"}},
+		{"上线", models.ActivityEventPublish, config.LotteryActivityExample, now - 60, now + 3600, loaded, nil},
+		{"上线时活动已结束", models.ActivityEventPublish, config.LotteryActivityExample, now - 3600, now, loaded, []string{"end_at: activity window has passed"}},
+		{"上线时折扣码不足", models.ActivityEventPublish, config.LotteryActivityExample, now - 60, now + 3600, map[string]int64{"幸运抽奖#0": 499}, []string{`prize "幸运抽奖#0": 499 discount codes loaded, 500 required`}},
+		{"审核通过时未导入折扣码且开始时间已过", models.ActivityEventApprove, config.LotteryActivityExample, now - 60, now + 3600, nil, []string{
+			"start_at: must be in the future",
+			`prize "幸运抽奖#0": 0 discount codes loaded, 500 required`,
+		}},
+		{"恢复时活动已结束", models.ActivityEventResume, config.LotteryActivityExample, now - 3600, now - 1, nil, []string{"end_at: activity window has passed"}},
+		{"恢复时不检查折扣码", models.ActivityEventResume, config.LotteryActivityExample, now - 60, now + 3600, nil, nil},
+		{"暂停没有前置条件", models.ActivityEventPause, "{", now - 3600, now - 1, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &activityService{codeRepo: fakeCodePool{available: tt.available}}
+			activity := &entity.Activity{ID: 1, Config: tt.config, StartAt: tt.startAt, EndAt: tt.endAt}
+
+			got, err := service.transitionGuardViolations(ctx, activity, tt.event, now)
+			if err != nil {
+				t.Fatalf("transitionGuardViolations() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("transitionGuardViolations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateActivityStartsAsDraft(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &fakeActivityStore{}
+	h := NewHandler(nil, NewActivityService(store, nil, nil, nil), nil, nil, nil)
+
+	// 请求中不需要状态，新建的活动总是草稿
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/admin/activity", strings.NewReader(config.LotteryActivityExample))
+	c.Request.Header.Set("Content-Type", "application/json")
+	h.CreateActivity(c)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data ActivityResponse `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.Status != models.ActivityStatusDraft || store.activity.Status != models.ActivityStatusDraft {
+		t.Errorf("status = %d, saved %d, want draft", resp.Data.Status, store.activity.Status)
+	}
+}
+
+func TestActivityRejectsLongDiscountCodePrefix(t *testing.T) {
+	ctx := context.Background()
+	var example models.ActivityConfigJSON
+	if err := json.Unmarshal([]byte(config.LotteryActivityExample), &example); err != nil {
+		t.Fatal(err)
+	}
+	withPrefix := func(prefix string) []models.GameConfig {
+		return lotteryGames(t, func(game map[string]interface{}) {
+			lotterySlot(game, 0)["discount_code"].(map[string]interface{})["discount_code"] = prefix
+		})
+	}
+	longest := strings.Repeat("P", models.DiscountCodeMaxLen-10)
+	wantDetails := []string{"games[0].config: slots[0]: discount code prefix must not be longer than 40 characters"}
+
+	store := &fakeActivityStore{}
+	service := NewActivityService(store, nil, nil, nil)
+	create := func(prefix string) error {
+		_, err := service.CreateActivity(ctx, &CreateActivityRequest{
+			Name:     example.Name,
+			Category: example.Category,
+			Version:  example.Version,
+			StartAt:  example.StartAt,
+			EndAt:    example.EndAt,
+			Games:    withPrefix(prefix),
+		})
+		return err
+	}
+
+	// 前缀加上10位随机后缀正好50个字符时允许，超过时创建和修改都返回参数错误
+	if err := create(longest); err != nil {
+		t.Fatalf("CreateActivity(%d characters) error = %v", len(longest), err)
+	}
+	if apiErr := toAPIError(create(longest + "X")); apiErr.Code != ErrInvalidParam.Code || !reflect.DeepEqual(apiErr.Details, wantDetails) {
+		t.Errorf("CreateActivity(long prefix) error = %+v, want ErrInvalidParam with %q", apiErr, wantDetails)
+	}
+	_, err := service.UpdateActivity(ctx, 1, nil, &UpdateActivityRequest{Games: withPrefix(longest + "X")})
+	if apiErr := toAPIError(err); apiErr.Code != ErrInvalidParam.Code || !reflect.DeepEqual(apiErr.Details, wantDetails) {
+		t.Errorf("UpdateActivity(long prefix) error = %+v, want ErrInvalidParam with %q", apiErr, wantDetails)
+	}
+
+	codes, err := models.GenerateDiscountCodes(longest, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range codes {
+		if len(code) != models.DiscountCodeMaxLen {
+			t.Errorf("generated code %q has %d characters, want %d", code, len(code), models.DiscountCodeMaxLen)
+		}
+	}
+}
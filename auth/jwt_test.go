@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMACVerifier(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewHMACVerifier([]byte("local-secret"), "activity")
+	verifier.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	valid, err := verifier.Sign(Claims{Subject: "u1", Roles: []string{"operator"}, ExpiresAt: now.Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	claims, err := verifier.Verify(ctx, valid)
+	if err != nil {
+		t.Fatalf("verify valid token: %v", err)
+	}
+	if claims.Subject != "u1" || len(claims.Roles) != 1 || claims.Roles[0] != "operator" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	exp := now.Add(time.Hour).Unix()
+	expired, _ := verifier.Sign(Claims{Subject: "u1", ExpiresAt: now.Add(-time.Second).Unix()})
+	noExpiry, _ := verifier.Sign(Claims{Subject: "u1"})
+	otherKey, _ := NewHMACVerifier([]byte("other-secret"), "activity").Sign(Claims{Subject: "u1", ExpiresAt: exp})
+	otherIssuer, _ := verifier.Sign(Claims{Subject: "u1", Issuer: "other", ExpiresAt: exp})
+	noSubject, _ := verifier.Sign(Claims{ExpiresAt: exp})
+	parts := strings.Split(valid, ".")
+	algNone := encodeSegment([]byte(`{"alg":"none"}`)) + "." + parts[1] + "."
+
+	cases := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", expired, ErrTokenExpired},
+		{"missing exp", noExpiry, ErrInvalidToken},
+		{"wrong key", otherKey, ErrInvalidToken},
+		{"wrong issuer", otherIssuer, ErrInvalidToken},
+		{"missing subject", noSubject, ErrInvalidToken},
+		{"alg none", algNone, ErrInvalidToken},
+		{"tampered payload", parts[0] + "." + encodeSegment([]byte(`{"sub":"admin"}`)) + "." + parts[2], ErrInvalidToken},
+		{"malformed", "not-a-token", ErrInvalidToken},
+	}
+	for _, tc := range cases {
+		if _, err := verifier.Verify(ctx, tc.token); !errors.Is(err, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package auth
+
+// Role 后台角色，来自令牌中的roles声明
+type Role = string
+
+const (
+	RoleOperator Role = "operator" // 运营：可创建、修改活动和管理奖品
+	RoleAuditor  Role = "auditor"  // 审计：可查看活动和后台操作日志
+	RoleViewer   Role = "viewer"   // 只读：只能查看活动
+)
+
+// Permission 后台接口权限，在注册路由时声明
+type Permission string
+
+const (
+	PermActivityRead  Permission = "activity:read"  // 查看活动
+	PermActivityWrite Permission = "activity:write" // 创建、修改活动
+	PermPrizeWrite    Permission = "prize:write"    // 管理奖品与折扣码池
+	PermAuditRead     Permission = "audit:read"     // 查看后台操作日志
+)
+
+// rolePermissions 角色与权限的对应关系
+var rolePermissions = map[Role][]Permission{
+	RoleOperator: {PermActivityRead, PermActivityWrite, PermPrizeWrite},
+	RoleAuditor:  {PermActivityRead, PermAuditRead},
+	RoleViewer:   {PermActivityRead},
+}
+
+// HasPermission 判断声明中的任一角色是否拥有指定权限，未知角色不授予任何权限
+func (c *Claims) HasPermission(perm Permission) bool {
+	for _, role := range c.Roles {
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
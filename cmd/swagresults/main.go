@@ -0,0 +1,19 @@
+// swagresults 在swag init生成的docs中加入按game_type区分的玩法结果定义
+//
+//	swag init && go run ./cmd/swagresults
+package main
+
+import (
+	"Activity/api"
+	"flag"
+	"log"
+)
+
+func main() {
+	dir := flag.String("dir", "docs", "swag生成的文档目录")
+	flag.Parse()
+
+	if err := api.WriteGameResults(*dir); err != nil {
+		log.Fatalf("Failed to write game results: %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package community
+
+import (
+	"Activity/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// postResponse 社区服务返回的帖子
+type postResponse struct {
+	ID        string `json:"id"`
+	AuthorID  string `json:"author_id"`
+	Topic     string `json:"topic"`
+	Content   string `json:"content"`
+	CreatedAt int64  `json:"created_at"` // 发帖时间戳
+}
+
+// HTTPClient 通过HTTP接口访问社区服务
+type HTTPClient struct {
+	baseURL string
+	token   string // 非空时作为Bearer令牌携带
+	client  *http.Client
+}
+
+// NewHTTPClient 创建社区服务客户端，baseURL如 http://community.internal/api
+func NewHTTPClient(baseURL, token string, timeout time.Duration) *HTTPClient {
+	return &HTTPClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// GetPost 请求 GET {baseURL}/posts/{id}，404时返回 models.ErrPostNotFound
+func (c *HTTPClient) GetPost(ctx context.Context, postID string) (*models.Post, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/posts/"+url.PathEscape(postID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request community service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, models.ErrPostNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("community service returned status %d", resp.StatusCode)
+	}
+
+	var post postResponse
+	if err := json.NewDecoder(resp.Body).Decode(&post); err != nil {
+		return nil, fmt.Errorf("failed to decode post: %w", err)
+	}
+	return &models.Post{
+		ID:        post.ID,
+		AuthorID:  post.AuthorID,
+		Topic:     post.Topic,
+		Content:   post.Content,
+		CreatedAt: time.Unix(post.CreatedAt, 0),
+	}, nil
+}
@@ -0,0 +1,94 @@
+package community
+
+import (
+	"Activity/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientGetPost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+		switch r.URL.Path {
+		case "/api/posts/p1":
+			w.Write([]byte(`{"id":"p1","author_id":"u1","topic":"spring","content":"hello","created_at":1700000000}`))
+		case "/api/posts/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(server.URL+"/api/", "secret", time.Second)
+	ctx := context.Background()
+
+	post, err := client.GetPost(ctx, "p1")
+	if err != nil {
+		t.Fatalf("GetPost() error = %v", err)
+	}
+	want := models.Post{ID: "p1", AuthorID: "u1", Topic: "spring", Content: "hello", CreatedAt: time.Unix(1700000000, 0)}
+	if *post != want {
+		t.Errorf("GetPost() = %+v, want %+v", *post, want)
+	}
+
+	if _, err := client.GetPost(ctx, "missing"); !errors.Is(err, models.ErrPostNotFound) {
+		t.Errorf("GetPost(missing) error = %v, want ErrPostNotFound", err)
+	}
+	if _, err := client.GetPost(ctx, "broken"); err == nil || errors.Is(err, models.ErrPostNotFound) {
+		t.Errorf("GetPost(broken) error = %v, want server error", err)
+	}
+}
+
+// noParticipations 用户均未参与过的参与记录存储
+type noParticipations struct{}
+
+func (noParticipations) CountParticipations(ctx context.Context, activityID int64, gameName, uid string) (int64, error) {
+	return 0, nil
+}
+
+func TestCommunityPostGameVerifiesPost(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0)
+	end := start.Add(7 * 24 * time.Hour)
+
+	client := NewFakeClient(
+		models.Post{ID: "ok", AuthorID: "u1", Topic: "spring", Content: "春天来了，出去走走", CreatedAt: start.Add(time.Hour)},
+		models.Post{ID: "other", AuthorID: "u2", Topic: "spring", Content: "春天来了，出去走走", CreatedAt: start.Add(time.Hour)},
+		models.Post{ID: "early", AuthorID: "u1", Topic: "spring", Content: "春天来了，出去走走", CreatedAt: start.Add(-time.Hour)},
+		models.Post{ID: "late", AuthorID: "u1", Topic: "spring", Content: "春天来了，出去走走", CreatedAt: end.Add(time.Hour)},
+		models.Post{ID: "topic", AuthorID: "u1", Topic: "summer", Content: "春天来了，出去走走", CreatedAt: start.Add(time.Hour)},
+		models.Post{ID: "short", AuthorID: "u1", Topic: "spring", Content: "春天", CreatedAt: start.Add(time.Hour)},
+	)
+	game := &models.CommunityPostGame{Name_: "post", State: models.GameStateOPEN, Topic: "spring", MinLength: 5}
+	game.BindRuntime(&models.Runtime{
+		ActivityID:     1,
+		StartAt:        start.Unix(),
+		EndAt:          end.Unix(),
+		Participations: noParticipations{},
+		Community:      client,
+	})
+
+	user := models.User{Uid: "u1"}
+	if _, err := game.Perform(ctx, user, &models.CommunityPostAction{PostID: "ok"}); err != nil {
+		t.Fatalf("Perform(ok) error = %v", err)
+	}
+	for _, postID := range []string{"", "missing", "other", "early", "late", "topic", "short"} {
+		_, err := game.Perform(ctx, user, &models.CommunityPostAction{PostID: postID})
+		if !errors.Is(err, models.ErrUserNotPosted) {
+			t.Errorf("Perform(%q) error = %v, want ErrUserNotPosted", postID, err)
+		}
+	}
+
+	// 未配置社区服务时不能跳过验证
+	game.BindRuntime(&models.Runtime{Participations: noParticipations{}})
+	if _, err := game.Perform(ctx, user, &models.CommunityPostAction{PostID: "ok"}); !errors.Is(err, models.ErrRuntimeNotConfigured) {
+		t.Errorf("Perform without client error = %v, want ErrRuntimeNotConfigured", err)
+	}
+}
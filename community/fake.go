@@ -0,0 +1,40 @@
+package community
+
+import (
+	"Activity/models"
+	"context"
+	"sync"
+)
+
+// FakeClient 内存中的社区服务，用于测试和本地开发
+type FakeClient struct {
+	mu    sync.RWMutex
+	posts map[string]models.Post
+}
+
+// NewFakeClient 创建包含指定帖子的内存社区服务
+func NewFakeClient(posts ...models.Post) *FakeClient {
+	c := &FakeClient{posts: make(map[string]models.Post)}
+	for _, post := range posts {
+		c.AddPost(post)
+	}
+	return c
+}
+
+// AddPost 添加或替换帖子
+func (c *FakeClient) AddPost(post models.Post) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.posts[post.ID] = post
+}
+
+// GetPost 查询帖子，帖子不存在时返回 models.ErrPostNotFound
+func (c *FakeClient) GetPost(ctx context.Context, postID string) (*models.Post, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	post, ok := c.posts[postID]
+	if !ok {
+		return nil, models.ErrPostNotFound
+	}
+	return &post, nil
+}
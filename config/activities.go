@@ -0,0 +1,13 @@
+package config
+
+import _ "embed"
+
+// 示例活动配置，内容为config目录下对应的JSON文件，用于本地调试和测试
+var (
+	//go:embed checkin_activity.json
+	CheckinActivityExample string
+	//go:embed community_activity.json
+	CommunityActivityExample string
+	//go:embed lottery_activity.json
+	LotteryActivityExample string
+)
@@ -8,11 +8,25 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
+const (
+	// EnvAuthSecret 令牌签名密钥的环境变量，设置后覆盖配置文件中的auth.secret
+	EnvAuthSecret = "ACTIVITY_AUTH_SECRET"
+	// placeholderSecret 示例配置中的占位密钥，不能用于启动服务
+	placeholderSecret = "change-me-in-production"
+)
+
 // Config 应用配置
 type Config struct {
-	MySQL MySQLConfig `yaml:"mysql"`
-	API   APIConfig   `yaml:"api"`
-	Log   LogConfig   `yaml:"log"`
+	MySQL     MySQLConfig     `yaml:"mysql"`
+	API       APIConfig       `yaml:"api"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+	Cache     CacheConfig     `yaml:"cache"`
+	Redis     RedisConfig     `yaml:"redis"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Risk      RiskConfig      `yaml:"risk"`
+	Community CommunityConfig `yaml:"community"`
+	Log       LogConfig       `yaml:"log"`
 }
 
 // MySQLConfig MySQL配置
@@ -33,6 +47,71 @@ type APIConfig struct {
 	Mode         string        `yaml:"mode"`
 	ReadTimeout  time.Duration `yaml:"read_timeout"`
 	WriteTimeout time.Duration `yaml:"write_timeout"`
+	// TrustedProxies 可信代理的IP或网段，只有来自这些地址的请求才按X-Forwarded-For取客户端IP，为空时使用连接的对端地址
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// AuthConfig 认证配置
+type AuthConfig struct {
+	Secret string `yaml:"secret"` // HMAC签名密钥，建议通过环境变量ACTIVITY_AUTH_SECRET注入
+	Issuer string `yaml:"issuer"` // 令牌签发方，为空时不校验
+}
+
+// SchedulerConfig 活动调度器配置
+type SchedulerConfig struct {
+	Enabled    bool          `yaml:"enabled"`      // 是否启动调度器
+	Interval   time.Duration `yaml:"interval"`     // 轮询间隔，默认10秒
+	WarmUpLead time.Duration `yaml:"warm_up_lead"` // 活动开始前多久触发预热，默认10分钟
+}
+
+// CacheConfig 进程内缓存配置
+type CacheConfig struct {
+	ActivityTTL time.Duration `yaml:"activity_ttl"` // 活动缓存有效期，其他实例的修改最多延迟该时间生效，默认5秒
+}
+
+// RedisConfig Redis库存配置，启用后奖品库存扣减和参与去重在Redis中完成，记录由调度器异步落库
+type RedisConfig struct {
+	Enabled           bool          `yaml:"enabled"`            // 是否启用Redis库存，需要同时启用调度器
+	Addr              string        `yaml:"addr"`               // 地址，如 localhost:6379
+	Password          string        `yaml:"password"`           // 密码
+	DB                int           `yaml:"db"`                 // 数据库编号
+	ReconcileInterval time.Duration `yaml:"reconcile_interval"` // 按MySQL对账的间隔，默认1分钟
+}
+
+// RateLimitConfig 参与接口的限流配置，活动配置了rate_limit时以活动为准
+type RateLimitConfig struct {
+	Enabled  bool          `yaml:"enabled"`  // 是否启用限流
+	Backend  string        `yaml:"backend"`  // 令牌桶存储，memory为每个实例单独计数，redis为多个实例共用，默认memory
+	User     RateLimitRule `yaml:"user"`     // 每个用户在活动内的默认规则
+	IP       RateLimitRule `yaml:"ip"`       // 每个IP在活动内的默认规则
+	Activity RateLimitRule `yaml:"activity"` // 整个活动的默认规则
+}
+
+// RateLimitRule 令牌桶规则，rate为0时不限流
+type RateLimitRule struct {
+	Rate  float64 `yaml:"rate"`  // 每秒补充的令牌数
+	Burst int64   `yaml:"burst"` // 令牌桶容量
+}
+
+// RiskConfig 参与玩法前的风控配置，数值为0的规则不启用
+type RiskConfig struct {
+	Enabled            bool          `yaml:"enabled"`              // 是否启用风控
+	BlockedUsers       []string      `yaml:"blocked_users"`        // 用户黑名单
+	BlockedDevices     []string      `yaml:"blocked_devices"`      // 设备黑名单
+	BlockedIPs         []string      `yaml:"blocked_ips"`          // IP黑名单
+	DeviceMaxUsers     int64         `yaml:"device_max_users"`     // 统计窗口内每个设备允许参与的账号数
+	DeviceUsersWindow  time.Duration `yaml:"device_users_window"`  // 设备账号数的统计窗口，默认24小时
+	DeviceMaxWins      int64         `yaml:"device_max_wins"`      // 统计窗口内每个设备允许的中奖次数
+	DeviceWinsWindow   time.Duration `yaml:"device_wins_window"`   // 设备中奖次数的统计窗口，默认24小时
+	NewAccountCooldown time.Duration `yaml:"new_account_cooldown"` // 注册时间不足该时长的账号需要完成验证
+	MissingSignal      string        `yaml:"missing_signal"`       // 缺少设备指纹或注册时间时的决策：allow/deny/challenge，默认challenge
+}
+
+// CommunityConfig 社区服务配置，发帖玩法通过社区服务验证帖子
+type CommunityConfig struct {
+	BaseURL string        `yaml:"base_url"` // 接口地址，为空时发帖玩法无法参与
+	Token   string        `yaml:"token"`    // 访问令牌
+	Timeout time.Duration `yaml:"timeout"`  // 请求超时，默认3秒
 }
 
 // LogConfig 日志配置
@@ -57,7 +136,52 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	// 密钥优先取环境变量，避免写入配置文件；为空或仍是示例中的占位值时拒绝启动
+	if secret := os.Getenv(EnvAuthSecret); secret != "" {
+		config.Auth.Secret = secret
+	}
+	if config.Auth.Secret == "" {
+		return nil, fmt.Errorf("auth secret is required, set %s", EnvAuthSecret)
+	}
+	if config.Auth.Secret == placeholderSecret {
+		return nil, fmt.Errorf("auth secret must not be the placeholder value, set %s", EnvAuthSecret)
+	}
+
+	// Redis中的记录依靠调度器落库，未启用调度器时记录不会写入MySQL
+	if config.Redis.Enabled && !config.Scheduler.Enabled {
+		return nil, fmt.Errorf("redis requires scheduler to be enabled")
+	}
+
+	if config.RateLimit.Backend == "" {
+		config.RateLimit.Backend = "memory"
+	}
+	if config.RateLimit.Backend != "memory" && config.RateLimit.Backend != "redis" {
+		return nil, fmt.Errorf("unknown rate limit backend: %s", config.RateLimit.Backend)
+	}
+	for name, rule := range map[string]RateLimitRule{"user": config.RateLimit.User, "ip": config.RateLimit.IP, "activity": config.RateLimit.Activity} {
+		if rule.Rate < 0 || (rule.Rate > 0 && rule.Burst < 1) {
+			return nil, fmt.Errorf("invalid rate limit rule for %s: rate=%v burst=%d", name, rule.Rate, rule.Burst)
+		}
+	}
+
 	// 设置默认值
+	if config.Community.Timeout == 0 {
+		config.Community.Timeout = 3 * time.Second
+	}
+	if config.Risk.DeviceUsersWindow == 0 {
+		config.Risk.DeviceUsersWindow = 24 * time.Hour
+	}
+	if config.Risk.MissingSignal == "" {
+		config.Risk.MissingSignal = "challenge"
+	}
+	switch config.Risk.MissingSignal {
+	case "allow", "deny", "challenge":
+	default:
+		return nil, fmt.Errorf("unknown risk missing_signal: %s", config.Risk.MissingSignal)
+	}
+	if config.Risk.DeviceWinsWindow == 0 {
+		config.Risk.DeviceWinsWindow = 24 * time.Hour
+	}
 	if config.MySQL.MaxIdleConns == 0 {
 		config.MySQL.MaxIdleConns = 10
 	}
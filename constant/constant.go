@@ -32,6 +32,42 @@ const (
 	ErrUserNotPosted = 10009
 	// 用户未签到
 	ErrUserNotCheckedIn = 10010
+	// 折扣码无效
+	ErrDiscountCodeInvalid = 10011
+	// 未登录或身份无效
+	ErrUnauthorized = 10012
+	// 无权限
+	ErrForbidden = 10013
+	// 活动报名人数已满
+	ErrActivityFull = 10014
+	// 不满足活动报名条件
+	ErrNotEligible = 10015
+	// 未报名活动
+	ErrNotEnrolled = 10016
+	// 缺少If-Match前置条件
+	ErrPreconditionRequired = 10017
+	// 活动已被修改
+	ErrActivityModified = 10018
+	// 已上线活动不允许的修改
+	ErrOnlineChangeForbidden = 10019
+	// 活动未发布
+	ErrActivityNotPublished = 10020
+	// 活动已暂停
+	ErrActivityPaused = 10021
+	// 活动状态不允许该操作
+	ErrInvalidTransition = 10022
+	// 活动不满足状态变更条件
+	ErrTransitionGuardFailed = 10023
+	// 相同幂等键的请求正在处理
+	ErrRequestInProgress = 10024
+	// 幂等键已用于其他请求
+	ErrIdempotencyKeyReused = 10025
+	// 请求过于频繁
+	ErrTooManyRequests = 10026
+	// 风控拒绝参与
+	ErrRiskDenied = 10027
+	// 风控要求完成验证
+	ErrRiskChallenge = 10028
 )
 
 // 错误消息
@@ -47,4 +83,22 @@ const (
 	ErrMsgPrizeStockEmpty         = "奖品库存不足"
 	ErrMsgUserNotPosted           = "用户未发帖"
 	ErrMsgUserNotCheckedIn        = "用户未签到"
+	ErrMsgDiscountCodeInvalid     = "折扣码无效或已使用"
+	ErrMsgUnauthorized            = "未登录或登录已过期"
+	ErrMsgForbidden               = "无权限执行该操作"
+	ErrMsgActivityFull            = "活动报名人数已满"
+	ErrMsgNotEligible             = "不满足活动报名条件"
+	ErrMsgNotEnrolled             = "请先报名参加活动"
+	ErrMsgPreconditionRequired    = "请携带If-Match请求头"
+	ErrMsgActivityModified        = "活动已被他人修改，请刷新后重试"
+	ErrMsgOnlineChangeForbidden   = "活动已上线，仅允许延长结束时间或增加库存"
+	ErrMsgActivityNotPublished    = "活动未发布"
+	ErrMsgActivityPaused          = "活动已暂停"
+	ErrMsgInvalidTransition       = "当前活动状态不允许该操作"
+	ErrMsgTransitionGuardFailed   = "活动不满足状态变更条件"
+	ErrMsgRequestInProgress       = "请求正在处理中，请稍后重试"
+	ErrMsgIdempotencyKeyReused    = "幂等键已用于其他请求"
+	ErrMsgTooManyRequests         = "请求过于频繁，请稍后再试"
+	ErrMsgRiskDenied              = "当前账号或设备存在风险，无法参与"
+	ErrMsgRiskChallenge           = "请完成安全验证后再参与"
 )
@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/activity": {
-            "post": {
-                "description": "创建一个新的活动",
+        "/activity/{id}": {
+            "get": {
+                "description": "获取指定ID的活动详细信息",
                 "consumes": [
                     "application/json"
                 ],
@@ -27,16 +27,14 @@ const docTemplate = `{
                 "tags": [
                     "活动管理"
                 ],
-                "summary": "创建活动",
+                "summary": "获取活动信息",
                 "parameters": [
                     {
-                        "description": "活动信息",
-                        "name": "activity",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.CreateActivityRequest"
-                        }
+                        "type": "string",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -51,11 +49,17 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.CreateActivityResponse"
+                                            "$ref": "#/definitions/api.ActivityResponse"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "活动的ETag，更新活动时作为If-Match传入"
+                            }
                         }
                     },
                     "400": {
@@ -64,6 +68,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -73,9 +83,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/activity/{id}": {
-            "get": {
-                "description": "获取指定ID的活动详细信息",
+        "/activity/{id}/participate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "报名参加指定活动，重复报名返回已有记录；返回活动下的玩法及当前用户是否可参与",
                 "consumes": [
                     "application/json"
                 ],
@@ -85,7 +100,7 @@ const docTemplate = `{
                 "tags": [
                     "活动管理"
                 ],
-                "summary": "获取活动信息",
+                "summary": "参与活动",
                 "parameters": [
                     {
                         "type": "string",
@@ -107,7 +122,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.GetActivityResponse"
+                                            "$ref": "#/definitions/api.ParticipateResponse"
                                         }
                                     }
                                 }
@@ -120,6 +135,36 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -127,9 +172,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "更新指定ID的活动信息",
+            }
+        },
+        "/activity/{id}/participation": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "分页获取用户在指定活动中的参与记录，按参与时间倒序",
                 "consumes": [
                     "application/json"
                 ],
@@ -139,7 +191,7 @@ const docTemplate = `{
                 "tags": [
                     "活动管理"
                 ],
-                "summary": "更新活动",
+                "summary": "获取参与记录",
                 "parameters": [
                     {
                         "type": "string",
@@ -149,13 +201,16 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "活动信息",
-                        "name": "activity",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.UpdateActivityRequest"
-                        }
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -170,7 +225,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.UpdateActivityResponse"
+                                            "$ref": "#/definitions/api.GetParticipationResponse"
                                         }
                                     }
                                 }
@@ -183,6 +238,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -192,9 +253,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/activity/{id}/participate": {
+        "/admin/activity": {
             "post": {
-                "description": "用户参与指定活动",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "创建一个新的活动，新建的活动为草稿状态，通过状态变更接口提交审核和上线",
                 "consumes": [
                     "application/json"
                 ],
@@ -204,22 +270,15 @@ const docTemplate = `{
                 "tags": [
                     "活动管理"
                 ],
-                "summary": "参与活动",
+                "summary": "创建活动",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "活动ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "参与信息",
-                        "name": "participation",
+                        "description": "活动信息",
+                        "name": "activity",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.ParticipateRequest"
+                            "$ref": "#/definitions/api.CreateActivityRequest"
                         }
                     }
                 ],
@@ -235,7 +294,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.ParticipateResponse"
+                                            "$ref": "#/definitions/api.CreateActivityResponse"
                                         }
                                     }
                                 }
@@ -248,6 +307,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -257,9 +328,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/activity/{id}/participation": {
+        "/admin/activity/{id}": {
             "get": {
-                "description": "获取用户在指定活动中的参与记录",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "后台查看指定ID的活动详细信息，需要activity:read权限",
                 "consumes": [
                     "application/json"
                 ],
@@ -267,9 +343,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "活动管理"
+                    "后台管理"
                 ],
-                "summary": "获取参与记录",
+                "summary": "后台获取活动信息",
                 "parameters": [
                     {
                         "type": "string",
@@ -277,13 +353,6 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "用户ID",
-                        "name": "user_id",
-                        "in": "query",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -298,11 +367,17 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.GetParticipationResponse"
+                                            "$ref": "#/definitions/api.ActivityResponse"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "活动的ETag，更新活动时作为If-Match传入"
+                            }
                         }
                     },
                     "400": {
@@ -311,6 +386,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -318,11 +405,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/game/participate": {
-            "post": {
-                "description": "用户参与指定玩法",
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "部分更新指定ID的活动，只修改传入的字段；需携带If-Match（取自获取活动时的ETag）防止覆盖他人的修改\n已发布的活动只允许延长结束时间和增加奖品库存，状态通过状态变更接口修改",
                 "consumes": [
                     "application/json"
                 ],
@@ -330,17 +420,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "玩法管理"
+                    "活动管理"
                 ],
-                "summary": "参与玩法",
+                "summary": "更新活动",
                 "parameters": [
                     {
-                        "description": "参与信息",
-                        "name": "participation",
+                        "type": "string",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "活动的ETag，传*表示不校验修订号",
+                        "name": "If-Match",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "活动信息",
+                        "name": "activity",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.ParticipateGameReq"
+                            "$ref": "#/definitions/api.UpdateActivityRequest"
                         }
                     }
                 ],
@@ -356,11 +460,17 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.ParticipateGameResponse"
+                                            "$ref": "#/definitions/api.ActivityResponse"
                                         }
                                     }
                                 }
                             ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "更新后活动的ETag"
+                            }
                         }
                     },
                     "400": {
@@ -369,6 +479,42 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "428": {
+                        "description": "Precondition Required",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -378,9 +524,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/game/prize": {
-            "get": {
-                "description": "获取用户在指定玩法中获得的奖品",
+        "/admin/activity/{id}/discount-codes/generate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按奖品配置的折扣码前缀生成随机折扣码到码池",
                 "consumes": [
                     "application/json"
                 ],
@@ -388,23 +539,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "玩法管理"
+                    "奖品管理"
                 ],
-                "summary": "获取用户奖品",
+                "summary": "生成折扣码",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "活动ID",
-                        "name": "activity_id",
-                        "in": "query",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "玩法名称",
-                        "name": "game_name",
-                        "in": "query",
-                        "required": true
+                        "description": "生成参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.GenerateDiscountCodesRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -419,7 +572,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.GetUserPrizeResponse"
+                                            "$ref": "#/definitions/api.DiscountCodePoolResponse"
                                         }
                                     }
                                 }
@@ -432,6 +585,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -441,32 +606,50 @@ const docTemplate = `{
                 }
             }
         },
-        "/game/status": {
-            "get": {
-                "description": "获取用户在指定玩法中的状态",
+        "/admin/activity/{id}/discount-codes/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "从CSV文件导入折扣码到奖品的码池，每行第一列为折扣码",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "玩法管理"
+                    "奖品管理"
                 ],
-                "summary": "获取玩法状态",
+                "summary": "导入折扣码",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "活动ID",
-                        "name": "activity_id",
-                        "in": "query",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "玩法名称",
-                        "name": "game_name",
-                        "in": "query",
+                        "description": "奖品库存标识",
+                        "name": "prize_key",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "过期时间戳",
+                        "name": "expires_at",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "折扣码CSV文件",
+                        "name": "file",
+                        "in": "formData",
                         "required": true
                     }
                 ],
@@ -482,7 +665,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/api.GetGameStatusResponse"
+                                            "$ref": "#/definitions/api.DiscountCodePoolResponse"
                                         }
                                     }
                                 }
@@ -495,6 +678,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.BaseResp"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -503,284 +698,1469 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.BaseResp": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "description": "错误码",
-                    "type": "integer"
-                },
-                "data": {
-                    "description": "响应数据"
-                },
-                "message": {
+        },
+        "/admin/activity/{id}/transitions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按时间顺序返回活动的状态变更记录",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "活动管理"
+                ],
+                "summary": "查询活动状态变更记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.BaseResp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/api.ListStatusHistoryResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按操作推进活动生命周期：草稿 → 审核中 → 已排期 → 进行中 ⇄ 已暂停 → 已结束 → 已归档\n当前状态不允许该操作返回409，不满足前置条件（配置有效、折扣码已导入、活动时间）返回422；暂停后立即停止参与",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "活动管理"
+                ],
+                "summary": "变更活动状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "活动ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "状态变更操作",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.TransitionActivityRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.BaseResp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/api.ActivityResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "变更后活动的ETag"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/audit-logs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "分页查询后台操作日志，按时间倒序，需要audit:read权限",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "后台管理"
+                ],
+                "summary": "查询后台操作日志",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "操作人用户ID",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "页码，默认1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "每页数量，默认20，最大100",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.BaseResp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/api.ListAuditLogsResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/discount-code/redeem": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "核销用户已获得的折扣码",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "奖品管理"
+                ],
+                "summary": "核销折扣码",
+                "parameters": [
+                    {
+                        "description": "核销参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.RedeemDiscountCodeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.BaseResp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/api.RedeemDiscountCodeResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/game/participate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "用户参与指定玩法；携带Idempotency-Key请求头或request_id时，相同用户和幂等键的重试直接返回首次成功的响应，不会再次执行玩法；执行玩法前进行风控检查，被拒绝或需要验证时返回403；响应中result的结构由game_type决定，见与玩法类型同名的定义",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "玩法管理"
+                ],
+                "summary": "参与玩法",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "幂等键，最长64个字符",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "设备指纹，用于风控",
+                        "name": "X-Device-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "参与信息",
+                        "name": "participation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ParticipateGameReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.BaseResp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/api.ParticipateGameResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/game/prize": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取用户在指定玩法中获得的奖品",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "玩法管理"
+                ],
+                "summary": "获取用户奖品",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "活动ID",
+                        "name": "activity_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "玩法名称",
+                        "name": "game_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.BaseResp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/api.GetUserPrizeResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    }
+                }
+            }
+        },
+        "/game/status": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取玩法状态以及当前用户在该玩法中的参与状态和参与次数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "玩法管理"
+                ],
+                "summary": "获取玩法状态",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "活动ID",
+                        "name": "activity_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "玩法名称",
+                        "name": "game_name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/api.BaseResp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/api.GameStatusResp"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/api.BaseResp"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.ActivityResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "string"
+                },
+                "config": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "end_at": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revision": {
+                    "description": "修订号，同时通过ETag响应头返回",
+                    "type": "integer"
+                },
+                "start_at": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "活动状态：0-草稿，1-进行中，2-审核中，3-已排期，4-已暂停，5-已结束，6-已归档",
+                    "type": "integer"
+                },
+                "status_name": {
+                    "description": "活动状态名称",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "description": "操作人用户ID",
+                    "type": "string"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "method": {
+                    "description": "HTTP方法",
+                    "type": "string"
+                },
+                "path": {
+                    "description": "路由模板",
+                    "type": "string"
+                },
+                "resource_id": {
+                    "description": "操作的资源ID",
+                    "type": "string"
+                },
+                "roles": {
+                    "description": "操作人角色，逗号分隔",
+                    "type": "string"
+                },
+                "status_code": {
+                    "description": "HTTP响应状态码",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.BaseResp": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "错误码",
+                    "type": "integer"
+                },
+                "data": {
+                    "description": "响应数据"
+                },
+                "message": {
                     "description": "错误信息",
                     "type": "string"
                 }
             }
         },
-        "api.CreateActivityRequest": {
-            "description": "创建活动请求参数",
+        "api.CreateActivityRequest": {
+            "description": "创建活动请求参数",
+            "type": "object",
+            "required": [
+                "category",
+                "end_at",
+                "games",
+                "name",
+                "start_at",
+                "version"
+            ],
+            "properties": {
+                "category": {
+                    "description": "@Description 活动类型",
+                    "type": "string"
+                },
+                "end_at": {
+                    "description": "@Description 活动结束时间",
+                    "type": "integer"
+                },
+                "enrollment": {
+                    "description": "@Description 报名配置，为空表示无需报名即可参与玩法",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EnrollmentConfig"
+                        }
+                    ]
+                },
+                "games": {
+                    "description": "@Description 玩法配置列表，结构同活动配置中的games",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GameConfig"
+                    }
+                },
+                "name": {
+                    "description": "@Description 活动名称",
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "description": "@Description 参与接口的限流配置，为空时使用默认规则",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RateLimitConfig"
+                        }
+                    ]
+                },
+                "start_at": {
+                    "description": "@Description 活动开始时间",
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "@Description 活动版本",
+                    "type": "string"
+                }
+            }
+        },
+        "api.CreateActivityResponse": {
+            "description": "创建活动响应数据",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "@Description 活动ID",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.DiscountCodePoolResponse": {
+            "description": "折扣码导入或生成结果",
+            "type": "object",
+            "properties": {
+                "available": {
+                    "description": "@Description 码池中当前可分配的数量",
+                    "type": "integer"
+                },
+                "imported": {
+                    "description": "@Description 本次写入码池的数量",
+                    "type": "integer"
+                },
+                "skipped": {
+                    "description": "@Description 因重复被跳过的数量",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.GameStatusResp": {
+            "type": "object",
+            "properties": {
+                "end_time": {
+                    "description": "结束时间",
+                    "type": "string"
+                },
+                "game_state": {
+                    "description": "玩法状态",
+                    "type": "string"
+                },
+                "participation_count": {
+                    "description": "当前用户已参与次数",
+                    "type": "integer"
+                },
+                "remain_num": {
+                    "description": "剩余奖品数量",
+                    "type": "integer"
+                },
+                "start_time": {
+                    "description": "开始时间",
+                    "type": "string"
+                },
+                "total_num": {
+                    "description": "总奖品数量",
+                    "type": "integer"
+                },
+                "user_state": {
+                    "description": "当前用户状态",
+                    "type": "string"
+                }
+            }
+        },
+        "api.GenerateDiscountCodesRequest": {
+            "description": "按奖品配置的前缀生成折扣码并放入码池",
+            "type": "object",
+            "required": [
+                "count",
+                "prize_key"
+            ],
+            "properties": {
+                "count": {
+                    "description": "@Description 生成数量",
+                    "type": "integer",
+                    "maximum": 100000,
+                    "minimum": 1
+                },
+                "expires_at": {
+                    "description": "@Description 过期时间戳，为0表示不过期",
+                    "type": "integer"
+                },
+                "prize_key": {
+                    "description": "@Description 奖品库存标识，单奖品玩法为玩法名称",
+                    "type": "string"
+                }
+            }
+        },
+        "api.GetParticipationResponse": {
+            "description": "获取参与记录响应数据",
+            "type": "object",
+            "properties": {
+                "list": {
+                    "description": "@Description 参与记录，按参与时间倒序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.ParticipationResponse"
+                    }
+                },
+                "page": {
+                    "description": "@Description 页码",
+                    "type": "integer"
+                },
+                "page_size": {
+                    "description": "@Description 每页数量",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "@Description 记录总数",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.GetUserPrizeResponse": {
+            "description": "获取用户奖品响应数据",
+            "type": "object",
+            "properties": {
+                "prizes": {
+                    "description": "@Description 奖品列表，按获得时间倒序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.UserPrizeResp"
+                    }
+                }
+            }
+        },
+        "api.ListAuditLogsResponse": {
+            "description": "查询后台操作日志响应数据",
+            "type": "object",
+            "properties": {
+                "list": {
+                    "description": "@Description 操作日志，按时间倒序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.AuditLogResponse"
+                    }
+                },
+                "page": {
+                    "description": "@Description 页码",
+                    "type": "integer"
+                },
+                "page_size": {
+                    "description": "@Description 每页数量",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "@Description 记录总数",
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ListStatusHistoryResponse": {
+            "description": "查询活动状态变更记录响应数据",
+            "type": "object",
+            "properties": {
+                "list": {
+                    "description": "@Description 状态变更记录，按时间顺序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.StatusHistoryResponse"
+                    }
+                }
+            }
+        },
+        "api.ParticipateGameReq": {
+            "description": "参与玩法请求参数",
+            "type": "object",
+            "required": [
+                "activity_id",
+                "game_name"
+            ],
+            "properties": {
+                "action": {
+                    "description": "@Description 玩法动作，结构由玩法类型决定，如发帖玩法为 {\"post_id\": \"...\"}，签到和抽奖可不传",
+                    "type": "object"
+                },
+                "activity_id": {
+                    "description": "@Description 活动ID",
+                    "type": "string"
+                },
+                "game_name": {
+                    "description": "@Description 玩法名称，活动内唯一，不是玩法类型",
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "@Description 客户端请求ID，作为幂等键使用，Idempotency-Key请求头优先",
+                    "type": "string",
+                    "maxLength": 64
+                }
+            }
+        },
+        "api.ParticipateGameResponse": {
+            "description": "参与玩法响应数据，按game_type区分result的结构",
+            "type": "object",
+            "required": [
+                "game_type"
+            ],
+            "properties": {
+                "game_name": {
+                    "description": "@Description 玩法名称",
+                    "type": "string"
+                },
+                "game_type": {
+                    "description": "@Description 玩法类型，决定result的结构",
+                    "type": "string"
+                },
+                "prize": {
+                    "description": "@Description 获得的奖品，未获得奖品时为空",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.PrizeInfo"
+                        }
+                    ]
+                },
+                "result": {
+                    "description": "@Description 玩法结果，结构由玩法的Results声明",
+                    "type": "object"
+                },
+                "won": {
+                    "description": "@Description 是否获得奖品",
+                    "type": "boolean"
+                }
+            },
+            "discriminator": "game_type"
+        },
+        "api.ParticipateResponse": {
+            "description": "参与活动响应数据",
+            "type": "object",
+            "properties": {
+                "activity_id": {
+                    "description": "@Description 活动ID",
+                    "type": "integer"
+                },
+                "already_enrolled": {
+                    "description": "@Description 是否此前已经报名，重复报名不会占用名额",
+                    "type": "boolean"
+                },
+                "enrolled_at": {
+                    "description": "@Description 报名时间",
+                    "type": "string"
+                },
+                "enrollment_id": {
+                    "description": "@Description 报名记录ID",
+                    "type": "integer"
+                },
+                "games": {
+                    "description": "@Description 活动下的玩法及当前用户可参与的状态",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.PlayableGameResponse"
+                    }
+                }
+            }
+        },
+        "api.ParticipationResponse": {
+            "type": "object",
+            "properties": {
+                "activity_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "extra": {
+                    "description": "玩法返回的结果",
+                    "type": "object"
+                },
+                "game_target": {
+                    "type": "string"
+                },
+                "game_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PlayableGameResponse": {
+            "description": "玩法状态及当前用户是否可参与",
+            "type": "object",
+            "properties": {
+                "game_state": {
+                    "description": "@Description 玩法状态",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "@Description 玩法名称",
+                    "type": "string"
+                },
+                "playable": {
+                    "description": "@Description 当前用户是否可以参与",
+                    "type": "boolean"
+                },
+                "type": {
+                    "description": "@Description 玩法类型",
+                    "type": "string"
+                },
+                "user_state": {
+                    "description": "@Description 当前用户状态",
+                    "type": "string"
+                }
+            }
+        },
+        "api.PrizeInfo": {
+            "type": "object",
+            "properties": {
+                "discount_code": {
+                    "description": "折扣码（折扣码类型）",
+                    "type": "string"
+                },
+                "price_rule_id": {
+                    "description": "价格规则ID（折扣码类型）",
+                    "type": "integer"
+                },
+                "sku": {
+                    "description": "商品SKU（商品类型）",
+                    "type": "string"
+                },
+                "title": {
+                    "description": "商品标题（商品类型）",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "奖品类型",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RedeemDiscountCodeRequest": {
+            "description": "核销折扣码请求参数",
             "type": "object",
             "required": [
-                "category",
-                "end_at",
-                "name",
-                "start_at",
-                "status",
-                "version"
+                "code"
             ],
             "properties": {
-                "category": {
-                    "description": "@Description 活动类型",
+                "code": {
+                    "description": "@Description 折扣码",
+                    "type": "string"
+                }
+            }
+        },
+        "api.RedeemDiscountCodeResponse": {
+            "description": "核销折扣码响应数据",
+            "type": "object",
+            "properties": {
+                "success": {
+                    "description": "@Description 是否核销成功",
+                    "type": "boolean"
+                }
+            }
+        },
+        "api.StatusHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "end_at": {
-                    "description": "@Description 活动结束时间",
-                    "type": "integer"
+                "event": {
+                    "description": "触发变更的操作",
+                    "type": "string"
                 },
-                "name": {
-                    "description": "@Description 活动名称",
+                "from_status": {
+                    "description": "变更前状态",
                     "type": "string"
                 },
-                "start_at": {
-                    "description": "@Description 活动开始时间",
+                "id": {
                     "type": "integer"
                 },
-                "status": {
-                    "description": "@Description 活动状态",
-                    "type": "integer"
+                "operator": {
+                    "description": "操作人用户ID，系统触发时为空",
+                    "type": "string"
                 },
-                "version": {
-                    "description": "@Description 活动版本",
+                "reason": {
+                    "description": "变更原因",
+                    "type": "string"
+                },
+                "to_status": {
+                    "description": "变更后状态",
                     "type": "string"
                 }
             }
         },
-        "api.CreateActivityResponse": {
-            "description": "创建活动响应数据",
+        "api.TransitionActivityRequest": {
+            "description": "变更活动状态请求参数",
             "type": "object",
+            "required": [
+                "event"
+            ],
             "properties": {
-                "id": {
-                    "description": "@Description 活动ID",
-                    "type": "integer"
+                "event": {
+                    "description": "@Description 操作：submit-提交审核，reject-驳回，approve-审核通过，publish-上线，pause-暂停，resume-恢复，end-结束，archive-归档",
+                    "type": "string",
+                    "enum": [
+                        "submit",
+                        "reject",
+                        "approve",
+                        "publish",
+                        "pause",
+                        "resume",
+                        "end",
+                        "archive"
+                    ]
+                },
+                "reason": {
+                    "description": "@Description 变更原因",
+                    "type": "string",
+                    "maxLength": 255
                 }
             }
         },
-        "api.GetActivityResponse": {
-            "description": "获取活动响应数据",
+        "api.UpdateActivityRequest": {
+            "description": "更新活动请求参数，未传入的字段保持不变；已发布的活动只允许延长结束时间和增加奖品库存，状态通过状态变更接口修改",
             "type": "object",
             "properties": {
-                "category": {
-                    "description": "@Description 活动类型",
-                    "type": "string"
-                },
                 "end_at": {
                     "description": "@Description 活动结束时间",
                     "type": "integer"
                 },
-                "id": {
-                    "description": "@Description 活动ID",
-                    "type": "integer"
+                "enrollment": {
+                    "description": "@Description 报名配置，为空时保留原配置",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.EnrollmentConfig"
+                        }
+                    ]
+                },
+                "games": {
+                    "description": "@Description 玩法配置列表，为空时保留原配置",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GameConfig"
+                    }
                 },
                 "name": {
                     "description": "@Description 活动名称",
                     "type": "string"
                 },
+                "rate_limit": {
+                    "description": "@Description 参与接口的限流配置，为空时保留原配置",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RateLimitConfig"
+                        }
+                    ]
+                },
                 "start_at": {
                     "description": "@Description 活动开始时间",
                     "type": "integer"
-                },
-                "status": {
-                    "description": "@Description 活动状态",
-                    "type": "integer"
-                },
-                "version": {
-                    "description": "@Description 活动版本",
-                    "type": "string"
                 }
             }
         },
-        "api.GetGameStatusResponse": {
-            "description": "获取玩法状态响应数据",
+        "api.UserPrizeResp": {
             "type": "object",
             "properties": {
-                "participation_count": {
-                    "description": "@Description 参与次数",
-                    "type": "integer"
-                },
-                "status": {
-                    "description": "@Description 玩法状态",
+                "created_at": {
+                    "description": "获得时间",
                     "type": "string"
+                },
+                "prize": {
+                    "description": "奖品信息",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/api.PrizeInfo"
+                        }
+                    ]
                 }
             }
         },
-        "api.GetParticipationResponse": {
-            "description": "获取参与记录响应数据",
+        "checkin": {
+            "description": "game_type为checkin时的参与玩法响应",
+            "allOf": [
+                {
+                    "$ref": "#/definitions/api.ParticipateGameResponse"
+                },
+                {
+                    "type": "object",
+                    "properties": {
+                        "result": {
+                            "$ref": "#/definitions/models.CheckinResult"
+                        }
+                    }
+                }
+            ]
+        },
+        "lottery": {
+            "description": "game_type为lottery时的参与玩法响应",
+            "allOf": [
+                {
+                    "$ref": "#/definitions/api.ParticipateGameResponse"
+                },
+                {
+                    "type": "object",
+                    "properties": {
+                        "result": {
+                            "$ref": "#/definitions/models.LotteryResult"
+                        }
+                    }
+                }
+            ]
+        },
+        "models.CheckinResult": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "description": "@Description 参与时间",
+                "checkin_date": {
+                    "type": "string"
+                },
+                "checkin_days": {
                     "type": "integer"
                 },
-                "participation_id": {
-                    "description": "@Description 参与ID",
+                "completed": {
+                    "type": "boolean"
+                },
+                "consecutive_days": {
                     "type": "integer"
                 },
-                "user_id": {
-                    "description": "@Description 用户ID",
+                "cumulative_days": {
+                    "type": "integer"
+                },
+                "game_name": {
                     "type": "string"
+                },
+                "grant": {
+                    "$ref": "#/definitions/models.PrizeGrant"
+                },
+                "prize": {
+                    "$ref": "#/definitions/models.DiscountCodePrize"
+                },
+                "required_days": {
+                    "type": "integer"
                 }
             }
         },
-        "api.GetUserPrizeResponse": {
-            "description": "获取用户奖品响应数据",
+        "models.CommunityPostResult": {
             "type": "object",
             "properties": {
-                "prizes": {
-                    "description": "@Description 奖品列表",
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/api.PrizeInfo"
-                    }
+                "game_name": {
+                    "type": "string"
+                },
+                "grant": {
+                    "$ref": "#/definitions/models.PrizeGrant"
+                },
+                "prize": {
+                    "$ref": "#/definitions/models.DiscountCodePrize"
                 }
             }
         },
-        "api.ParticipateGameReq": {
-            "description": "参与玩法请求参数",
+        "models.DiscountCodePrize": {
             "type": "object",
-            "required": [
-                "activity_id",
-                "game_name",
-                "user_id"
-            ],
             "properties": {
-                "activity_id": {
-                    "description": "@Description 活动ID",
+                "discount_code": {
                     "type": "string"
                 },
-                "game_name": {
-                    "description": "@Description 玩法名称",
-                    "type": "string"
+                "price_rule_id": {
+                    "type": "integer"
                 },
-                "user_id": {
-                    "description": "@Description 用户ID",
-                    "type": "string"
+                "probability": {
+                    "type": "integer"
+                },
+                "total_num": {
+                    "type": "integer"
                 }
             }
         },
-        "api.ParticipateGameResponse": {
-            "description": "参与玩法响应数据",
+        "models.EnrollmentConfig": {
             "type": "object",
             "properties": {
-                "has_prize": {
-                    "description": "@Description 是否获得奖品",
-                    "type": "boolean"
+                "allowed_users": {
+                    "description": "允许报名的用户ID，为空表示不限",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "prize": {
-                    "description": "@Description 奖品信息",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/api.PrizeInfo"
-                        }
-                    ]
+                "max_participants": {
+                    "description": "报名人数上限，0 表示不限",
+                    "type": "integer"
                 },
-                "success": {
-                    "description": "@Description 是否参与成功",
+                "required": {
+                    "description": "为true时必须先报名才能参与玩法",
                     "type": "boolean"
                 }
             }
         },
-        "api.ParticipateRequest": {
-            "description": "参与活动请求参数",
+        "models.GameConfig": {
             "type": "object",
-            "required": [
-                "user_id"
-            ],
             "properties": {
-                "user_id": {
-                    "description": "@Description 用户ID",
+                "config": {
+                    "description": "玩法具体配置",
+                    "type": "object"
+                },
+                "name": {
+                    "description": "玩法名称",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "玩法类型",
                     "type": "string"
                 }
             }
         },
-        "api.ParticipateResponse": {
-            "description": "参与活动响应数据",
-            "type": "object",
-            "properties": {
-                "participation_id": {
-                    "description": "@Description 参与ID",
-                    "type": "integer"
-                }
-            }
-        },
-        "api.PrizeInfo": {
+        "models.LotteryResult": {
             "type": "object",
             "properties": {
-                "discount_code": {
-                    "description": "折扣码（折扣码类型）",
+                "fallback_slots": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "game_name": {
                     "type": "string"
                 },
-                "price_rule_id": {
-                    "description": "价格规则ID（折扣码类型）",
+                "grant": {
+                    "$ref": "#/definitions/models.PrizeGrant"
+                },
+                "slot_index": {
                     "type": "integer"
                 },
-                "sku": {
-                    "description": "商品SKU（商品类型）",
+                "slot_name": {
                     "type": "string"
                 },
-                "title": {
-                    "description": "商品标题（商品类型）",
+                "slot_type": {
                     "type": "string"
                 },
-                "type": {
-                    "description": "奖品类型",
-                    "type": "string"
+                "won": {
+                    "type": "boolean"
                 }
             }
         },
-        "api.UpdateActivityRequest": {
-            "description": "更新活动请求参数",
+        "models.PrizeGrant": {
             "type": "object",
             "properties": {
-                "category": {
-                    "description": "@Description 活动类型",
+                "prize_id": {
                     "type": "string"
                 },
-                "end_at": {
-                    "description": "@Description 活动结束时间",
-                    "type": "integer"
-                },
-                "name": {
-                    "description": "@Description 活动名称",
+                "prize_type": {
                     "type": "string"
                 },
-                "start_at": {
-                    "description": "@Description 活动开始时间",
+                "record_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.RateLimitConfig": {
+            "type": "object",
+            "properties": {
+                "activity": {
+                    "description": "整个活动的限流",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RateLimitRule"
+                        }
+                    ]
                 },
-                "status": {
-                    "description": "@Description 活动状态",
-                    "type": "integer"
+                "ip": {
+                    "description": "每个IP在活动内的限流",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RateLimitRule"
+                        }
+                    ]
                 },
-                "version": {
-                    "description": "@Description 活动版本",
-                    "type": "string"
+                "user": {
+                    "description": "每个用户在活动内的限流",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.RateLimitRule"
+                        }
+                    ]
                 }
             }
         },
-        "api.UpdateActivityResponse": {
-            "description": "更新活动响应数据",
+        "models.RateLimitRule": {
             "type": "object",
             "properties": {
-                "success": {
-                    "description": "@Description 是否更新成功",
-                    "type": "boolean"
+                "burst": {
+                    "description": "令牌桶容量，即允许的瞬时请求数",
+                    "type": "integer"
+                },
+                "rate": {
+                    "description": "每秒补充的令牌数，0 表示不限流",
+                    "type": "number"
                 }
             }
+        },
+        "post": {
+            "description": "game_type为post时的参与玩法响应",
+            "allOf": [
+                {
+                    "$ref": "#/definitions/api.ParticipateGameResponse"
+                },
+                {
+                    "type": "object",
+                    "properties": {
+                        "result": {
+                            "$ref": "#/definitions/models.CommunityPostResult"
+                        }
+                    }
+                }
+            ]
         }
     }
 }`
@@ -0,0 +1,39 @@
+package i18n
+
+import (
+	"Activity/constant"
+)
+
+func init() {
+	RegisterBundle(LocaleEnUS, map[int]string{
+		constant.ErrSystem:                  "Something went wrong, please try again later",
+		constant.ErrInvalidParam:            "Invalid parameters",
+		constant.ErrActivityNotFound:        "Activity not found",
+		constant.ErrActivityEnded:           "This activity has ended",
+		constant.ErrActivityNotStarted:      "This activity has not started yet",
+		constant.ErrGameNotFound:            "Game not found",
+		constant.ErrGameClosed:              "This game is closed",
+		constant.ErrUserAlreadyParticipated: "You have already participated",
+		constant.ErrPrizeStockEmpty:         "Prizes are out of stock",
+		constant.ErrUserNotPosted:           "You have not posted yet",
+		constant.ErrUserNotCheckedIn:        "You have not checked in yet",
+		constant.ErrDiscountCodeInvalid:     "The discount code is invalid or has been used",
+		constant.ErrUnauthorized:            "Please sign in to continue",
+		constant.ErrForbidden:               "You do not have permission to perform this action",
+		constant.ErrActivityFull:            "This activity is full",
+		constant.ErrNotEligible:             "You are not eligible for this activity",
+		constant.ErrNotEnrolled:             "Please join the activity first",
+		constant.ErrPreconditionRequired:    "The If-Match header is required",
+		constant.ErrActivityModified:        "The activity was modified by someone else, please reload and try again",
+		constant.ErrOnlineChangeForbidden:   "The activity is online, only extending the end time or adding stock is allowed",
+		constant.ErrActivityNotPublished:    "This activity has not been published",
+		constant.ErrActivityPaused:          "This activity is paused",
+		constant.ErrInvalidTransition:       "This operation is not allowed in the current activity status",
+		constant.ErrTransitionGuardFailed:   "The activity does not meet the conditions for this status change",
+		constant.ErrRequestInProgress:       "A request with the same idempotency key is in progress, please retry later",
+		constant.ErrIdempotencyKeyReused:    "The idempotency key has been used for a different request",
+		constant.ErrTooManyRequests:         "Too many requests, please try again later",
+		constant.ErrRiskDenied:              "Participation is not allowed for this account or device",
+		constant.ErrRiskChallenge:           "Please complete the security verification before participating",
+	})
+}
@@ -0,0 +1,128 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 内置语言
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEnUS = "en-US"
+
+	// DefaultLocale 无法协商出语言时使用的默认语言
+	DefaultLocale = LocaleZhCN
+)
+
+var (
+	mu      sync.RWMutex
+	bundles = make(map[string]map[int]string)
+)
+
+// RegisterBundle 注册某个语言的错误消息，key为错误码，重复注册时合并覆盖
+func RegisterBundle(locale string, messages map[int]string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	bundle, ok := bundles[locale]
+	if !ok {
+		bundle = make(map[int]string, len(messages))
+		bundles[locale] = bundle
+	}
+	for code, msg := range messages {
+		bundle[code] = msg
+	}
+}
+
+// Supported 返回已注册的语言，按名称排序
+func Supported() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	locales := make([]string, 0, len(bundles))
+	for locale := range bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Message 返回错误码在指定语言下的消息，缺失时回退到默认语言，仍缺失时返回空字符串
+func Message(locale string, code int) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if msg, ok := bundles[locale][code]; ok {
+		return msg
+	}
+	return bundles[DefaultLocale][code]
+}
+
+// Match 将语言标签匹配到已注册的语言，大小写不敏感，如 "en" 或 "en_GB" 匹配 "en-US"
+func Match(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" || tag == "*" {
+		return "", false
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	// 1. 完整匹配
+	for locale := range bundles {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+
+	// 2. 按主语言匹配，多个候选时取名称最小的，保证结果稳定
+	primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+	var matched string
+	for locale := range bundles {
+		if strings.ToLower(strings.SplitN(locale, "-", 2)[0]) != primary {
+			continue
+		}
+		if matched == "" || locale < matched {
+			matched = locale
+		}
+	}
+	return matched, matched != ""
+}
+
+// Negotiate 根据Accept-Language头选择语言，按q值从高到低匹配，均不支持时返回默认语言
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(part, ";")
+		c := candidate{tag: strings.TrimSpace(fields[0]), q: 1}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				c.q = q
+			}
+		}
+		if c.tag != "" && c.q > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, c := range candidates {
+		if locale, ok := Match(c.tag); ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
@@ -0,0 +1,38 @@
+package i18n
+
+import (
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", DefaultLocale},
+		{"en-US", LocaleEnUS},
+		{"en", LocaleEnUS},
+		{"en-gb,en;q=0.8", LocaleEnUS},
+		{"fr-FR, en;q=0.5, zh-CN;q=0.9", LocaleZhCN},
+		{"zh-TW", LocaleZhCN},
+		{"fr-FR, de;q=0.7", DefaultLocale},
+		{"en;q=0, zh", LocaleZhCN},
+		{"*", DefaultLocale},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.header); got != tc.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+// TestBundlesComplete 所有内置语言都要覆盖默认语言中的错误码
+func TestBundlesComplete(t *testing.T) {
+	for code := range bundles[DefaultLocale] {
+		for _, locale := range Supported() {
+			if _, ok := bundles[locale][code]; !ok {
+				t.Errorf("locale %s is missing message for code %d", locale, code)
+			}
+		}
+	}
+}
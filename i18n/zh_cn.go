@@ -0,0 +1,39 @@
+package i18n
+
+import (
+	"Activity/constant"
+)
+
+func init() {
+	RegisterBundle(LocaleZhCN, map[int]string{
+		constant.ErrSystem:                  constant.ErrMsgSystem,
+		constant.ErrInvalidParam:            constant.ErrMsgInvalidParam,
+		constant.ErrActivityNotFound:        constant.ErrMsgActivityNotFound,
+		constant.ErrActivityEnded:           constant.ErrMsgActivityEnded,
+		constant.ErrActivityNotStarted:      constant.ErrMsgActivityNotStarted,
+		constant.ErrGameNotFound:            constant.ErrMsgGameNotFound,
+		constant.ErrGameClosed:              constant.ErrMsgGameClosed,
+		constant.ErrUserAlreadyParticipated: constant.ErrMsgUserAlreadyParticipated,
+		constant.ErrPrizeStockEmpty:         constant.ErrMsgPrizeStockEmpty,
+		constant.ErrUserNotPosted:           constant.ErrMsgUserNotPosted,
+		constant.ErrUserNotCheckedIn:        constant.ErrMsgUserNotCheckedIn,
+		constant.ErrDiscountCodeInvalid:     constant.ErrMsgDiscountCodeInvalid,
+		constant.ErrUnauthorized:            constant.ErrMsgUnauthorized,
+		constant.ErrForbidden:               constant.ErrMsgForbidden,
+		constant.ErrActivityFull:            constant.ErrMsgActivityFull,
+		constant.ErrNotEligible:             constant.ErrMsgNotEligible,
+		constant.ErrNotEnrolled:             constant.ErrMsgNotEnrolled,
+		constant.ErrPreconditionRequired:    constant.ErrMsgPreconditionRequired,
+		constant.ErrActivityModified:        constant.ErrMsgActivityModified,
+		constant.ErrOnlineChangeForbidden:   constant.ErrMsgOnlineChangeForbidden,
+		constant.ErrActivityNotPublished:    constant.ErrMsgActivityNotPublished,
+		constant.ErrActivityPaused:          constant.ErrMsgActivityPaused,
+		constant.ErrInvalidTransition:       constant.ErrMsgInvalidTransition,
+		constant.ErrTransitionGuardFailed:   constant.ErrMsgTransitionGuardFailed,
+		constant.ErrRequestInProgress:       constant.ErrMsgRequestInProgress,
+		constant.ErrIdempotencyKeyReused:    constant.ErrMsgIdempotencyKeyReused,
+		constant.ErrTooManyRequests:         constant.ErrMsgTooManyRequests,
+		constant.ErrRiskDenied:              constant.ErrMsgRiskDenied,
+		constant.ErrRiskChallenge:           constant.ErrMsgRiskChallenge,
+	})
+}
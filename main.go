@@ -2,15 +2,23 @@ package main
 
 import (
 	"Activity/api"
+	"Activity/auth"
+	"Activity/community"
 	"Activity/config"
 	"Activity/models"
+	"Activity/ratelimit"
+	"Activity/risk"
+	"Activity/scheduler"
 	"Activity/storage/mysql"
 	"Activity/storage/mysql/repository"
+	redisstore "Activity/storage/redis"
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -36,17 +44,99 @@ func main() {
 	}
 
 	// 创建仓储实例
-	activityRepo := repository.NewActivityRepository(db)
+	prizeRepo := repository.NewPrizeRepository(db)
+	codeRepo := repository.NewDiscountCodeRepository(db)
+	participationRepo := repository.NewParticipationRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	enrollmentRepo := repository.NewEnrollmentRepository(db)
+	requestRepo := repository.NewParticipationRequestRepository(db)
+
+	// 启用Redis库存时，发奖和参与记录改为访问Redis，由调度器异步落库
+	var (
+		redisPrizes *redisstore.PrizeRepository
+		writeBehind *redisstore.WriteBehind
+	)
+	var redisClient *redis.Client
+	if cfg.Redis.Enabled || (cfg.RateLimit.Enabled && cfg.RateLimit.Backend == "redis") {
+		redisClient, err = redisstore.NewClient(&redisstore.Config{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		if err != nil {
+			log.Fatalf("Failed to connect to redis: %v", err)
+		}
+	}
+	if cfg.Redis.Enabled {
+		client := redisClient
+		writeBehind = redisstore.NewWriteBehind(client, prizeRepo, participationRepo, codeRepo, cfg.Redis.ReconcileInterval)
+		redisPrizes = redisstore.NewPrizeRepository(client, prizeRepo, codeRepo)
+		prizeRepo = redisPrizes
+		participationRepo = redisstore.NewParticipationRepository(client, participationRepo)
+	}
+	// 发帖玩法通过社区服务验证帖子
+	var communityClient models.CommunityClient
+	if cfg.Community.BaseURL != "" {
+		communityClient = community.NewHTTPClient(cfg.Community.BaseURL, cfg.Community.Token, cfg.Community.Timeout)
+	}
+	transactor := repository.NewTransactor(db)
+	activityRepo := repository.NewCachedActivityRepository(repository.NewActivityRepositoryWithStores(db, participationRepo, prizeRepo, communityClient, transactor), cfg.Cache.ActivityTTL)
 
 	// 创建服务实例
-	activityService := api.NewActivityService(activityRepo)
-	gameService := api.NewGameService(activityRepo)
+	activityService := api.NewActivityService(activityRepo, codeRepo, participationRepo, enrollmentRepo)
+	gameService := api.NewGameService(activityRepo, prizeRepo, participationRepo, enrollmentRepo, requestRepo, transactor, newRiskChecker(cfg.Risk, repository.NewRiskEventRepository(db)))
+	auditService := api.NewAuditService(auditRepo)
+
+	// 创建令牌校验器
+	verifier := auth.NewHMACVerifier([]byte(cfg.Auth.Secret), cfg.Auth.Issuer)
+
+	// 启动活动调度器，按时间自动上线、结束活动，库存耗尽时关闭玩法
+	if cfg.Scheduler.Enabled {
+		sched := scheduler.NewScheduler(activityRepo, repository.NewSchedulerLockRepository(db), scheduler.Config{
+			Interval:   cfg.Scheduler.Interval,
+			WarmUpLead: cfg.Scheduler.WarmUpLead,
+		})
+		for _, point := range scheduler.HookPoints {
+			sched.On(point, scheduler.LogHook)
+		}
+		sched.AddJob(scheduler.JobDiscountCodeExpire, scheduler.ExpireDiscountCodes(codeRepo))
+		if writeBehind != nil {
+			// 活动开始前预先把库存加载到Redis，并定期将Redis中的记录落库
+			sched.On(scheduler.HookWarmUp, func(ctx context.Context, event scheduler.Event) error {
+				activity, err := activityRepo.GetActivity(ctx, strconv.FormatInt(event.ActivityID, 10))
+				if err != nil {
+					return err
+				}
+				return redisPrizes.Warm(ctx, activity)
+			})
+			sched.AddJob("redis_sync", writeBehind.Sync)
+		}
+		go sched.Run(context.Background())
+	}
+
+	// 创建参与接口的限流器
+	var rateLimiter *api.RateLimiter
+	if cfg.RateLimit.Enabled {
+		limiter := ratelimit.NewMemoryLimiter()
+		if cfg.RateLimit.Backend == "redis" {
+			limiter = ratelimit.NewRedisLimiter(redisClient)
+		}
+		rateLimiter = api.NewRateLimiter(limiter, &models.RateLimitConfig{
+			User:     &models.RateLimitRule{Rate: cfg.RateLimit.User.Rate, Burst: cfg.RateLimit.User.Burst},
+			IP:       &models.RateLimitRule{Rate: cfg.RateLimit.IP.Rate, Burst: cfg.RateLimit.IP.Burst},
+			Activity: &models.RateLimitRule{Rate: cfg.RateLimit.Activity.Rate, Burst: cfg.RateLimit.Activity.Burst},
+		})
+	}
 
 	// 创建处理器
-	handler := api.NewHandler(gameService, activityService)
+	handler := api.NewHandler(gameService, activityService, auditService, verifier, rateLimiter)
 
 	// 创建路由
 	r := gin.Default()
+	// 客户端IP用于限流和风控，只信任配置的代理转发的X-Forwarded-For
+	if err := r.SetTrustedProxies(cfg.API.TrustedProxies); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
 
 	// 注册Swagger路由
 	handler.RegisterSwagger(r)
@@ -62,39 +152,20 @@ func main() {
 	}
 }
 
-// ActivityRepository 活动仓库实现
-type activityRepository struct {
-	// TODO: 添加数据库连接等依赖
-}
-
-func NewActivityRepository() api.ActivityRepository {
-	return &activityRepository{}
-}
-
-func (r *activityRepository) GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error) {
-	// TODO: 从数据库获取活动信息
-	// 这里先返回一个模拟数据
-	return &models.CommunityActivity{
-		MetaActivity: models.MetaActivity{
-			ID:       1,
-			Category: "community",
-			Version:  "v1",
-			StartAt:  1679000000,
-			EndAt:    1679086400,
-			Status:   1,
-		},
-		GameList: []models.GameInterface{
-			&models.CommunityPostGame{
-				Name_: "发帖奖励",
-				Prize: &models.DiscountCodePrize{
-					DiscountCode: "COMMUNITY_2024",
-					PriceRuleID:  123,
-					Probability:  100,
-					TotalNum:     1000,
-					RemainNum:    1000,
-				},
-				State: models.GameStateOPEN,
-			},
-		},
-	}, nil
+// newRiskChecker 按配置创建风控规则链，未启用时返回nil
+func newRiskChecker(cfg config.RiskConfig, events repository.RiskEventRepository) api.RiskChecker {
+	if !cfg.Enabled {
+		return nil
+	}
+	rules := []risk.Rule{risk.NewBlocklist(cfg.BlockedUsers, cfg.BlockedDevices, cfg.BlockedIPs)}
+	if cfg.DeviceMaxUsers > 0 {
+		rules = append(rules, risk.NewDeviceSharing(events, cfg.DeviceMaxUsers, cfg.DeviceUsersWindow, cfg.MissingSignal))
+	}
+	if cfg.DeviceMaxWins > 0 {
+		rules = append(rules, risk.NewDeviceWins(events, cfg.DeviceMaxWins, cfg.DeviceWinsWindow, cfg.MissingSignal))
+	}
+	if cfg.NewAccountCooldown > 0 {
+		rules = append(rules, risk.NewNewAccountCooldown(cfg.NewAccountCooldown, cfg.MissingSignal))
+	}
+	return risk.NewChain(events, rules...)
 }
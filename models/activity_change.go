@@ -0,0 +1,141 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// PrizeStockDeltas 比较新旧玩法中同一库存标识的奖品总库存，返回发生变化的库存标识及变化量
+// 新增或删除的奖品不计入，库存记录会在首次发放时按新配置初始化
+func PrizeStockDeltas(ctx context.Context, oldGames, newGames []GameInterface) map[string]int64 {
+	oldStocks := stockTotals(ctx, oldGames)
+	deltas := make(map[string]int64)
+	for key, total := range stockTotals(ctx, newGames) {
+		if old, ok := oldStocks[key]; ok && old != total {
+			deltas[key] = total - old
+		}
+	}
+	return deltas
+}
+
+// CheckStockOnlyChange 校验新玩法配置相对旧配置只增加了奖品库存，用于已上线活动的修改
+func CheckStockOnlyChange(ctx context.Context, oldGames, newGames []GameInterface) error {
+	if len(oldGames) != len(newGames) {
+		return fmt.Errorf("games cannot be added or removed")
+	}
+	for i := range oldGames {
+		oldGame, newGame := oldGames[i], newGames[i]
+		if oldGame.Name(ctx) != newGame.Name(ctx) || oldGame.Type(ctx) != newGame.Type(ctx) {
+			return fmt.Errorf("games[%d]: name and type cannot be changed", i)
+		}
+		if err := checkGameStockOnlyChange(ctx, oldGame, newGame); err != nil {
+			return fmt.Errorf("games[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// checkGameStockOnlyChange 将新配置副本中的库存替换为旧值后与旧配置的副本比较序列化结果，确认除库存外没有其他变化
+func checkGameStockOnlyChange(ctx context.Context, oldGame, newGame GameInterface) error {
+	oldStocks := stockPrizes(ctx, oldGame)
+	newStocks := stockPrizes(ctx, newGame)
+	if len(oldStocks) != len(newStocks) {
+		return fmt.Errorf("prizes cannot be added or removed")
+	}
+	for key, newPrize := range newStocks {
+		oldPrize, ok := oldStocks[key]
+		if !ok {
+			return fmt.Errorf("prizes cannot be added or removed")
+		}
+		if newPrize.StockTotal() < oldPrize.StockTotal() {
+			return fmt.Errorf("prize %q: stock cannot be reduced", key)
+		}
+	}
+
+	// 序列化不包含玩法名称等字段，新旧配置都在副本上比较，副本中的库存标识一一对应
+	oldCopy, err := copyGame(oldGame)
+	if err != nil {
+		return err
+	}
+	newCopy, err := copyGame(newGame)
+	if err != nil {
+		return err
+	}
+	copiedStocks := stockPrizes(ctx, oldCopy)
+	for key, prize := range stockPrizes(ctx, newCopy) {
+		if oldPrize, ok := copiedStocks[key]; ok {
+			prize.setStockTotal(oldPrize.StockTotal())
+		}
+	}
+
+	oldJSON, err := json.Marshal(oldCopy)
+	if err != nil {
+		return err
+	}
+	newJSON, err := json.Marshal(newCopy)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(oldJSON, newJSON) {
+		return fmt.Errorf("only prize stock can be increased")
+	}
+	return nil
+}
+
+// copyGame 通过序列化复制玩法配置，副本不携带运行时依赖和不参与序列化的字段（如玩法名称），修改副本不影响原玩法
+func copyGame(game GameInterface) (GameInterface, error) {
+	data, err := json.Marshal(game)
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeOf(game)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	copied, ok := reflect.New(typ).Interface().(GameInterface)
+	if !ok {
+		return nil, fmt.Errorf("game %T cannot be copied", game)
+	}
+	if err := copied.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
+
+// StockPrizes 汇总各玩法中配置了库存的奖品，键为库存标识
+func StockPrizes(ctx context.Context, games []GameInterface) map[string]StockPrize {
+	prizes := make(map[string]StockPrize)
+	for _, game := range games {
+		for key, prize := range stockPrizes(ctx, game) {
+			prizes[key] = prize
+		}
+	}
+	return prizes
+}
+
+// stockTotals 汇总玩法中各库存标识的总库存
+func stockTotals(ctx context.Context, games []GameInterface) map[string]int64 {
+	totals := make(map[string]int64)
+	for key, prize := range StockPrizes(ctx, games) {
+		totals[key] = prize.StockTotal()
+	}
+	return totals
+}
+
+// stockPrizes 返回玩法中配置了库存的奖品
+func stockPrizes(ctx context.Context, game GameInterface) map[string]StockPrize {
+	provider, ok := game.(PrizeProvider)
+	if !ok {
+		return nil
+	}
+	prizes := make(map[string]StockPrize)
+	for key, prize := range provider.Prizes(ctx) {
+		if stock, ok := prize.(StockPrize); ok {
+			prizes[key] = stock
+		}
+	}
+	return prizes
+}
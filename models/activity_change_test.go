@@ -0,0 +1,102 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newChangeGames 返回与lottery_activity.json结构一致的抽奖玩法和签到玩法，modify用于修改新配置
+func newChangeGames(modify func(lottery *LotteryGame, checkin *CheckinGame)) []GameInterface {
+	lottery := &LotteryGame{
+		Name_:    "幸运抽奖",
+		State:    GameStateOPEN,
+		MaxDraws: 3,
+		Slots: []LotterySlot{
+			{Type: LotterySlotDiscountCode, Name: "9折优惠码", DiscountCode: &DiscountCodePrize{DiscountCode: "LUCKY_2024", Probability: 30, TotalNum: 500}},
+			{Type: LotterySlotProduct, Name: "周边礼品", Product: &ProductPrize{Sku: "GIFT-001", Probability: 5, TotalNum: 20}},
+			{Type: LotterySlotThanks, Name: "谢谢参与", Probability: 65},
+		},
+	}
+	checkin := &CheckinGame{
+		Name_:  "每日签到",
+		State:  GameStateOPEN,
+		Prize:  &DiscountCodePrize{DiscountCode: "CHECKIN", TotalNum: 100},
+		Config: CheckinConfig{RequiredDays: 3},
+	}
+	if modify != nil {
+		modify(lottery, checkin)
+	}
+	return []GameInterface{lottery, checkin}
+}
+
+func TestCheckStockOnlyChange(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		modify  func(lottery *LotteryGame, checkin *CheckinGame)
+		wantErr string // 为空表示允许
+	}{
+		{"配置不变", nil, ""},
+		{"增加库存", func(l *LotteryGame, c *CheckinGame) {
+			l.Slots[0].DiscountCode.TotalNum = 600
+			c.Prize.TotalNum = 150
+		}, ""},
+		{"减少库存", func(l *LotteryGame, c *CheckinGame) { l.Slots[1].Product.TotalNum = 10 }, `games[0]: prize "幸运抽奖#1": stock cannot be reduced`},
+		{"删除奖品", func(l *LotteryGame, c *CheckinGame) { l.Slots = append(l.Slots[:1], l.Slots[2]) }, "games[0]: prizes cannot be added or removed"},
+		{"删除签到奖品", func(l *LotteryGame, c *CheckinGame) { c.Prize = nil }, "games[1]: prizes cannot be added or removed"},
+		{"新增奖品", func(l *LotteryGame, c *CheckinGame) {
+			l.Slots[2] = LotterySlot{Type: LotterySlotProduct, Name: "新礼品", Product: &ProductPrize{Sku: "GIFT-002", Probability: 65, TotalNum: 1}}
+		}, "games[0]: prizes cannot be added or removed"},
+		{"重命名玩法", func(l *LotteryGame, c *CheckinGame) { l.Name_ = "超级抽奖" }, "games[0]: name and type cannot be changed"},
+		{"修改中奖概率", func(l *LotteryGame, c *CheckinGame) { l.Slots[0].DiscountCode.Probability = 60 }, "games[0]: only prize stock can be increased"},
+		{"增加库存的同时修改其他配置", func(l *LotteryGame, c *CheckinGame) {
+			c.Prize.TotalNum = 150
+			c.Config.RequiredDays = 1
+		}, "games[1]: only prize stock can be increased"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newGames := newChangeGames(tt.modify)
+			before := newChangeGames(tt.modify)
+
+			err := CheckStockOnlyChange(ctx, newChangeGames(nil), newGames)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("CheckStockOnlyChange() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("CheckStockOnlyChange() error = %v, want %q", err, tt.wantErr)
+			}
+			// 比较只在副本上进行，新配置保持原样
+			if !reflect.DeepEqual(newGames, before) {
+				t.Errorf("CheckStockOnlyChange() modified the new games")
+			}
+		})
+	}
+
+	if err := CheckStockOnlyChange(ctx, newChangeGames(nil), newChangeGames(nil)[:1]); err == nil || !strings.Contains(err.Error(), "cannot be added or removed") {
+		t.Errorf("CheckStockOnlyChange(removed game) error = %v", err)
+	}
+}
+
+func TestPrizeStockDeltas(t *testing.T) {
+	ctx := context.Background()
+	newGames := newChangeGames(func(l *LotteryGame, c *CheckinGame) {
+		l.Slots[0].DiscountCode.TotalNum = 600
+		l.Slots[1].Product.TotalNum = 15
+		c.Prize = nil
+	})
+
+	// 键与发奖时使用的库存标识一致，删除的奖品不计入
+	want := map[string]int64{"幸运抽奖#0": 100, "幸运抽奖#1": -5}
+	if got := PrizeStockDeltas(ctx, newChangeGames(nil), newGames); !reflect.DeepEqual(got, want) {
+		t.Errorf("PrizeStockDeltas() = %v, want %v", got, want)
+	}
+	for key := range want {
+		if _, ok := newGames[0].(PrizeProvider).Prizes(ctx)[key]; !ok {
+			t.Errorf("delta key %s is not a prize key", key)
+		}
+	}
+}
@@ -1,22 +1,40 @@
 package models
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 )
 
+// ErrUnsupportedGameType 玩法类型未注册
+var ErrUnsupportedGameType = errors.New("unsupported game type")
+
 // ActivityFactory 活动工厂接口
 type ActivityFactory interface {
 	Create(config ActivityConfigJSON) (ActivityInterface, error)
 }
 
+// GameFactory 玩法工厂接口
+type GameFactory interface {
+	Create(config GameConfig) (GameInterface, error)
+}
+
 // activityFactoryRegistry 活动工厂注册表
 var (
 	activityFactoryRegistry = make(map[string]ActivityFactory)
 	registryMutex           sync.RWMutex
 )
 
+// gameFactoryRegistry 玩法工厂注册表，任意活动类型都可以组合已注册的玩法
+var (
+	gameFactoryRegistry = make(map[string]GameFactory)
+	gameRegistryMutex   sync.RWMutex
+)
+
 // RegisterActivityFactory 注册活动工厂
 func RegisterActivityFactory(category string, factory ActivityFactory) {
 	registryMutex.Lock()
@@ -32,21 +50,50 @@ func GetActivityFactory(category string) (ActivityFactory, bool) {
 	return factory, exists
 }
 
+// RegisterGameFactory 注册玩法工厂
+func RegisterGameFactory(gameType string, factory GameFactory) {
+	gameRegistryMutex.Lock()
+	defer gameRegistryMutex.Unlock()
+	gameFactoryRegistry[gameType] = factory
+}
+
+// GetGameFactory 获取玩法工厂
+func GetGameFactory(gameType string) (GameFactory, bool) {
+	gameRegistryMutex.RLock()
+	defer gameRegistryMutex.RUnlock()
+	factory, exists := gameFactoryRegistry[gameType]
+	return factory, exists
+}
+
+// GameTypes 返回已注册的玩法类型，按名称排序
+func GameTypes() []string {
+	gameRegistryMutex.RLock()
+	defer gameRegistryMutex.RUnlock()
+	types := make([]string, 0, len(gameFactoryRegistry))
+	for gameType := range gameFactoryRegistry {
+		types = append(types, gameType)
+	}
+	sort.Strings(types)
+	return types
+}
+
 // ActivityConfigJSON 活动配置JSON结构体
 type ActivityConfigJSON struct {
-	Category string       `json:"category"` // 活动类型
-	Version  string       `json:"version"`  // 活动版本
-	Name     string       `json:"name"`     // 活动名称
-	StartAt  int64        `json:"start_at"` // 开始时间
-	EndAt    int64        `json:"end_at"`   // 结束时间
-	Games    []GameConfig `json:"games"`    // 玩法配置列表
+	Category   string            `json:"category"`             // 活动类型
+	Version    string            `json:"version"`              // 活动版本
+	Name       string            `json:"name"`                 // 活动名称
+	StartAt    int64             `json:"start_at"`             // 开始时间
+	EndAt      int64             `json:"end_at"`               // 结束时间
+	Games      []GameConfig      `json:"games"`                // 玩法配置列表
+	Enrollment *EnrollmentConfig `json:"enrollment,omitempty"` // 报名配置，为空表示无需报名
+	RateLimit  *RateLimitConfig  `json:"rate_limit,omitempty"` // 参与接口的限流配置，为空时使用默认规则
 }
 
 // GameConfig 玩法配置结构体
 type GameConfig struct {
-	Type   string          `json:"type"`   // 玩法类型
-	Name   string          `json:"name"`   // 玩法名称
-	Config json.RawMessage `json:"config"` // 玩法具体配置
+	Type   string          `json:"type"`                        // 玩法类型
+	Name   string          `json:"name"`                        // 玩法名称
+	Config json.RawMessage `json:"config" swaggertype:"object"` // 玩法具体配置
 }
 
 // NewActivityFromConfig 根据配置创建活动实例
@@ -66,15 +113,110 @@ func NewActivityFromConfig(configJSON []byte) (ActivityInterface, error) {
 
 // NewGameFromConfig 根据配置创建玩法实例
 func NewGameFromConfig(config GameConfig) (GameInterface, error) {
-	switch config.Type {
-	case "post":
-		var game CommunityPostGame
-		if err := json.Unmarshal(config.Config, &game); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal game config: %w", err)
+	factory, exists := GetGameFactory(config.Type)
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedGameType, config.Type)
+	}
+	return factory.Create(config)
+}
+
+// NewGamesFromConfig 根据配置列表创建玩法实例
+func NewGamesFromConfig(configs []GameConfig) ([]GameInterface, error) {
+	games := make([]GameInterface, 0, len(configs))
+	for _, gameConfig := range configs {
+		game, err := NewGameFromConfig(gameConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create game %q: %w", gameConfig.Name, err)
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// FieldError 配置字段错误
+type FieldError struct {
+	Field   string `json:"field"`   // 出错字段，如 games[0].config
+	Message string `json:"message"` // 错误描述
+}
+
+func (e FieldError) String() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ConfigErrors 配置校验错误集合
+type ConfigErrors []FieldError
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.String())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate 校验活动配置，逐个字段收集错误
+// 玩法配置会通过注册的工厂实例化，并调用玩法自身的ValidateConfig
+func (c ActivityConfigJSON) Validate(ctx context.Context) error {
+	var errs ConfigErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if c.Name == "" {
+		add("name", "is required")
+	}
+	if c.Category == "" {
+		add("category", "is required")
+	} else if _, exists := GetActivityFactory(c.Category); !exists {
+		add("category", "unsupported activity category %q", c.Category)
+	}
+	if c.StartAt >= c.EndAt {
+		add("end_at", "must be after start_at")
+	}
+	if len(c.Games) == 0 {
+		add("games", "at least one game is required")
+	}
+	if c.Enrollment != nil {
+		if err := c.Enrollment.Validate(); err != nil {
+			add("enrollment", "%v", err)
+		}
+	}
+	if c.RateLimit != nil {
+		if err := c.RateLimit.Validate(); err != nil {
+			add("rate_limit", "%v", err)
+		}
+	}
+
+	names := make(map[string]struct{}, len(c.Games))
+	for i, gameConfig := range c.Games {
+		field := fmt.Sprintf("games[%d]", i)
+		if gameConfig.Name == "" {
+			add(field+".name", "is required")
+		} else if _, dup := names[gameConfig.Name]; dup {
+			add(field+".name", "duplicate game name %q", gameConfig.Name)
 		}
-		game.Name_ = config.Name
-		return &game, nil
-	default:
-		return nil, fmt.Errorf("unsupported game type: %s", config.Type)
+		names[gameConfig.Name] = struct{}{}
+
+		if gameConfig.Type == "" {
+			add(field+".type", "is required")
+			continue
+		}
+		game, err := NewGameFromConfig(gameConfig)
+		if err != nil {
+			if errors.Is(err, ErrUnsupportedGameType) {
+				add(field+".type", "unsupported game type %q", gameConfig.Type)
+			} else {
+				add(field+".config", "%v", err)
+			}
+			continue
+		}
+		if err := game.ValidateConfig(ctx); err != nil {
+			add(field+".config", "%v", err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
 	}
+	return nil
 }
@@ -3,28 +3,168 @@ package models
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 )
 
+// CheckinActivityFactory 签到活动工厂
+type CheckinActivityFactory struct{}
+
+func (f *CheckinActivityFactory) Create(config ActivityConfigJSON) (ActivityInterface, error) {
+	// 解析玩法配置
+	games, err := NewGamesFromConfig(config.Games)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckinActivity{
+		MetaActivity: MetaActivity{
+			Category:   config.Category,
+			Version:    config.Version,
+			Name:       config.Name,
+			StartAt:    config.StartAt,
+			EndAt:      config.EndAt,
+			Status:     ActivityStatusOnline, // 默认上线状态，存储层以数据库状态回填
+			Enrollment: config.Enrollment,
+			RateLimit:  config.RateLimit,
+		},
+		GameList: games,
+	}, nil
+}
+
+// CheckinGameFactory 签到玩法工厂
+type CheckinGameFactory struct{}
+
+func (f *CheckinGameFactory) Create(config GameConfig) (GameInterface, error) {
+	var game CheckinGame
+	if err := json.Unmarshal(config.Config, &game); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal game config: %w", err)
+	}
+	game.Name_ = config.Name
+	return &game, nil
+}
+
+// init 注册活动工厂和玩法工厂
+func init() {
+	RegisterActivityFactory("checkin", &CheckinActivityFactory{})
+	RegisterGameFactory(GameTypeCheckin, &CheckinGameFactory{})
+}
+
 // CheckinActivity 签到活动
 type CheckinActivity struct {
 	MetaActivity
 	GameList []GameInterface
 }
 
+func (a *CheckinActivity) Category() string {
+	return a.MetaActivity.Category
+}
+
+func (a *CheckinActivity) Version() string {
+	return a.MetaActivity.Version
+}
+
+func (a *CheckinActivity) Name() string {
+	return a.MetaActivity.Name
+}
+
+func (a *CheckinActivity) Games() []GameInterface {
+	return a.GameList
+}
+
+func (a *CheckinActivity) StartAt() int64 {
+	return a.MetaActivity.StartAt
+}
+
+func (a *CheckinActivity) EndAt() int64 {
+	return a.MetaActivity.EndAt
+}
+
+func (a *CheckinActivity) Status() int64 {
+	return a.MetaActivity.Status
+}
+
 // CheckinGame 签到玩法
 type CheckinGame struct {
-	Name_  string             `json:"-"`
-	Prize  *DiscountCodePrize `json:"prize"`
-	State  GameState          `json:"state"`
-	Config CheckinConfig      `json:"config"`
+	Name_   string             `json:"-"`
+	Prize   *DiscountCodePrize `json:"prize"`
+	State   GameState          `json:"state"`
+	Config  CheckinConfig      `json:"config"`
+	runtime *Runtime           // 运行时依赖，由BindRuntime注入
 }
 
+// 签到进度的计算方式
+const (
+	CheckinStreakConsecutive = "consecutive" // 连续签到，漏签一天后进度清零
+	CheckinStreakCumulative  = "cumulative"  // 累计签到，漏签不影响进度
+)
+
+// checkinDateLayout 签到自然日格式
+const checkinDateLayout = "2006-01-02"
+
+// ErrAlreadyCheckedIn 用户当天已签到
+var ErrAlreadyCheckedIn = errors.New("user already checked in today")
+
 // CheckinConfig 签到配置
 type CheckinConfig struct {
-	RequiredDays int64 `json:"required_days"` // 需要连续签到的天数
-	CheckinDays  int64 `json:"checkin_days"`  // 当前已签到天数
+	RequiredDays int64  `json:"required_days"` // 领奖需要的签到天数
+	StreakMode   string `json:"streak_mode"`   // 进度计算方式：consecutive(默认)/cumulative
+	TimeZone     string `json:"time_zone"`     // 划分自然日使用的时区，如 Asia/Shanghai，默认服务器本地时区
+}
+
+// CheckinStore 用户签到记录存储
+type CheckinStore interface {
+	// Checkin 记录用户在某个自然日的签到，同一天重复签到返回 ErrAlreadyCheckedIn
+	Checkin(ctx context.Context, activityID int64, gameName, uid, date string) error
+	// CheckinDates 返回用户所有签到的自然日，格式为 YYYY-MM-DD，按日期升序
+	CheckinDates(ctx context.Context, activityID int64, gameName, uid string) ([]string, error)
+}
+
+// CheckinStreak 用户签到进度
+type CheckinStreak struct {
+	Consecutive int64 // 截止今天（或昨天）仍未中断的连续签到天数
+	Longest     int64 // 历史最长连续签到天数
+	Cumulative  int64 // 累计签到天数
+	CheckedIn   bool  // 今天是否已签到
+}
+
+// computeCheckinStreak 根据升序的签到日期计算进度
+func computeCheckinStreak(dates []string, today string) CheckinStreak {
+	var streak CheckinStreak
+	var prev time.Time
+	var run int64
+	for _, date := range dates {
+		day, err := time.Parse(checkinDateLayout, date)
+		if err != nil {
+			continue
+		}
+		if !prev.IsZero() && day.Sub(prev) == 24*time.Hour {
+			run++
+		} else if prev.IsZero() || day.After(prev) {
+			run = 1
+		} else {
+			continue // 重复或乱序的日期不计入
+		}
+		prev = day
+		streak.Cumulative++
+		if run > streak.Longest {
+			streak.Longest = run
+		}
+	}
+
+	// 最后一次签到在今天或昨天时，连续签到尚未中断
+	todayDay, err := time.Parse(checkinDateLayout, today)
+	if err == nil && !prev.IsZero() {
+		switch todayDay.Sub(prev) {
+		case 0:
+			streak.Consecutive = run
+			streak.CheckedIn = true
+		case 24 * time.Hour:
+			streak.Consecutive = run
+		}
+	}
+	return streak
 }
 
 // Name 返回玩法名称
@@ -32,38 +172,102 @@ func (p CheckinGame) Name(ctx context.Context) string {
 	return p.Name_
 }
 
+// Type 返回玩法类型
+func (p CheckinGame) Type(ctx context.Context) string {
+	return GameTypeCheckin
+}
+
+// BindRuntime 注入运行时依赖
+func (p *CheckinGame) BindRuntime(rt *Runtime) {
+	p.runtime = rt
+	if p.Prize != nil {
+		p.Prize.Bind(rt, p.Name_, p.Name_)
+	}
+}
+
 // Perform 执行签到
 func (p CheckinGame) Perform(ctx context.Context, user User, action ActionInterface) (ResultInterface, error) {
 	// 1. 检查玩法状态
 	if p.GameState(ctx) != GameStateOPEN {
-		return nil, fmt.Errorf("game is not open")
+		return nil, ErrGameNotOpen
+	}
+	if p.runtime == nil || p.runtime.Checkins == nil {
+		return nil, fmt.Errorf("%w: checkin store", ErrRuntimeNotConfigured)
+	}
+
+	// 2. 计算签到所在的自然日
+	checkinTime := time.Now()
+	if a, ok := action.(*CheckinAction); ok && !a.CheckinTime.IsZero() {
+		checkinTime = a.CheckinTime
+	}
+	today, err := p.date(checkinTime)
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 检查用户状态
-	if p.UserState(ctx) != UserStateOPEN {
-		return nil, fmt.Errorf("user cannot participate")
+	// 3. 检查用户状态
+	before, err := p.streak(ctx, user, today)
+	if err != nil {
+		return nil, err
+	}
+	if before.CheckedIn {
+		return nil, ErrAlreadyCheckedIn
+	}
+	if p.completed(before) {
+		return nil, ErrUserCannotParticipate
 	}
 
-	// 3. 更新签到天数
-	p.Config.CheckinDays++
+	// 4. 记录签到并在达到要求天数时发放奖励，两者在同一事务中提交，发奖失败时签到一并回滚，用户可以重新签到领奖
+	// 重复签到由存储层的唯一约束拦截
+	var result *CheckinResult
+	err = p.runtime.transaction(ctx, func(ctx context.Context) error {
+		if err := p.runtime.Checkins.Checkin(ctx, p.runtime.ActivityID, p.Name_, user.Uid, today); err != nil {
+			return err
+		}
+		after, err := p.streak(ctx, user, today)
+		if err != nil {
+			return err
+		}
+
+		result = &CheckinResult{
+			GameName:        p.Name_,
+			CheckinDate:     today,
+			CheckinDays:     p.progress(after),
+			ConsecutiveDays: after.Consecutive,
+			CumulativeDays:  after.Cumulative,
+			RequiredDays:    p.Config.RequiredDays,
+			Completed:       p.completed(after),
+			state:           UserStateOPEN,
+		}
+		if !result.Completed {
+			return nil
+		}
+		result.state = UserStateCLOSED
 
-	// 4. 检查是否达到要求天数
-	if p.Config.CheckinDays >= p.Config.RequiredDays {
-		// 5. 发放奖励
+		// 5. 本次签到达到要求天数时发放奖励
 		if p.Prize != nil {
-			err := p.Prize.WinPrize(ctx, user)
+			grant, err := p.Prize.WinPrize(ctx, user)
 			if err != nil {
-				return nil, fmt.Errorf("failed to give prize: %w", err)
+				return fmt.Errorf("failed to give prize: %w", err)
 			}
+			result.Prize = p.Prize
+			result.Grant = grant
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &CheckinResult{
-		GameName:     p.Name_,
-		CheckinDays:  p.Config.CheckinDays,
-		RequiredDays: p.Config.RequiredDays,
-		Prize:        p.Prize,
-	}, nil
+	return result, nil
+}
+
+// Prizes 返回玩法的奖品，库存标识为玩法名称
+func (p CheckinGame) Prizes(ctx context.Context) map[string]PrizeInterface {
+	if p.Prize == nil {
+		return nil
+	}
+	return map[string]PrizeInterface{p.Name_: p.Prize}
 }
 
 // GameState 返回玩法状态
@@ -71,13 +275,73 @@ func (p CheckinGame) GameState(ctx context.Context) GameState {
 	return p.State
 }
 
-// UserState 返回用户状态
-func (p CheckinGame) UserState(ctx context.Context) UserState {
-	// 如果已经达到要求天数，则不能再参与
-	if p.Config.CheckinDays >= p.Config.RequiredDays {
+// UserState 返回用户状态，未签到过为PENDING，达到领奖天数后为CLOSED
+func (p CheckinGame) UserState(ctx context.Context, user User) UserState {
+	if p.runtime == nil || p.runtime.Checkins == nil {
+		return UserStateUNKNOWN
+	}
+	today, err := p.date(time.Now())
+	if err != nil {
+		return UserStateUNKNOWN
+	}
+	streak, err := p.streak(ctx, user, today)
+	if err != nil {
+		return UserStateUNKNOWN
+	}
+	switch {
+	case streak.Cumulative == 0:
+		return UserStatePENDING
+	case p.completed(streak):
 		return UserStateCLOSED
+	default:
+		return UserStateOPEN
+	}
+}
+
+// location 返回划分自然日使用的时区
+func (p CheckinGame) location() (*time.Location, error) {
+	if p.Config.TimeZone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(p.Config.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q: %w", p.Config.TimeZone, err)
 	}
-	return UserStateOPEN
+	return loc, nil
+}
+
+// date 返回某个时间点在配置时区下的自然日
+func (p CheckinGame) date(t time.Time) (string, error) {
+	loc, err := p.location()
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format(checkinDateLayout), nil
+}
+
+// streak 读取用户签到记录并计算进度
+func (p CheckinGame) streak(ctx context.Context, user User, today string) (CheckinStreak, error) {
+	dates, err := p.runtime.Checkins.CheckinDates(ctx, p.runtime.ActivityID, p.Name_, user.Uid)
+	if err != nil {
+		return CheckinStreak{}, fmt.Errorf("failed to load checkin records: %w", err)
+	}
+	return computeCheckinStreak(dates, today), nil
+}
+
+// progress 按配置的计算方式返回当前签到进度
+func (p CheckinGame) progress(streak CheckinStreak) int64 {
+	if p.Config.StreakMode == CheckinStreakCumulative {
+		return streak.Cumulative
+	}
+	return streak.Consecutive
+}
+
+// completed 用户是否已经达到领奖天数，连续模式下以历史最长连续天数为准，奖励只发一次
+func (p CheckinGame) completed(streak CheckinStreak) bool {
+	if p.Config.StreakMode == CheckinStreakCumulative {
+		return streak.Cumulative >= p.Config.RequiredDays
+	}
+	return streak.Longest >= p.Config.RequiredDays
 }
 
 // ValidateConfig 验证配置
@@ -85,9 +349,20 @@ func (p CheckinGame) ValidateConfig(ctx context.Context) error {
 	if p.Prize == nil {
 		return fmt.Errorf("prize is not configured")
 	}
+	if err := p.Prize.Validate(); err != nil {
+		return err
+	}
 	if p.Config.RequiredDays <= 0 {
 		return fmt.Errorf("required days must be greater than 0")
 	}
+	switch p.Config.StreakMode {
+	case "", CheckinStreakConsecutive, CheckinStreakCumulative:
+	default:
+		return fmt.Errorf("unsupported streak mode %q", p.Config.StreakMode)
+	}
+	if _, err := p.location(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -131,21 +406,37 @@ func (p *CheckinGame) UnmarshalJSON(data []byte) error {
 
 // CheckinAction 签到动作
 type CheckinAction struct {
-	CheckinTime time.Time `json:"checkin_time"` // 签到时间
-}
-
-func (a CheckinAction) Target(ctx context.Context) string {
-	return "checkin"
+	ActionTarget
+	CheckinTime time.Time `json:"-"` // 签到时间，由服务端填写，客户端不能指定；为空时使用当前时间
 }
 
 // CheckinResult 签到结果
 type CheckinResult struct {
-	GameName     string             `json:"game_name"`
-	CheckinDays  int64              `json:"checkin_days"`
-	RequiredDays int64              `json:"required_days"`
-	Prize        *DiscountCodePrize `json:"prize"`
+	GameName        string             `json:"game_name"`
+	CheckinDate     string             `json:"checkin_date"`     // 本次签到的自然日
+	CheckinDays     int64              `json:"checkin_days"`     // 按配置计算方式得到的当前进度
+	ConsecutiveDays int64              `json:"consecutive_days"` // 当前连续签到天数
+	CumulativeDays  int64              `json:"cumulative_days"`  // 累计签到天数
+	RequiredDays    int64              `json:"required_days"`
+	Completed       bool               `json:"completed"` // 是否已达到领奖天数
+	Prize           *DiscountCodePrize `json:"prize"`     // 本次签到获得的奖品
+	Grant           *PrizeGrant        `json:"grant"`     // 奖品发放记录
+	state           UserState          // 本次签到后的用户状态
 }
 
 func (r CheckinResult) Target(ctx context.Context) string {
 	return r.GameName
 }
+
+// WonPrize 达到领奖天数并发放成功时返回签到奖品
+func (r CheckinResult) WonPrize() (PrizeInterface, *PrizeGrant) {
+	if r.Grant == nil || r.Prize == nil {
+		return nil, nil
+	}
+	return r.Prize, r.Grant
+}
+
+// StateAfter 返回本次签到后的用户状态，达到领奖天数后为CLOSED
+func (r CheckinResult) StateAfter() UserState {
+	return r.state
+}
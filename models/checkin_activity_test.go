@@ -0,0 +1,144 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeCheckins 内存中的签到记录，同一天重复签到返回ErrAlreadyCheckedIn
+type fakeCheckins struct {
+	dates map[string]bool
+}
+
+func (s *fakeCheckins) Checkin(ctx context.Context, activityID int64, gameName, uid, date string) error {
+	if s.dates[date] {
+		return ErrAlreadyCheckedIn
+	}
+	s.dates[date] = true
+	return nil
+}
+
+func (s *fakeCheckins) CheckinDates(ctx context.Context, activityID int64, gameName, uid string) ([]string, error) {
+	dates := make([]string, 0, len(s.dates))
+	for date := range s.dates {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates, nil
+}
+
+func TestComputeCheckinStreak(t *testing.T) {
+	tests := []struct {
+		name  string
+		dates []string
+		today string
+		want  CheckinStreak
+	}{
+		{"未签到", nil, "2024-03-05", CheckinStreak{}},
+		{"今天已签到", []string{"2024-03-03", "2024-03-04", "2024-03-05"}, "2024-03-05", CheckinStreak{Consecutive: 3, Longest: 3, Cumulative: 3, CheckedIn: true}},
+		{"最后一次在昨天，连续未中断", []string{"2024-03-03", "2024-03-04"}, "2024-03-05", CheckinStreak{Consecutive: 2, Longest: 2, Cumulative: 2}},
+		{"最后一次在前天，连续已中断", []string{"2024-03-02", "2024-03-03"}, "2024-03-05", CheckinStreak{Consecutive: 0, Longest: 2, Cumulative: 2}},
+		{"漏签后重新计算", []string{"2024-03-01", "2024-03-02", "2024-03-03", "2024-03-05"}, "2024-03-05", CheckinStreak{Consecutive: 1, Longest: 3, Cumulative: 4, CheckedIn: true}},
+		{"重复日期只计一次", []string{"2024-03-04", "2024-03-04", "2024-03-05"}, "2024-03-05", CheckinStreak{Consecutive: 2, Longest: 2, Cumulative: 2, CheckedIn: true}},
+		{"乱序日期不计入", []string{"2024-03-03", "2024-03-01", "2024-03-04"}, "2024-03-04", CheckinStreak{Consecutive: 2, Longest: 2, Cumulative: 2, CheckedIn: true}},
+		{"无效日期不计入", []string{"2024-03-04", "bad", "2024-03-05"}, "2024-03-05", CheckinStreak{Consecutive: 2, Longest: 2, Cumulative: 2, CheckedIn: true}},
+		{"跨月连续", []string{"2024-02-28", "2024-02-29", "2024-03-01"}, "2024-03-01", CheckinStreak{Consecutive: 3, Longest: 3, Cumulative: 3, CheckedIn: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeCheckinStreak(tt.dates, tt.today); got != tt.want {
+				t.Errorf("computeCheckinStreak() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckinGamePerform(t *testing.T) {
+	ctx := context.Background()
+
+	type step struct {
+		date          string
+		wantDays      int64 // 按计算方式得到的进度
+		wantCompleted bool
+		wantErr       error
+	}
+	tests := []struct {
+		name  string
+		mode  string
+		steps []step
+	}{
+		{"连续签到，漏签后进度清零", CheckinStreakConsecutive, []step{
+			{"2024-03-01", 1, false, nil},
+			{"2024-03-02", 2, false, nil},
+			{"2024-03-04", 1, false, nil},
+			{"2024-03-05", 2, false, nil},
+			{"2024-03-06", 3, true, nil},
+			{"2024-03-07", 0, false, ErrUserCannotParticipate},
+		}},
+		{"累计签到，漏签不影响进度", CheckinStreakCumulative, []step{
+			{"2024-03-01", 1, false, nil},
+			{"2024-03-03", 2, false, nil},
+			{"2024-03-03", 0, false, ErrAlreadyCheckedIn},
+			{"2024-03-07", 3, true, nil},
+		}},
+		{"连续签到达标后中断，按历史最长连续天数视为已完成", CheckinStreakConsecutive, []step{
+			{"2024-03-01", 1, false, nil},
+			{"2024-03-02", 2, false, nil},
+			{"2024-03-03", 3, true, nil},
+			{"2024-03-05", 0, false, ErrUserCannotParticipate},
+		}},
+		{"同一天重复签到", "", []step{
+			{"2024-03-01", 1, false, nil},
+			{"2024-03-01", 0, false, ErrAlreadyCheckedIn},
+			{"2024-03-02", 2, false, nil},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prizes := &fakePrizes{stock: map[string]int64{"每日签到": 10}}
+			game := &CheckinGame{
+				Name_:  "每日签到",
+				State:  GameStateOPEN,
+				Prize:  &DiscountCodePrize{DiscountCode: "CHECKIN", TotalNum: 10},
+				Config: CheckinConfig{RequiredDays: 3, StreakMode: tt.mode, TimeZone: "UTC"},
+			}
+			game.BindRuntime(&Runtime{ActivityID: 1, Checkins: &fakeCheckins{dates: map[string]bool{}}, Prizes: prizes, Tx: &fakeTx{}})
+
+			for _, s := range tt.steps {
+				at, _ := time.Parse(checkinDateLayout, s.date)
+				got, err := game.Perform(ctx, User{Uid: "user-1"}, &CheckinAction{CheckinTime: at.Add(8 * time.Hour)})
+				if s.wantErr != nil {
+					if !errors.Is(err, s.wantErr) {
+						t.Fatalf("%s: Perform() error = %v, want %v", s.date, err, s.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%s: Perform() error = %v", s.date, err)
+				}
+				result := got.(*CheckinResult)
+				if result.CheckinDate != s.date || result.CheckinDays != s.wantDays || result.Completed != s.wantCompleted {
+					t.Errorf("%s: Perform() = %s days %d completed %t, want %s days %d completed %t",
+						s.date, result.CheckinDate, result.CheckinDays, result.Completed, s.date, s.wantDays, s.wantCompleted)
+				}
+				if (result.Grant != nil) != s.wantCompleted {
+					t.Errorf("%s: grant = %+v, want issued only on completion", s.date, result.Grant)
+				}
+			}
+
+			// 奖励只发一次
+			completed := 0
+			for _, s := range tt.steps {
+				if s.wantCompleted {
+					completed++
+				}
+			}
+			if len(prizes.issued) != completed {
+				t.Errorf("issued = %d, want %d", len(prizes.issued), completed)
+			}
+		})
+	}
+}
@@ -3,38 +3,75 @@ package models
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
+	"unicode/utf8"
 )
 
+var (
+	// ErrUserNotPosted 用户没有符合玩法要求的帖子
+	ErrUserNotPosted = errors.New("user has not posted")
+	// ErrPostNotFound 社区服务中不存在该帖子
+	ErrPostNotFound = errors.New("post not found")
+)
+
+// Post 社区帖子
+type Post struct {
+	ID        string    `json:"id"`
+	AuthorID  string    `json:"author_id"` // 发帖用户ID
+	Topic     string    `json:"topic"`     // 帖子所属话题
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CommunityClient 社区服务客户端
+type CommunityClient interface {
+	// GetPost 查询帖子，帖子不存在时返回 ErrPostNotFound
+	GetPost(ctx context.Context, postID string) (*Post, error)
+}
+
 // CommunityActivityFactory 社区活动工厂
 type CommunityActivityFactory struct{}
 
 func (f *CommunityActivityFactory) Create(config ActivityConfigJSON) (ActivityInterface, error) {
 	// 解析玩法配置
-	var games []GameInterface
-	for _, gameConfig := range config.Games {
-		game, err := NewGameFromConfig(gameConfig)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create game: %w", err)
-		}
-		games = append(games, game)
+	games, err := NewGamesFromConfig(config.Games)
+	if err != nil {
+		return nil, err
 	}
 
 	return &CommunityActivity{
 		MetaActivity: MetaActivity{
-			Category: config.Category,
-			Version:  config.Version,
-			StartAt:  config.StartAt,
-			EndAt:    config.EndAt,
-			Status:   1, // 默认上线状态
+			Category:   config.Category,
+			Version:    config.Version,
+			Name:       config.Name,
+			StartAt:    config.StartAt,
+			EndAt:      config.EndAt,
+			Status:     ActivityStatusOnline, // 默认上线状态，存储层以数据库状态回填
+			Enrollment: config.Enrollment,
+			RateLimit:  config.RateLimit,
 		},
 		GameList: games,
 	}, nil
 }
 
-// init 注册活动工厂
+// CommunityPostGameFactory 社区发帖玩法工厂
+type CommunityPostGameFactory struct{}
+
+func (f *CommunityPostGameFactory) Create(config GameConfig) (GameInterface, error) {
+	var game CommunityPostGame
+	if err := json.Unmarshal(config.Config, &game); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal game config: %w", err)
+	}
+	game.Name_ = config.Name
+	return &game, nil
+}
+
+// init 注册活动工厂和玩法工厂
 func init() {
 	RegisterActivityFactory("community", &CommunityActivityFactory{})
+	RegisterGameFactory(GameTypePost, &CommunityPostGameFactory{})
 }
 
 // CommunityActivity 社区活动实现
@@ -52,7 +89,7 @@ func (a *CommunityActivity) Version() string {
 }
 
 func (a *CommunityActivity) Name() string {
-	return a.MetaActivity.ActivityConfig.Activity.Name()
+	return a.MetaActivity.Name
 }
 
 func (a *CommunityActivity) Games() []GameInterface {
@@ -71,11 +108,14 @@ func (a *CommunityActivity) Status() int64 {
 	return a.MetaActivity.Status
 }
 
-// CommunityPostGame 社区发帖玩法
+// CommunityPostGame 社区发帖玩法，用户提交活动期间发布的帖子领取奖励
 type CommunityPostGame struct {
-	Name_ string             `json:"-"` // 玩法名称，从GameConfig中获取
-	Prize *DiscountCodePrize `json:"prize"`
-	State GameState          `json:"state"`
+	Name_     string             `json:"-"` // 玩法名称，从GameConfig中获取
+	Prize     *DiscountCodePrize `json:"prize"`
+	State     GameState          `json:"state"`
+	Topic     string             `json:"topic,omitempty"`      // 帖子需要属于的话题，为空时不限
+	MinLength int                `json:"min_length,omitempty"` // 帖子内容的最少字数，0 表示不限
+	runtime   *Runtime           // 运行时依赖，由BindRuntime注入
 }
 
 // Name 返回玩法名称
@@ -83,52 +123,115 @@ func (p CommunityPostGame) Name(ctx context.Context) string {
 	return p.Name_
 }
 
+// Type 返回玩法类型
+func (p CommunityPostGame) Type(ctx context.Context) string {
+	return GameTypePost
+}
+
+// BindRuntime 注入运行时依赖
+func (p *CommunityPostGame) BindRuntime(rt *Runtime) {
+	p.runtime = rt
+	if p.Prize != nil {
+		p.Prize.Bind(rt, p.Name_, p.Name_)
+	}
+}
+
 // Perform 执行玩法逻辑
 func (p CommunityPostGame) Perform(ctx context.Context, user User, action ActionInterface) (ResultInterface, error) {
 	// 1. 检查玩法状态
 	if p.GameState(ctx) != GameStateOPEN {
-		return nil, fmt.Errorf("game is not open")
+		return nil, ErrGameNotOpen
 	}
 
 	// 2. 检查用户状态
-	if p.UserState(ctx) != UserStateOPEN {
-		return nil, fmt.Errorf("user cannot participate")
+	if !CanParticipate(p.UserState(ctx, user)) {
+		return nil, ErrUserCannotParticipate
 	}
 
-	// 3. 验证用户是否已发帖
-	// TODO: 调用社区服务检查用户是否已发帖
-	// checkUserPost(ctx, user.Uid)
+	// 3. 验证用户提交的帖子
+	if err := p.verifyPost(ctx, user, action); err != nil {
+		return nil, err
+	}
 
 	// 4. 发放折扣码奖励
+	var grant *PrizeGrant
 	if p.Prize != nil {
-		err := p.Prize.WinPrize(ctx, user)
+		var err error
+		grant, err = p.Prize.WinPrize(ctx, user)
 		if err != nil {
 			return nil, fmt.Errorf("failed to give prize: %w", err)
 		}
 	}
 
-	// 5. 记录用户参与状态
-	// TODO: 更新用户参与记录
-	// updateUserGameRecord(ctx, user.Uid, UserStateCLOSED)
+	// 5. 参与记录由应用层在Perform成功后写入，之后用户状态变为CLOSED
 
 	return &CommunityPostResult{
 		GameName: p.Name_,
 		Prize:    p.Prize,
+		Grant:    grant,
 	}, nil
 }
 
+// verifyPost 通过社区服务验证帖子存在、由当前用户在活动期间发布，并满足话题和字数要求
+func (p CommunityPostGame) verifyPost(ctx context.Context, user User, action ActionInterface) error {
+	var postID string
+	switch a := action.(type) {
+	case *CommunityPostAction:
+		postID = a.PostID
+	case CommunityPostAction:
+		postID = a.PostID
+	}
+	if postID == "" {
+		return fmt.Errorf("%w: post id is required", ErrUserNotPosted)
+	}
+	if p.runtime == nil || p.runtime.Community == nil {
+		return fmt.Errorf("%w: community client", ErrRuntimeNotConfigured)
+	}
+
+	post, err := p.runtime.Community.GetPost(ctx, postID)
+	if errors.Is(err, ErrPostNotFound) {
+		return fmt.Errorf("%w: post %s not found", ErrUserNotPosted, postID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get post %s: %w", postID, err)
+	}
+
+	switch {
+	case post.AuthorID != user.Uid:
+		return fmt.Errorf("%w: post %s is not written by the user", ErrUserNotPosted, postID)
+	case post.CreatedAt.Unix() < p.runtime.StartAt || (p.runtime.EndAt > 0 && post.CreatedAt.Unix() > p.runtime.EndAt):
+		return fmt.Errorf("%w: post %s is not created during the activity", ErrUserNotPosted, postID)
+	case p.Topic != "" && post.Topic != p.Topic:
+		return fmt.Errorf("%w: post %s is not in topic %s", ErrUserNotPosted, postID, p.Topic)
+	case utf8.RuneCountInString(post.Content) < p.MinLength:
+		return fmt.Errorf("%w: post %s is shorter than %d characters", ErrUserNotPosted, postID, p.MinLength)
+	}
+	return nil
+}
+
+// Prizes 返回玩法的奖品，库存标识为玩法名称
+func (p CommunityPostGame) Prizes(ctx context.Context) map[string]PrizeInterface {
+	if p.Prize == nil {
+		return nil
+	}
+	return map[string]PrizeInterface{p.Name_: p.Prize}
+}
+
 // GameState 返回玩法状态
 func (p CommunityPostGame) GameState(ctx context.Context) GameState {
 	return p.State
 }
 
-// UserState 返回用户参与状态
-func (p CommunityPostGame) UserState(ctx context.Context) UserState {
-	// TODO:
-	// 1. 检查用户是否已经参与过
-	// 2. 检查用户是否已经获得奖励
-	// 3. 返回对应状态
-	return UserStateOPEN
+// UserState 返回用户参与状态，发帖奖励每个用户只能领取一次
+func (p CommunityPostGame) UserState(ctx context.Context, user User) UserState {
+	count, err := p.runtime.countParticipations(ctx, p.Name_, user)
+	if err != nil {
+		return UserStateUNKNOWN
+	}
+	if count == 0 {
+		return UserStatePENDING
+	}
+	return UserStateCLOSED
 }
 
 // ValidateConfig 验证配置
@@ -136,6 +239,12 @@ func (p CommunityPostGame) ValidateConfig(ctx context.Context) error {
 	if p.Prize == nil {
 		return fmt.Errorf("prize is not configured")
 	}
+	if err := p.Prize.Validate(); err != nil {
+		return err
+	}
+	if p.MinLength < 0 {
+		return fmt.Errorf("min length must not be negative")
+	}
 	return nil
 }
 
@@ -179,19 +288,30 @@ func (p *CommunityPostGame) UnmarshalJSON(data []byte) error {
 
 // CommunityPostAction 发帖动作
 type CommunityPostAction struct {
+	ActionTarget
 	PostID string `json:"post_id"` // 用户发帖ID
 }
 
-func (a CommunityPostAction) Target(ctx context.Context) string {
-	return "community_post"
-}
-
 // CommunityPostResult 发帖结果
 type CommunityPostResult struct {
 	GameName string             `json:"game_name"`
 	Prize    *DiscountCodePrize `json:"prize"`
+	Grant    *PrizeGrant        `json:"grant"` // 奖品发放记录
 }
 
 func (r CommunityPostResult) Target(ctx context.Context) string {
 	return r.GameName
 }
+
+// WonPrize 发放成功时返回发帖奖品
+func (r CommunityPostResult) WonPrize() (PrizeInterface, *PrizeGrant) {
+	if r.Grant == nil || r.Prize == nil {
+		return nil, nil
+	}
+	return r.Prize, r.Grant
+}
+
+// StateAfter 发帖奖励每个用户只能领取一次，参与后用户状态为CLOSED
+func (r CommunityPostResult) StateAfter() UserState {
+	return UserStateCLOSED
+}
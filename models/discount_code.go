@@ -2,34 +2,101 @@ package models
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 // 折扣码的奖品
 
-// DiscountCodePrize 折扣码奖品
+// ErrDiscountCodeUnavailable 折扣码不存在、未发放给该用户或已失效
+var ErrDiscountCodeUnavailable = errors.New("discount code is unavailable")
+
+// discountCodeAlphabet 生成折扣码使用的字符，去掉了容易混淆的 0/O/1/I
+const discountCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// discountCodeSuffixLen 生成折扣码的随机后缀长度
+const discountCodeSuffixLen = 10
+
+// DiscountCodeMaxLen 折扣码的最大长度，与discount_codes.code和prize_records.prize_id的列宽一致
+const DiscountCodeMaxLen = 50
+
+// DiscountCodePrize 折扣码奖品，中奖时从折扣码池中分配一个未使用的码
 type DiscountCodePrize struct {
-	DiscountCode string `json:"discount_code"` // 折扣码前缀
-	PriceRuleID  int64  `json:"price_rule_id"` // 价格规则ID
-	Probability  int64  `json:"probability"`   // 中奖概率
-	TotalNum     int64  `json:"total_num"`     // 总数量
-	RemainNum    int64  `json:"remain_num"`    // 剩余数量
+	DiscountCode string       `json:"discount_code"` // 折扣码前缀
+	PriceRuleID  int64        `json:"price_rule_id"` // 价格规则ID
+	Probability  int64        `json:"probability"`   // 中奖概率
+	TotalNum     int64        `json:"total_num"`     // 总数量，剩余数量以库存表为准
+	binding      prizeBinding // 库存绑定，由BindRuntime注入
 }
 
-func (p DiscountCodePrize) WinPrize(ctx context.Context, user User) error {
-	// 1. 检查库存
-	if p.RemainNum <= 0 {
-		return fmt.Errorf("prize stock is empty")
+// Validate 验证奖品配置，前缀加上生成的随机后缀不能超过折扣码的最大长度
+func (p DiscountCodePrize) Validate() error {
+	if p.TotalNum <= 0 {
+		return fmt.Errorf("prize total num must be greater than 0")
+	}
+	if len(p.DiscountCode)+discountCodeSuffixLen > DiscountCodeMaxLen {
+		return fmt.Errorf("discount code prefix must not be longer than %d characters", DiscountCodeMaxLen-discountCodeSuffixLen)
 	}
+	return nil
+}
 
-	// 2. 找到一个空的，直接分配给用户
-	// TODO: 实现折扣码分配逻辑
+// Bind 绑定奖品库存，key 在同一活动内唯一
+func (p *DiscountCodePrize) Bind(rt *Runtime, gameName, key string) {
+	p.binding = newPrizeBinding(rt, gameName, key)
+}
 
-	// 3. 更新库存
-	p.RemainNum--
-	return nil
+func (p DiscountCodePrize) WinPrize(ctx context.Context, user User) (*PrizeGrant, error) {
+	// 扣减库存并从码池中分配一个码给用户，二者在同一事务中完成
+	return p.binding.issue(ctx, user, PrizeIssue{
+		PrizeType:    PrizeTypeDiscountCode,
+		TotalNum:     p.TotalNum,
+		AllocateCode: true,
+	})
+}
+
+func (p DiscountCodePrize) StockTotal() int64 {
+	return p.TotalNum
+}
+
+func (p *DiscountCodePrize) setStockTotal(n int64) {
+	p.TotalNum = n
 }
 
 func (p DiscountCodePrize) WinProbability() int64 {
 	return p.Probability
 }
+
+// Detail 折扣码取自发放记录
+func (p DiscountCodePrize) Detail(grant *PrizeGrant) PrizeDetail {
+	detail := PrizeDetail{Type: PrizeTypeDiscountCode, PriceRuleID: p.PriceRuleID}
+	if grant != nil {
+		detail.DiscountCode = grant.PrizeID
+	}
+	return detail
+}
+
+// RemainNum 返回剩余库存
+func (p DiscountCodePrize) RemainNum(ctx context.Context) (int64, error) {
+	return p.binding.remain(ctx, p.TotalNum)
+}
+
+// GenerateDiscountCodes 按前缀生成 n 个随机折扣码
+func GenerateDiscountCodes(prefix string, n int) ([]string, error) {
+	codes := make([]string, 0, n)
+	buf := make([]byte, discountCodeSuffixLen)
+	for i := 0; i < n; i++ {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to generate discount code: %w", err)
+		}
+		var sb strings.Builder
+		sb.Grow(len(prefix) + discountCodeSuffixLen)
+		sb.WriteString(prefix)
+		for _, b := range buf {
+			sb.WriteByte(discountCodeAlphabet[int(b)%len(discountCodeAlphabet)])
+		}
+		codes = append(codes, sb.String())
+	}
+	return codes, nil
+}
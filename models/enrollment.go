@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrActivityFull 活动报名人数已达上限
+	ErrActivityFull = errors.New("activity participant limit reached")
+	// ErrNotEligible 用户不满足活动的报名条件
+	ErrNotEligible = errors.New("user is not eligible for the activity")
+	// ErrNotEnrolled 活动要求先报名，用户尚未报名
+	ErrNotEnrolled = errors.New("user has not enrolled in the activity")
+)
+
+// EnrollmentConfig 活动报名配置，未配置时任何用户都可以直接参与玩法
+type EnrollmentConfig struct {
+	Required        bool     `json:"required"`                // 为true时必须先报名才能参与玩法
+	MaxParticipants int64    `json:"max_participants"`        // 报名人数上限，0 表示不限
+	AllowedUsers    []string `json:"allowed_users,omitempty"` // 允许报名的用户ID，为空表示不限
+}
+
+// Validate 校验报名配置
+func (e *EnrollmentConfig) Validate() error {
+	if e.MaxParticipants < 0 {
+		return fmt.Errorf("max participants must not be negative")
+	}
+	return nil
+}
+
+// Eligible 判断用户是否满足报名条件
+func (e *EnrollmentConfig) Eligible(user User) bool {
+	if e == nil || len(e.AllowedUsers) == 0 {
+		return true
+	}
+	for _, uid := range e.AllowedUsers {
+		if uid == user.Uid {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,25 @@
 package models
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	// ErrGameNotFound 活动中不存在指定名称的玩法
+	ErrGameNotFound = errors.New("game not found")
+	// ErrGameNotOpen 玩法未开放
+	ErrGameNotOpen = errors.New("game is not open")
+	// ErrUserCannotParticipate 用户已参与或已达到参与上限
+	ErrUserCannotParticipate = errors.New("user cannot participate")
+	// ErrRuntimeNotConfigured 玩法运行时依赖未注入
+	ErrRuntimeNotConfigured = errors.New("game runtime is not configured")
+	// ErrInvalidAction 请求的动作不是玩法支持的动作
+	ErrInvalidAction = errors.New("invalid game action")
 )
 
 // GameInterface 是对目前Shopping项目中所有玩法的公共抽象
@@ -13,11 +30,12 @@ type GameInterface interface {
 	// ValidateConfig 校验配置是否合法, ctx 用于传递活动和玩法的上下文信息
 	ValidateConfig(ctx context.Context) error
 
-	Name(ctx context.Context) string               // 游戏的名称，在同一个活动中，游戏名称必须保证唯一
-	Actions(ctx context.Context) []ActionInterface // 游戏支持哪些请求
-	Results(ctx context.Context) []ResultInterface // 游戏支持哪些响应
-	GameState(ctx context.Context) GameState       // 玩法状态
-	UserState(ctx context.Context) UserState       // 用户参与结果
+	Name(ctx context.Context) string                    // 游戏的名称，在同一个活动中，游戏名称必须保证唯一
+	Type(ctx context.Context) string                    // 游戏的类型，与配置中的type一致
+	Actions(ctx context.Context) []ActionInterface      // 游戏支持哪些请求
+	Results(ctx context.Context) []ResultInterface      // 游戏支持哪些响应
+	GameState(ctx context.Context) GameState            // 玩法状态
+	UserState(ctx context.Context, user User) UserState // 用户参与结果，由参与记录等存储计算
 
 	json.Unmarshaler // 非业务功能
 	json.Marshaler   // 非业务功能
@@ -30,3 +48,122 @@ const (
 	GameStateOPEN    GameState = "OPEN"    // 还可以继续参加
 	GameStateCLOSED  GameState = "CLOSED"  // 玩法关闭
 )
+
+// 内置玩法类型
+const (
+	GameTypePost    = "post"    // 社区发帖
+	GameTypeCheckin = "checkin" // 签到
+	GameTypeLottery = "lottery" // 抽奖
+)
+
+// StockExhausted 玩法中配置了库存的奖品是否已全部发完，没有库存奖品的玩法始终返回false
+func StockExhausted(ctx context.Context, game GameInterface) (bool, error) {
+	prizes := stockPrizes(ctx, game)
+	if len(prizes) == 0 {
+		return false, nil
+	}
+	for _, prize := range prizes {
+		remain, err := prize.RemainNum(ctx)
+		if err != nil {
+			return false, err
+		}
+		if remain > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// SetGameConfigState 修改玩法配置中的玩法状态，其他配置保持原样
+func SetGameConfigState(config GameConfig, state GameState) (GameConfig, error) {
+	fields := make(map[string]json.RawMessage)
+	if len(config.Config) > 0 {
+		if err := json.Unmarshal(config.Config, &fields); err != nil {
+			return config, fmt.Errorf("failed to unmarshal game %q config: %w", config.Name, err)
+		}
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return config, err
+	}
+	fields["state"] = raw
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return config, fmt.Errorf("failed to marshal game %q config: %w", config.Name, err)
+	}
+	config.Config = data
+	return config, nil
+}
+
+// GameFinder 可以按名称直接查找玩法的活动，活动缓存为玩法建立了索引
+type GameFinder interface {
+	Game(ctx context.Context, name string) (GameInterface, bool)
+}
+
+// FindGame 按名称查找活动下的玩法，活动实现了GameFinder时使用索引，否则顺序查找
+func FindGame(ctx context.Context, activity ActivityInterface, name string) (GameInterface, bool) {
+	if finder, ok := activity.(GameFinder); ok {
+		return finder.Game(ctx, name)
+	}
+	for _, game := range activity.Games() {
+		if game.Name(ctx) == name {
+			return game, true
+		}
+	}
+	return nil, false
+}
+
+// ActionTarget 动作指定的玩法，嵌入到各玩法的动作中实现ActionInterface
+type ActionTarget struct {
+	GameName string `json:"game_name,omitempty"` // 动作指定的玩法名称，客户端未指定时由DecodeAction填写为当前玩法
+}
+
+// Target 返回动作指定的玩法名称
+func (a ActionTarget) Target(ctx context.Context) string {
+	return a.GameName
+}
+
+// setTarget 填写动作指定的玩法
+func (a *ActionTarget) setTarget(name string) {
+	a.GameName = name
+}
+
+// DecodeAction 按玩法Actions声明的动作类型解析请求中的动作，依次尝试每种类型，返回第一个字段完全匹配的动作
+// raw为空时按空对象解析；动作中不允许出现声明之外的字段，服务端填写的字段不能由客户端指定
+// 动作未指定玩法时填写为game，指定了其他玩法时返回ErrInvalidAction
+func DecodeAction(ctx context.Context, game GameInterface, raw json.RawMessage) (ActionInterface, error) {
+	if len(bytes.TrimSpace(raw)) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		raw = json.RawMessage("{}")
+	}
+
+	var lastErr error
+	for _, declared := range game.Actions(ctx) {
+		typ := reflect.TypeOf(declared)
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		action, ok := reflect.New(typ).Interface().(ActionInterface)
+		if !ok {
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(action); err != nil {
+			lastErr = err
+			continue
+		}
+		if target, ok := action.(interface{ setTarget(string) }); ok && action.Target(ctx) == "" {
+			target.setTarget(game.Name(ctx))
+		}
+		if target := action.Target(ctx); target != game.Name(ctx) {
+			return nil, fmt.Errorf("%w: action targets game %s, not %s", ErrInvalidAction, target, game.Name(ctx))
+		}
+		return action, nil
+	}
+	if lastErr == nil {
+		return nil, fmt.Errorf("%w: game %s declares no action", ErrInvalidAction, game.Name(ctx))
+	}
+	return nil, fmt.Errorf("%w: %v", ErrInvalidAction, lastErr)
+}
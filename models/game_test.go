@@ -0,0 +1,72 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecodeAction(t *testing.T) {
+	ctx := context.Background()
+	post := &CommunityPostGame{Name_: "发帖奖励"}
+	checkin := &CheckinGame{Name_: "每日签到"}
+	lottery := &LotteryGame{Name_: "幸运抽奖"}
+
+	action, err := DecodeAction(ctx, post, json.RawMessage(`{"post_id":"p1"}`))
+	if err != nil {
+		t.Fatalf("DecodeAction(post) error = %v", err)
+	}
+	if got, ok := action.(*CommunityPostAction); !ok || got.PostID != "p1" {
+		t.Errorf("DecodeAction(post) = %#v, want post_id p1", action)
+	}
+
+	// 不需要参数的动作可以不传
+	for _, raw := range []string{"", "null", "{}"} {
+		action, err := DecodeAction(ctx, lottery, json.RawMessage(raw))
+		if err != nil {
+			t.Fatalf("DecodeAction(lottery, %q) error = %v", raw, err)
+		}
+		if _, ok := action.(*LotteryAction); !ok {
+			t.Errorf("DecodeAction(lottery, %q) = %T, want *LotteryAction", raw, action)
+		}
+	}
+
+	// 动作指定的玩法必须是当前玩法，未指定时填写为当前玩法
+	action, err = DecodeAction(ctx, post, json.RawMessage(`{"post_id":"p1","game_name":"发帖奖励"}`))
+	if err != nil || action.Target(ctx) != "发帖奖励" {
+		t.Errorf("DecodeAction(post, same game) = %v, %v, want target 发帖奖励", action, err)
+	}
+	if action, err := DecodeAction(ctx, checkin, nil); err != nil || action.Target(ctx) != "每日签到" {
+		t.Errorf("DecodeAction(checkin) = %v, %v, want target 每日签到", action, err)
+	}
+	mismatched := []struct {
+		game GameInterface
+		raw  string
+	}{
+		{post, `{"post_id":"p1","game_name":"每日签到"}`},
+		{lottery, `{"game_name":"超级抽奖"}`},
+		{checkin, `{"game_name":"幸运抽奖"}`},
+	}
+	for _, tc := range mismatched {
+		if _, err := DecodeAction(ctx, tc.game, json.RawMessage(tc.raw)); !errors.Is(err, ErrInvalidAction) {
+			t.Errorf("DecodeAction(%s, %s) error = %v, want ErrInvalidAction", tc.game.Name(ctx), tc.raw, err)
+		}
+	}
+
+	// 声明之外的字段和服务端填写的字段被拒绝
+	invalid := []struct {
+		game GameInterface
+		raw  string
+	}{
+		{post, `{"post_id":1}`},
+		{post, `{"post_id":"p1","extra":true}`},
+		{checkin, `{"checkin_time":"2024-01-01T00:00:00Z"}`},
+		{lottery, `[]`},
+	}
+	for _, tc := range invalid {
+		if _, err := DecodeAction(ctx, tc.game, json.RawMessage(tc.raw)); !errors.Is(err, ErrInvalidAction) {
+			t.Errorf("DecodeAction(%s, %s) error = %v, want ErrInvalidAction", tc.game.Name(ctx), tc.raw, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 活动生命周期：草稿 → 审核中 → 已排期 → 进行中 ⇄ 已暂停 → 已结束 → 已归档
+// 0和1沿用原有草稿、上线的取值，已有数据无需迁移
+const (
+	ActivityStatusDraft     int64 = 0 // 草稿
+	ActivityStatusOnline    int64 = 1 // 进行中
+	ActivityStatusReview    int64 = 2 // 审核中
+	ActivityStatusScheduled int64 = 3 // 已排期，等待上线
+	ActivityStatusPaused    int64 = 4 // 已暂停
+	ActivityStatusEnded     int64 = 5 // 已结束
+	ActivityStatusArchived  int64 = 6 // 已归档
+)
+
+// activityStatusNames 活动状态名称，用于接口响应和状态变更记录
+var activityStatusNames = map[int64]string{
+	ActivityStatusDraft:     "draft",
+	ActivityStatusReview:    "review",
+	ActivityStatusScheduled: "scheduled",
+	ActivityStatusOnline:    "online",
+	ActivityStatusPaused:    "paused",
+	ActivityStatusEnded:     "ended",
+	ActivityStatusArchived:  "archived",
+}
+
+// ActivityEvent 触发活动状态变更的操作
+type ActivityEvent = string
+
+const (
+	ActivityEventSubmit  ActivityEvent = "submit"  // 提交审核
+	ActivityEventReject  ActivityEvent = "reject"  // 审核驳回，退回草稿
+	ActivityEventApprove ActivityEvent = "approve" // 审核通过，等待上线
+	ActivityEventPublish ActivityEvent = "publish" // 上线
+	ActivityEventPause   ActivityEvent = "pause"   // 暂停，立即停止参与
+	ActivityEventResume  ActivityEvent = "resume"  // 恢复
+	ActivityEventEnd     ActivityEvent = "end"     // 结束
+	ActivityEventArchive ActivityEvent = "archive" // 归档
+)
+
+var (
+	// ErrActivityNotPublished 活动未发布
+	ErrActivityNotPublished = errors.New("activity not published")
+	// ErrActivityPaused 活动已暂停
+	ErrActivityPaused = errors.New("activity paused")
+	// ErrInvalidTransition 当前状态不允许该操作
+	ErrInvalidTransition = errors.New("invalid activity status transition")
+)
+
+// activityTransition 一次合法的状态变更
+type activityTransition struct {
+	from []int64
+	to   int64
+}
+
+// activityTransitions 各操作允许的起始状态和目标状态
+var activityTransitions = map[ActivityEvent]activityTransition{
+	ActivityEventSubmit:  {from: []int64{ActivityStatusDraft}, to: ActivityStatusReview},
+	ActivityEventReject:  {from: []int64{ActivityStatusReview}, to: ActivityStatusDraft},
+	ActivityEventApprove: {from: []int64{ActivityStatusReview}, to: ActivityStatusScheduled},
+	ActivityEventPublish: {from: []int64{ActivityStatusScheduled}, to: ActivityStatusOnline},
+	ActivityEventPause:   {from: []int64{ActivityStatusOnline}, to: ActivityStatusPaused},
+	ActivityEventResume:  {from: []int64{ActivityStatusPaused}, to: ActivityStatusOnline},
+	ActivityEventEnd:     {from: []int64{ActivityStatusOnline, ActivityStatusPaused}, to: ActivityStatusEnded},
+	ActivityEventArchive: {from: []int64{ActivityStatusEnded}, to: ActivityStatusArchived},
+}
+
+// ActivityStatusName 返回活动状态名称，未知状态返回unknown
+func ActivityStatusName(status int64) string {
+	if name, ok := activityStatusNames[status]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// NextActivityStatus 返回活动在当前状态下执行操作后的状态，不允许的操作返回ErrInvalidTransition
+func NextActivityStatus(from int64, event ActivityEvent) (int64, error) {
+	transition, ok := activityTransitions[event]
+	if !ok {
+		return from, fmt.Errorf("%w: unknown event %q", ErrInvalidTransition, event)
+	}
+	for _, status := range transition.from {
+		if status == from {
+			return transition.to, nil
+		}
+	}
+	return from, fmt.Errorf("%w: cannot %s from %s", ErrInvalidTransition, event, ActivityStatusName(from))
+}
+
+// IsActivityPublished 活动是否已经发布，发布后的活动只允许安全的修改
+func IsActivityPublished(status int64) bool {
+	switch status {
+	case ActivityStatusOnline, ActivityStatusPaused, ActivityStatusEnded, ActivityStatusArchived:
+		return true
+	}
+	return false
+}
+
+// CheckActivityOpen 检查活动在当前时间是否可以参与，各状态返回对应的错误
+func CheckActivityOpen(status, startAt, endAt, now int64) error {
+	switch status {
+	case ActivityStatusOnline:
+		if now < startAt {
+			return ErrActivityNotStarted
+		}
+		if now > endAt {
+			return ErrActivityEnded
+		}
+		return nil
+	case ActivityStatusScheduled:
+		return ErrActivityNotStarted
+	case ActivityStatusPaused:
+		return ErrActivityPaused
+	case ActivityStatusEnded, ActivityStatusArchived:
+		return ErrActivityEnded
+	default:
+		return ErrActivityNotPublished
+	}
+}
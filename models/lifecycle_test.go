@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNextActivityStatus(t *testing.T) {
+	tests := []struct {
+		from  int64
+		event ActivityEvent
+		want  int64
+	}{
+		{ActivityStatusDraft, ActivityEventSubmit, ActivityStatusReview},
+		{ActivityStatusReview, ActivityEventReject, ActivityStatusDraft},
+		{ActivityStatusReview, ActivityEventApprove, ActivityStatusScheduled},
+		{ActivityStatusScheduled, ActivityEventPublish, ActivityStatusOnline},
+		{ActivityStatusOnline, ActivityEventPause, ActivityStatusPaused},
+		{ActivityStatusPaused, ActivityEventResume, ActivityStatusOnline},
+		{ActivityStatusOnline, ActivityEventEnd, ActivityStatusEnded},
+		{ActivityStatusPaused, ActivityEventEnd, ActivityStatusEnded},
+		{ActivityStatusEnded, ActivityEventArchive, ActivityStatusArchived},
+	}
+	legal := make(map[int64]map[ActivityEvent]bool)
+	for _, tt := range tests {
+		got, err := NextActivityStatus(tt.from, tt.event)
+		if err != nil || got != tt.want {
+			t.Errorf("NextActivityStatus(%s, %s) = %s, %v, want %s", ActivityStatusName(tt.from), tt.event, ActivityStatusName(got), err, ActivityStatusName(tt.want))
+		}
+		if legal[tt.from] == nil {
+			legal[tt.from] = make(map[ActivityEvent]bool)
+		}
+		legal[tt.from][tt.event] = true
+	}
+
+	// 上表之外的组合都不允许，状态保持不变
+	for from := range activityStatusNames {
+		for event := range activityTransitions {
+			if legal[from][event] {
+				continue
+			}
+			got, err := NextActivityStatus(from, event)
+			if !errors.Is(err, ErrInvalidTransition) || got != from {
+				t.Errorf("NextActivityStatus(%s, %s) = %s, %v, want ErrInvalidTransition", ActivityStatusName(from), event, ActivityStatusName(got), err)
+			}
+		}
+	}
+
+	if got, err := NextActivityStatus(ActivityStatusDraft, "delete"); !errors.Is(err, ErrInvalidTransition) || got != ActivityStatusDraft {
+		t.Errorf("NextActivityStatus(unknown event) = %d, %v, want ErrInvalidTransition", got, err)
+	}
+}
@@ -0,0 +1,396 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// 抽奖奖池的槽位类型
+const (
+	LotterySlotDiscountCode = "discount_code" // 折扣码
+	LotterySlotProduct      = "product"       // 商品
+	LotterySlotThanks       = "thanks"        // 谢谢参与
+)
+
+// RandSource 抽奖使用的随机数源，测试中可以注入固定序列
+type RandSource interface {
+	Int63n(n int64) int64 // 返回 [0, n) 内的随机数
+}
+
+// globalRand 默认随机数源，math/rand 的全局函数是并发安全的
+type globalRand struct{}
+
+func (globalRand) Int63n(n int64) int64 {
+	return rand.Int63n(n)
+}
+
+// DrawStore 抽奖记录存储，每次抽奖的结果都会落库用于审计
+type DrawStore interface {
+	RecordDraw(ctx context.Context, record DrawRecord) error
+}
+
+// DrawRecord 抽奖记录
+type DrawRecord struct {
+	ActivityID    int64  // 活动ID
+	GameName      string // 玩法名称
+	UserID        string // 用户ID
+	SlotIndex     int    // 最终命中的槽位，奖品全部无库存时为 -1
+	SlotType      string // 最终命中的槽位类型
+	PrizeKey      string // 奖品库存标识
+	PrizeID       string // 发放的奖品标识
+	FallbackSlots []int  // 因无库存被跳过的槽位
+}
+
+// LotteryGameFactory 抽奖玩法工厂
+type LotteryGameFactory struct{}
+
+func (f *LotteryGameFactory) Create(config GameConfig) (GameInterface, error) {
+	var game LotteryGame
+	if err := json.Unmarshal(config.Config, &game); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal game config: %w", err)
+	}
+	game.Name_ = config.Name
+	return &game, nil
+}
+
+// init 注册玩法工厂
+func init() {
+	RegisterGameFactory(GameTypeLottery, &LotteryGameFactory{})
+}
+
+// LotterySlot 奖池槽位，奖品槽位的权重取奖品的中奖概率
+type LotterySlot struct {
+	Type         string             `json:"type"`                    // 槽位类型
+	Name         string             `json:"name"`                    // 槽位展示名称
+	DiscountCode *DiscountCodePrize `json:"discount_code,omitempty"` // 折扣码奖品
+	Product      *ProductPrize      `json:"product,omitempty"`       // 商品奖品
+	Probability  int64              `json:"probability"`             // 谢谢参与槽位的权重
+}
+
+// prize 返回槽位的奖品，谢谢参与槽位返回 nil
+func (s LotterySlot) prize() PrizeInterface {
+	switch s.Type {
+	case LotterySlotDiscountCode:
+		if s.DiscountCode != nil {
+			return s.DiscountCode
+		}
+	case LotterySlotProduct:
+		if s.Product != nil {
+			return s.Product
+		}
+	}
+	return nil
+}
+
+// weight 返回槽位权重
+func (s LotterySlot) weight() int64 {
+	if prize := s.prize(); prize != nil {
+		return prize.WinProbability()
+	}
+	return s.Probability
+}
+
+// LotteryGame 抽奖玩法，按权重从奖池中抽取一个槽位
+type LotteryGame struct {
+	Name_    string        `json:"-"`
+	State    GameState     `json:"state"`
+	Slots    []LotterySlot `json:"slots"`
+	MaxDraws int64         `json:"max_draws"` // 每个用户的抽奖次数上限，0 表示不限
+	runtime  *Runtime      // 运行时依赖，由BindRuntime注入
+}
+
+// Name 返回玩法名称
+func (p LotteryGame) Name(ctx context.Context) string {
+	return p.Name_
+}
+
+// Type 返回玩法类型
+func (p LotteryGame) Type(ctx context.Context) string {
+	return GameTypeLottery
+}
+
+// BindRuntime 注入运行时依赖
+func (p *LotteryGame) BindRuntime(rt *Runtime) {
+	p.runtime = rt
+	for i := range p.Slots {
+		key := p.prizeKey(i)
+		switch {
+		case p.Slots[i].DiscountCode != nil:
+			p.Slots[i].DiscountCode.Bind(rt, p.Name_, key)
+		case p.Slots[i].Product != nil:
+			p.Slots[i].Product.Bind(rt, p.Name_, key)
+		}
+	}
+}
+
+// prizeKey 返回槽位的奖品库存标识
+func (p LotteryGame) prizeKey(slot int) string {
+	return fmt.Sprintf("%s#%d", p.Name_, slot)
+}
+
+// Perform 执行抽奖，命中的奖品无库存时从剩余槽位中重新抽取
+func (p LotteryGame) Perform(ctx context.Context, user User, action ActionInterface) (ResultInterface, error) {
+	// 1. 检查玩法状态
+	if p.GameState(ctx) != GameStateOPEN {
+		return nil, ErrGameNotOpen
+	}
+	if p.runtime == nil || p.runtime.Draws == nil {
+		return nil, fmt.Errorf("%w: draw store", ErrRuntimeNotConfigured)
+	}
+	count, err := p.runtime.countParticipations(ctx, p.Name_, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count draws: %w", err)
+	}
+	if !CanParticipate(p.userState(count)) {
+		return nil, ErrUserCannotParticipate
+	}
+
+	// 2. 按权重抽取并记录抽奖结果，发奖和抽奖记录在同一事务中提交，记录写入失败时奖品一并回滚
+	result := &LotteryResult{
+		GameName:  p.Name_,
+		SlotIndex: -1,
+		SlotType:  LotterySlotThanks,
+		state:     p.userState(count + 1),
+	}
+	err = p.runtime.transaction(ctx, func(ctx context.Context) error {
+		if err := p.draw(ctx, user, result); err != nil {
+			return err
+		}
+		if err := p.recordDraw(ctx, user, result); err != nil {
+			return fmt.Errorf("failed to record draw: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// draw 按权重抽取槽位并发放奖品，奖品无库存时剔除该槽位后重抽，结果写入result
+func (p LotteryGame) draw(ctx context.Context, user User, result *LotteryResult) error {
+	candidates := make([]int, 0, len(p.Slots))
+	for i, slot := range p.Slots {
+		if slot.weight() > 0 {
+			candidates = append(candidates, i)
+		}
+	}
+
+	for len(candidates) > 0 {
+		pos := p.pick(candidates)
+		index := candidates[pos]
+		slot := p.Slots[index]
+
+		prize := slot.prize()
+		if prize == nil {
+			result.SlotIndex, result.SlotType, result.SlotName = index, slot.Type, slot.Name
+			return nil
+		}
+		grant, err := prize.WinPrize(ctx, user)
+		if errors.Is(err, ErrPrizeStockEmpty) {
+			result.FallbackSlots = append(result.FallbackSlots, index)
+			candidates = append(candidates[:pos], candidates[pos+1:]...)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to give prize: %w", err)
+		}
+		result.SlotIndex, result.SlotType, result.SlotName = index, slot.Type, slot.Name
+		result.Won = true
+		result.Grant = grant
+		result.prize = prize
+		return nil
+	}
+	return nil
+}
+
+// pick 按权重从候选槽位中抽取一个，返回其在候选列表中的位置
+func (p LotteryGame) pick(candidates []int) int {
+	var total int64
+	for _, index := range candidates {
+		total += p.Slots[index].weight()
+	}
+
+	var source RandSource = globalRand{}
+	if p.runtime != nil && p.runtime.Rand != nil {
+		source = p.runtime.Rand
+	}
+	n := source.Int63n(total)
+	for pos, index := range candidates {
+		n -= p.Slots[index].weight()
+		if n < 0 {
+			return pos
+		}
+	}
+	return len(candidates) - 1
+}
+
+// recordDraw 写入抽奖审计记录
+func (p LotteryGame) recordDraw(ctx context.Context, user User, result *LotteryResult) error {
+	record := DrawRecord{
+		ActivityID:    p.runtime.ActivityID,
+		GameName:      p.Name_,
+		UserID:        user.Uid,
+		SlotIndex:     result.SlotIndex,
+		SlotType:      result.SlotType,
+		FallbackSlots: result.FallbackSlots,
+	}
+	if result.Won {
+		record.PrizeKey = p.prizeKey(result.SlotIndex)
+		record.PrizeID = result.Grant.PrizeID
+	}
+	return p.runtime.Draws.RecordDraw(ctx, record)
+}
+
+// Prizes 返回奖池中的奖品，库存标识为 玩法名称#槽位序号
+func (p LotteryGame) Prizes(ctx context.Context) map[string]PrizeInterface {
+	prizes := make(map[string]PrizeInterface)
+	for i, slot := range p.Slots {
+		if prize := slot.prize(); prize != nil {
+			prizes[p.prizeKey(i)] = prize
+		}
+	}
+	return prizes
+}
+
+// GameState 返回玩法状态
+func (p LotteryGame) GameState(ctx context.Context) GameState {
+	return p.State
+}
+
+// UserState 返回用户状态，达到抽奖次数上限后不能再参与
+func (p LotteryGame) UserState(ctx context.Context, user User) UserState {
+	count, err := p.runtime.countParticipations(ctx, p.Name_, user)
+	if err != nil {
+		return UserStateUNKNOWN
+	}
+	return p.userState(count)
+}
+
+// userState 根据用户已抽奖的次数返回用户状态
+func (p LotteryGame) userState(count int64) UserState {
+	switch {
+	case count == 0:
+		return UserStatePENDING
+	case p.MaxDraws > 0 && count >= p.MaxDraws:
+		return UserStateCLOSED
+	default:
+		return UserStateOPEN
+	}
+}
+
+// ValidateConfig 验证配置
+func (p LotteryGame) ValidateConfig(ctx context.Context) error {
+	if len(p.Slots) == 0 {
+		return fmt.Errorf("at least one slot is required")
+	}
+	if p.MaxDraws < 0 {
+		return fmt.Errorf("max draws must not be negative")
+	}
+	var total int64
+	for i, slot := range p.Slots {
+		switch slot.Type {
+		case LotterySlotDiscountCode:
+			if slot.DiscountCode == nil {
+				return fmt.Errorf("slots[%d]: discount code prize is not configured", i)
+			}
+			if err := slot.DiscountCode.Validate(); err != nil {
+				return fmt.Errorf("slots[%d]: %w", i, err)
+			}
+		case LotterySlotProduct:
+			if slot.Product == nil {
+				return fmt.Errorf("slots[%d]: product prize is not configured", i)
+			}
+			if slot.Product.TotalNum <= 0 {
+				return fmt.Errorf("slots[%d]: prize total num must be greater than 0", i)
+			}
+		case LotterySlotThanks:
+		default:
+			return fmt.Errorf("slots[%d]: unsupported slot type %q", i, slot.Type)
+		}
+		if slot.weight() < 0 {
+			return fmt.Errorf("slots[%d]: probability must not be negative", i)
+		}
+		total += slot.weight()
+	}
+	if total <= 0 {
+		return fmt.Errorf("total probability must be greater than 0")
+	}
+	return nil
+}
+
+// Actions 返回支持的操作
+func (p LotteryGame) Actions(ctx context.Context) []ActionInterface {
+	return []ActionInterface{
+		&LotteryAction{}, // 定义抽奖动作
+	}
+}
+
+// Results 返回支持的结果
+func (p LotteryGame) Results(ctx context.Context) []ResultInterface {
+	return []ResultInterface{
+		&LotteryResult{}, // 定义结果类型
+	}
+}
+
+// MarshalJSON 实现json.Marshaler接口
+func (p LotteryGame) MarshalJSON() ([]byte, error) {
+	type Alias LotteryGame
+	return json.Marshal(&struct {
+		*Alias
+	}{
+		Alias: (*Alias)(&p),
+	})
+}
+
+// UnmarshalJSON 实现json.Unmarshaler接口
+func (p *LotteryGame) UnmarshalJSON(data []byte) error {
+	type Alias LotteryGame
+	aux := &struct {
+		*Alias
+	}{
+		Alias: (*Alias)(p),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	return nil
+}
+
+// LotteryAction 抽奖动作
+type LotteryAction struct {
+	ActionTarget
+}
+
+// LotteryResult 抽奖结果
+type LotteryResult struct {
+	GameName      string         `json:"game_name"`
+	SlotIndex     int            `json:"slot_index"`               // 命中的槽位，奖品全部无库存时为 -1
+	SlotType      string         `json:"slot_type"`                // 命中的槽位类型
+	SlotName      string         `json:"slot_name"`                // 命中的槽位名称
+	Won           bool           `json:"won"`                      // 是否中奖
+	Grant         *PrizeGrant    `json:"grant"`                    // 奖品发放记录
+	FallbackSlots []int          `json:"fallback_slots,omitempty"` // 因无库存被跳过的槽位
+	prize         PrizeInterface // 命中槽位的奖品
+	state         UserState      // 本次抽奖后的用户状态
+}
+
+func (r LotteryResult) Target(ctx context.Context) string {
+	return r.GameName
+}
+
+// WonPrize 中奖时返回命中槽位的奖品
+func (r LotteryResult) WonPrize() (PrizeInterface, *PrizeGrant) {
+	if !r.Won || r.prize == nil {
+		return nil, nil
+	}
+	return r.prize, r.Grant
+}
+
+// StateAfter 返回本次抽奖后的用户状态，达到抽奖次数上限后为CLOSED
+func (r LotteryResult) StateAfter() UserState {
+	return r.state
+}
@@ -0,0 +1,290 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// txKey 标记ctx处于fakeTx开启的事务中
+type txKey struct{}
+
+// fakeTx 记录事务执行情况的事务管理，不支持真正的回滚
+type fakeTx struct {
+	rollbacks int
+}
+
+func (t *fakeTx) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(context.WithValue(ctx, txKey{}, true))
+	if err != nil {
+		t.rollbacks++
+	}
+	return err
+}
+
+// errOutsideTx 存储操作不在事务中执行
+var errOutsideTx = errors.New("store accessed outside transaction")
+
+// fakePrizes 内存中的奖品库存，只允许在事务中发奖
+type fakePrizes struct {
+	stock  map[string]int64
+	issued []PrizeIssue
+}
+
+func (s *fakePrizes) Issue(ctx context.Context, req PrizeIssue) (*PrizeGrant, error) {
+	if ctx.Value(txKey{}) == nil {
+		return nil, errOutsideTx
+	}
+	if s.stock[req.PrizeKey] <= 0 {
+		return nil, ErrPrizeStockEmpty
+	}
+	s.stock[req.PrizeKey]--
+	s.issued = append(s.issued, req)
+	prizeID := req.PrizeID
+	if req.AllocateCode {
+		prizeID = fmt.Sprintf("CODE-%d", len(s.issued))
+	}
+	return &PrizeGrant{RecordID: int64(len(s.issued)), PrizeType: req.PrizeType, PrizeID: prizeID}, nil
+}
+
+func (s *fakePrizes) Remain(ctx context.Context, activityID int64, prizeKey string) (int64, bool, error) {
+	remain, ok := s.stock[prizeKey]
+	return remain, ok, nil
+}
+
+// fakeDraws 内存中的抽奖记录，只允许在事务中写入
+type fakeDraws struct {
+	records []DrawRecord
+	err     error
+}
+
+func (s *fakeDraws) RecordDraw(ctx context.Context, record DrawRecord) error {
+	if ctx.Value(txKey{}) == nil {
+		return errOutsideTx
+	}
+	if s.err != nil {
+		return s.err
+	}
+	s.records = append(s.records, record)
+	return nil
+}
+
+// fixedCount 所有用户的参与次数都相同的参与记录存储
+type fixedCount int64
+
+func (c fixedCount) CountParticipations(ctx context.Context, activityID int64, gameName, uid string) (int64, error) {
+	return int64(c), nil
+}
+
+// fixedRand 依次返回预设值的随机数源，并记录每次抽取的总权重
+type fixedRand struct {
+	values []int64
+	totals []int64
+}
+
+func (r *fixedRand) Int63n(n int64) int64 {
+	r.totals = append(r.totals, n)
+	v := r.values[0]
+	r.values = r.values[1:]
+	return v
+}
+
+func TestLotteryGamePerform(t *testing.T) {
+	ctx := context.Background()
+	newGame := func() *LotteryGame {
+		return &LotteryGame{
+			Name_:    "幸运抽奖",
+			State:    GameStateOPEN,
+			MaxDraws: 3,
+			Slots: []LotterySlot{
+				{Type: LotterySlotDiscountCode, Name: "9折优惠码", DiscountCode: &DiscountCodePrize{DiscountCode: "LUCKY", Probability: 30, TotalNum: 10}},
+				{Type: LotterySlotProduct, Name: "周边礼品", Product: &ProductPrize{Sku: "GIFT-001", Probability: 10, TotalNum: 10}},
+				{Type: LotterySlotThanks, Name: "谢谢参与", Probability: 60},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		rand      []int64
+		stock     map[string]int64
+		count     int64
+		wantTotal []int64 // 每次抽取时的总权重
+		want      LotteryResult
+		wantDraw  DrawRecord
+		wantState UserState
+	}{
+		{
+			name:      "按权重命中第一个奖品槽位的下界",
+			rand:      []int64{0},
+			wantTotal: []int64{100},
+			want:      LotteryResult{SlotIndex: 0, SlotType: LotterySlotDiscountCode, SlotName: "9折优惠码", Won: true, Grant: &PrizeGrant{RecordID: 1, PrizeType: PrizeTypeDiscountCode, PrizeID: "CODE-1"}},
+			wantDraw:  DrawRecord{SlotIndex: 0, SlotType: LotterySlotDiscountCode, PrizeKey: "幸运抽奖#0", PrizeID: "CODE-1"},
+			wantState: UserStateOPEN,
+		},
+		{
+			name:      "按权重命中第二个奖品槽位",
+			rand:      []int64{30},
+			wantTotal: []int64{100},
+			want:      LotteryResult{SlotIndex: 1, SlotType: LotterySlotProduct, SlotName: "周边礼品", Won: true, Grant: &PrizeGrant{RecordID: 1, PrizeType: PrizeTypeProduct, PrizeID: "GIFT-001"}},
+			wantDraw:  DrawRecord{SlotIndex: 1, SlotType: LotterySlotProduct, PrizeKey: "幸运抽奖#1", PrizeID: "GIFT-001"},
+			wantState: UserStateOPEN,
+		},
+		{
+			name:      "命中谢谢参与不发奖",
+			rand:      []int64{40},
+			wantTotal: []int64{100},
+			want:      LotteryResult{SlotIndex: 2, SlotType: LotterySlotThanks, SlotName: "谢谢参与"},
+			wantDraw:  DrawRecord{SlotIndex: 2, SlotType: LotterySlotThanks},
+			wantState: UserStateOPEN,
+		},
+		{
+			name:      "奖品无库存时剔除槽位重抽",
+			rand:      []int64{29, 0},
+			stock:     map[string]int64{"幸运抽奖#0": 0},
+			wantTotal: []int64{100, 70},
+			want:      LotteryResult{SlotIndex: 1, SlotType: LotterySlotProduct, SlotName: "周边礼品", Won: true, Grant: &PrizeGrant{RecordID: 1, PrizeType: PrizeTypeProduct, PrizeID: "GIFT-001"}, FallbackSlots: []int{0}},
+			wantDraw:  DrawRecord{SlotIndex: 1, SlotType: LotterySlotProduct, PrizeKey: "幸运抽奖#1", PrizeID: "GIFT-001", FallbackSlots: []int{0}},
+			wantState: UserStateOPEN,
+		},
+		{
+			name:      "重抽后命中谢谢参与",
+			rand:      []int64{35, 30},
+			stock:     map[string]int64{"幸运抽奖#1": 0},
+			wantTotal: []int64{100, 90},
+			want:      LotteryResult{SlotIndex: 2, SlotType: LotterySlotThanks, SlotName: "谢谢参与", FallbackSlots: []int{1}},
+			wantDraw:  DrawRecord{SlotIndex: 2, SlotType: LotterySlotThanks, FallbackSlots: []int{1}},
+			wantState: UserStateOPEN,
+		},
+		{
+			name:      "最后一次抽奖后用户状态为CLOSED",
+			rand:      []int64{99},
+			count:     2,
+			wantTotal: []int64{100},
+			want:      LotteryResult{SlotIndex: 2, SlotType: LotterySlotThanks, SlotName: "谢谢参与"},
+			wantDraw:  DrawRecord{SlotIndex: 2, SlotType: LotterySlotThanks},
+			wantState: UserStateCLOSED,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stock := map[string]int64{"幸运抽奖#0": 10, "幸运抽奖#1": 10}
+			for key, n := range tt.stock {
+				stock[key] = n
+			}
+			prizes := &fakePrizes{stock: stock}
+			draws := &fakeDraws{}
+			source := &fixedRand{values: tt.rand}
+			game := newGame()
+			game.BindRuntime(&Runtime{
+				ActivityID:     1,
+				Participations: fixedCount(tt.count),
+				Prizes:         prizes,
+				Draws:          draws,
+				Rand:           source,
+				Tx:             &fakeTx{},
+			})
+
+			got, err := game.Perform(ctx, User{Uid: "user-1"}, &LotteryAction{})
+			if err != nil {
+				t.Fatalf("Perform() error = %v", err)
+			}
+			result := got.(*LotteryResult)
+			if !reflect.DeepEqual(source.totals, tt.wantTotal) {
+				t.Errorf("total weights = %v, want %v", source.totals, tt.wantTotal)
+			}
+			if result.SlotIndex != tt.want.SlotIndex || result.SlotType != tt.want.SlotType || result.SlotName != tt.want.SlotName ||
+				result.Won != tt.want.Won || !reflect.DeepEqual(result.Grant, tt.want.Grant) || !reflect.DeepEqual(result.FallbackSlots, tt.want.FallbackSlots) {
+				t.Errorf("Perform() = %+v, want %+v", *result, tt.want)
+			}
+			if prize, _ := result.WonPrize(); (prize != nil) != tt.want.Won {
+				t.Errorf("WonPrize() = %v, want won %v", prize, tt.want.Won)
+			}
+			if state := result.StateAfter(); state != tt.wantState {
+				t.Errorf("StateAfter() = %s, want %s", state, tt.wantState)
+			}
+
+			tt.wantDraw.ActivityID, tt.wantDraw.GameName, tt.wantDraw.UserID = 1, "幸运抽奖", "user-1"
+			if len(draws.records) != 1 || !reflect.DeepEqual(draws.records[0], tt.wantDraw) {
+				t.Errorf("draw records = %+v, want %+v", draws.records, tt.wantDraw)
+			}
+		})
+	}
+}
+
+func TestLotteryGamePerformAllOutOfStock(t *testing.T) {
+	game := &LotteryGame{
+		Name_: "幸运抽奖",
+		State: GameStateOPEN,
+		Slots: []LotterySlot{
+			{Type: LotterySlotDiscountCode, Name: "9折优惠码", DiscountCode: &DiscountCodePrize{Probability: 50, TotalNum: 10}},
+			{Type: LotterySlotProduct, Name: "周边礼品", Product: &ProductPrize{Sku: "GIFT-001", Probability: 50, TotalNum: 10}},
+		},
+	}
+	draws := &fakeDraws{}
+	game.BindRuntime(&Runtime{
+		Participations: fixedCount(0),
+		Prizes:         &fakePrizes{stock: map[string]int64{"幸运抽奖#0": 0, "幸运抽奖#1": 0}},
+		Draws:          draws,
+		Rand:           &fixedRand{values: []int64{0, 0}},
+		Tx:             &fakeTx{},
+	})
+
+	got, err := game.Perform(context.Background(), User{Uid: "user-1"}, &LotteryAction{})
+	if err != nil {
+		t.Fatalf("Perform() error = %v", err)
+	}
+	result := got.(*LotteryResult)
+	if result.Won || result.SlotIndex != -1 || result.SlotType != LotterySlotThanks || !reflect.DeepEqual(result.FallbackSlots, []int{0, 1}) {
+		t.Errorf("Perform() = %+v, want no prize after falling back from slots 0 and 1", *result)
+	}
+	if len(draws.records) != 1 || draws.records[0].SlotIndex != -1 {
+		t.Errorf("draw records = %+v, want one record with slot -1", draws.records)
+	}
+}
+
+func TestLotteryGamePerformErrors(t *testing.T) {
+	ctx := context.Background()
+	newGame := func(rt *Runtime) *LotteryGame {
+		game := &LotteryGame{
+			Name_:    "幸运抽奖",
+			State:    GameStateOPEN,
+			MaxDraws: 1,
+			Slots: []LotterySlot{
+				{Type: LotterySlotProduct, Name: "周边礼品", Product: &ProductPrize{Sku: "GIFT-001", Probability: 1, TotalNum: 10}},
+			},
+		}
+		game.BindRuntime(rt)
+		return game
+	}
+
+	// 达到抽奖次数上限
+	game := newGame(&Runtime{Participations: fixedCount(1), Prizes: &fakePrizes{}, Draws: &fakeDraws{}, Rand: &fixedRand{}})
+	if _, err := game.Perform(ctx, User{Uid: "user-1"}, &LotteryAction{}); !errors.Is(err, ErrUserCannotParticipate) {
+		t.Errorf("Perform() at max draws error = %v, want ErrUserCannotParticipate", err)
+	}
+
+	// 抽奖记录写入失败时事务回滚，发奖在同一事务中
+	failed := errors.New("failed to insert draw")
+	tx := &fakeTx{}
+	prizes := &fakePrizes{stock: map[string]int64{"幸运抽奖#0": 10}}
+	game = newGame(&Runtime{Participations: fixedCount(0), Prizes: prizes, Draws: &fakeDraws{err: failed}, Rand: &fixedRand{values: []int64{0}}, Tx: tx})
+	if _, err := game.Perform(ctx, User{Uid: "user-1"}, &LotteryAction{}); !errors.Is(err, failed) {
+		t.Errorf("Perform() error = %v, want %v", err, failed)
+	}
+	if len(prizes.issued) != 1 || tx.rollbacks != 1 {
+		t.Errorf("issued = %d, rollbacks = %d, want the issue rolled back with the draw record", len(prizes.issued), tx.rollbacks)
+	}
+
+	// 未注入抽奖记录存储时不发奖
+	prizes = &fakePrizes{stock: map[string]int64{"幸运抽奖#0": 10}}
+	game = newGame(&Runtime{Participations: fixedCount(0), Prizes: prizes, Rand: &fixedRand{values: []int64{0}}, Tx: &fakeTx{}})
+	if _, err := game.Perform(ctx, User{Uid: "user-1"}, &LotteryAction{}); !errors.Is(err, ErrRuntimeNotConfigured) {
+		t.Errorf("Perform() without draw store error = %v, want ErrRuntimeNotConfigured", err)
+	}
+	if len(prizes.issued) != 0 {
+		t.Errorf("issued = %d, want 0", len(prizes.issued))
+	}
+}
@@ -2,9 +2,17 @@ package models
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrActivityNotStarted 活动未开始
+	ErrActivityNotStarted = errors.New("activity not started")
+	// ErrActivityEnded 活动已结束
+	ErrActivityEnded = errors.New("activity ended")
+)
+
 type ActivityInterface interface {
 	Category() string       // 活动类型
 	Version() string        // 活动版本
@@ -13,33 +21,52 @@ type ActivityInterface interface {
 	StartAt() int64         // 活动开始时间戳
 	EndAt() int64           // 活动结束时间戳
 	Status() int64          // 活动状态
+	Meta() *MetaActivity    // 活动元信息，由存储层回填ID、状态等字段
 }
 
 type ResultInterface interface {
 	Target(ctx context.Context) string // 当有多个相同玩法时，用于标识当前响应是来自哪个具体玩法
 }
 
+// StateResult 带有本次参与后用户状态的玩法结果实现该接口，应用层据此直接写入参与记录，无需写入后再计算
+type StateResult interface {
+	StateAfter() UserState
+}
+
 type ActionInterface interface {
 	Target(ctx context.Context) string // 当有多个相同玩法时，用于标识当前请求指定的具体玩法
 }
 
 type MetaActivity struct {
-	ID             int64          `db:"id"`              // 主键
-	CreatedAt      time.Time      `db:"created_at"`      // 创建时间
-	UpdatedAt      time.Time      `db:"updated_at"`      // 更新时间
-	Category       string         `db:"category"`        // 活动类型
-	Version        string         `db:"version"`         // 活动的版本
-	ActivityConfig ActivityConfig `db:"activity_config"` // 活动的JSON配置
-	StartAt        int64          `db:"start_at"`        // 活动开始时间戳
-	EndAt          int64          `db:"end_at"`          // 活动结束时间戳
-	Status         int64          `db:"status"`          // 0-draft; 1-online
+	ID             int64             `db:"id"`              // 主键
+	CreatedAt      time.Time         `db:"created_at"`      // 创建时间
+	UpdatedAt      time.Time         `db:"updated_at"`      // 更新时间
+	Category       string            `db:"category"`        // 活动类型
+	Version        string            `db:"version"`         // 活动的版本
+	Name           string            `db:"name"`            // 活动名称
+	ActivityConfig ActivityConfig    `db:"activity_config"` // 活动的JSON配置
+	StartAt        int64             `db:"start_at"`        // 活动开始时间戳
+	EndAt          int64             `db:"end_at"`          // 活动结束时间戳
+	Status         int64             `db:"status"`          // 活动状态，见ActivityStatus常量
+	Revision       int64             `db:"revision"`        // 修订号，每次更新加1
+	Enrollment     *EnrollmentConfig `db:"-"`               // 报名配置，来自活动配置
+	RateLimit      *RateLimitConfig  `db:"-"`               // 限流配置，来自活动配置
 }
+
+// Meta 返回活动元信息，嵌入MetaActivity的活动自动获得该方法
+func (m *MetaActivity) Meta() *MetaActivity {
+	return m
+}
+
 type ActivityConfig struct {
 	Activity ActivityInterface
 }
 
 type User struct {
-	Uid string
+	Uid          string
+	DeviceID     string    // 设备指纹，客户端未上报时为空
+	IP           string    // 客户端IP
+	RegisteredAt time.Time // 账号注册时间，令牌未携带时为零值
 }
 
 type UserState = string
@@ -50,3 +77,8 @@ const (
 	UserStateOPEN    UserState = "OPEN"    // 请优先判断玩法状态；玩法开放的前提下，还可以继续参加;
 	UserStateCLOSED  UserState = "CLOSED"  // 请优先判断玩法状态；玩法开放的前提下，不能参加了，已经有结果
 )
+
+// CanParticipate 用户状态是否允许继续参与
+func CanParticipate(state UserState) bool {
+	return state == UserStatePENDING || state == UserStateOPEN
+}
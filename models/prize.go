@@ -1,9 +1,123 @@
 package models
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPrizeStockEmpty 奖品库存不足
+var ErrPrizeStockEmpty = errors.New("prize stock is empty")
+
+// 奖品类型
+const (
+	PrizeTypeDiscountCode = "discount_code" // 折扣码
+	PrizeTypeProduct      = "product"       // 商品
+)
 
 // PrizeInterface 奖品的interface
 type PrizeInterface interface {
-	WinPrize(ctx context.Context, user User) error // 中奖后需要执行的逻辑
-	WinProbability() int64                         // 中奖概率
+	WinPrize(ctx context.Context, user User) (*PrizeGrant, error) // 中奖后需要执行的逻辑
+	WinProbability() int64                                        // 中奖概率
+	Detail(grant *PrizeGrant) PrizeDetail                         // 结合发放记录返回展示给用户的奖品详情
+}
+
+// PrizeDetail 奖品详情，不同类型的奖品填写各自的字段
+type PrizeDetail struct {
+	Type         string // 奖品类型
+	DiscountCode string // 折扣码（折扣码类型）
+	PriceRuleID  int64  // 价格规则ID（折扣码类型）
+	SKU          string // 商品SKU（商品类型）
+	Title        string // 商品标题（商品类型）
+}
+
+// PrizeResult 可能发放奖品的玩法结果实现该接口
+type PrizeResult interface {
+	// WonPrize 返回本次获得的奖品及发放记录，未获得奖品时均为nil
+	WonPrize() (PrizeInterface, *PrizeGrant)
+}
+
+// StockPrize 配置了总库存的奖品
+type StockPrize interface {
+	PrizeInterface
+	StockTotal() int64                            // 配置的总库存
+	RemainNum(ctx context.Context) (int64, error) // 剩余库存，库存尚未初始化时为总库存
+	setStockTotal(n int64)                        // 仅用于比较配置时替换副本的总库存
+}
+
+// PrizeProvider 持有奖品的玩法实现该接口，返回奖品库存标识到奖品的映射
+type PrizeProvider interface {
+	Prizes(ctx context.Context) map[string]PrizeInterface
+}
+
+// PrizeStore 奖品库存与发放记录存储
+type PrizeStore interface {
+	// Issue 在同一事务中扣减库存并写入发放记录，库存不足时返回 ErrPrizeStockEmpty
+	Issue(ctx context.Context, req PrizeIssue) (*PrizeGrant, error)
+	// Remain 返回剩余库存，库存尚未初始化时 exists 为 false
+	Remain(ctx context.Context, activityID int64, prizeKey string) (remain int64, exists bool, err error)
+}
+
+// PrizeIssue 发奖请求
+type PrizeIssue struct {
+	ActivityID   int64  // 活动ID
+	GameName     string // 发奖的玩法名称
+	PrizeKey     string // 奖品在活动内的唯一标识，同一个库存共用一个key
+	PrizeType    string // 奖品类型
+	PrizeID      string // 奖品标识，如商品SKU
+	TotalNum     int64  // 配置的总库存，库存尚未初始化时使用
+	UserID       string // 中奖用户
+	AllocateCode bool   // 是否从折扣码池分配一个码作为奖品标识
+}
+
+// PrizeGrant 发奖结果
+type PrizeGrant struct {
+	RecordID  int64  `json:"record_id"`  // 发放记录ID
+	PrizeType string `json:"prize_type"` // 奖品类型
+	PrizeID   string `json:"prize_id"`   // 奖品标识
+}
+
+// prizeBinding 奖品的库存绑定信息，由玩法在注入运行时依赖时设置
+type prizeBinding struct {
+	store      PrizeStore
+	activityID int64
+	gameName   string
+	key        string
+}
+
+// newPrizeBinding 根据运行时依赖创建库存绑定
+func newPrizeBinding(rt *Runtime, gameName, key string) prizeBinding {
+	return prizeBinding{
+		store:      rt.Prizes,
+		activityID: rt.ActivityID,
+		gameName:   gameName,
+		key:        key,
+	}
+}
+
+// issue 通过库存存储发放奖品，req 中的活动、玩法和库存标识由绑定信息填充
+func (b prizeBinding) issue(ctx context.Context, user User, req PrizeIssue) (*PrizeGrant, error) {
+	if b.store == nil {
+		return nil, fmt.Errorf("%w: prize store", ErrRuntimeNotConfigured)
+	}
+	req.ActivityID = b.activityID
+	req.GameName = b.gameName
+	req.PrizeKey = b.key
+	req.UserID = user.Uid
+	return b.store.Issue(ctx, req)
+}
+
+// remain 查询剩余库存，库存尚未初始化时返回配置的总库存
+func (b prizeBinding) remain(ctx context.Context, totalNum int64) (int64, error) {
+	if b.store == nil {
+		return totalNum, nil
+	}
+	remain, exists, err := b.store.Remain(ctx, b.activityID, b.key)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return totalNum, nil
+	}
+	return remain, nil
 }
@@ -4,15 +4,48 @@ import "context"
 
 // Product 商品
 type ProductPrize struct {
-	Sku         string `json:"sku"`
-	Title       string `json:"title"`
-	Probability int64  `json:"probability"` // 中奖概率
+	Sku         string       `json:"sku"`
+	Title       string       `json:"title"`
+	Probability int64        `json:"probability"` // 中奖概率
+	TotalNum    int64        `json:"total_num"`   // 总数量，剩余数量以库存表为准
+	binding     prizeBinding // 库存绑定，由BindRuntime注入
 }
 
-func (p ProductPrize) WinPrize(ctx context.Context, user User) error {
-	return nil
+// Bind 绑定奖品库存，key 在同一活动内唯一
+func (p *ProductPrize) Bind(rt *Runtime, gameName, key string) {
+	p.binding = newPrizeBinding(rt, gameName, key)
+}
+
+func (p ProductPrize) WinPrize(ctx context.Context, user User) (*PrizeGrant, error) {
+	return p.binding.issue(ctx, user, PrizeIssue{
+		PrizeType: PrizeTypeProduct,
+		PrizeID:   p.Sku,
+		TotalNum:  p.TotalNum,
+	})
+}
+
+func (p ProductPrize) StockTotal() int64 {
+	return p.TotalNum
+}
+
+func (p *ProductPrize) setStockTotal(n int64) {
+	p.TotalNum = n
 }
 
 func (p ProductPrize) WinProbability() int64 {
 	return p.Probability
 }
+
+// Detail 商品SKU以发放记录为准
+func (p ProductPrize) Detail(grant *PrizeGrant) PrizeDetail {
+	detail := PrizeDetail{Type: PrizeTypeProduct, SKU: p.Sku, Title: p.Title}
+	if grant != nil && grant.PrizeID != "" {
+		detail.SKU = grant.PrizeID
+	}
+	return detail
+}
+
+// RemainNum 返回剩余库存
+func (p ProductPrize) RemainNum(ctx context.Context) (int64, error) {
+	return p.binding.remain(ctx, p.TotalNum)
+}
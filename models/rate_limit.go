@@ -0,0 +1,72 @@
+package models
+
+import (
+	"fmt"
+)
+
+// RateLimitRule 令牌桶限流规则
+type RateLimitRule struct {
+	Rate  float64 `json:"rate"`  // 每秒补充的令牌数，0 表示不限流
+	Burst int64   `json:"burst"` // 令牌桶容量，即允许的瞬时请求数
+}
+
+// Enabled 规则是否生效
+func (r *RateLimitRule) Enabled() bool {
+	return r != nil && r.Rate > 0
+}
+
+// Validate 校验限流规则
+func (r *RateLimitRule) Validate() error {
+	if r.Rate < 0 {
+		return fmt.Errorf("rate must not be negative")
+	}
+	if r.Rate > 0 && r.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	return nil
+}
+
+// RateLimitConfig 活动参与接口的限流配置，未配置的范围使用服务的默认规则
+type RateLimitConfig struct {
+	User     *RateLimitRule `json:"user,omitempty"`     // 每个用户在活动内的限流
+	IP       *RateLimitRule `json:"ip,omitempty"`       // 每个IP在活动内的限流
+	Activity *RateLimitRule `json:"activity,omitempty"` // 整个活动的限流
+}
+
+// Validate 校验限流配置
+func (c *RateLimitConfig) Validate() error {
+	scopes := []struct {
+		name string
+		rule *RateLimitRule
+	}{{"user", c.User}, {"ip", c.IP}, {"activity", c.Activity}}
+	for _, scope := range scopes {
+		if scope.rule == nil {
+			continue
+		}
+		if err := scope.rule.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", scope.name, err)
+		}
+	}
+	return nil
+}
+
+// Merge 返回以c为准、未配置的范围取自defaults的限流配置
+func (c *RateLimitConfig) Merge(defaults *RateLimitConfig) *RateLimitConfig {
+	merged := RateLimitConfig{}
+	if defaults != nil {
+		merged = *defaults
+	}
+	if c == nil {
+		return &merged
+	}
+	if c.User != nil {
+		merged.User = c.User
+	}
+	if c.IP != nil {
+		merged.IP = c.IP
+	}
+	if c.Activity != nil {
+		merged.Activity = c.Activity
+	}
+	return &merged
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+	"fmt"
+)
+
+// Runtime 玩法运行时依赖，由存储层在构建活动时注入
+// 玩法配置只描述规则，用户维度的状态需要通过这里的存储读写
+type Runtime struct {
+	ActivityID     int64              // 所属活动ID
+	StartAt        int64              // 所属活动的开始时间戳
+	EndAt          int64              // 所属活动的结束时间戳
+	Participations ParticipationStore // 用户参与记录存储
+	Checkins       CheckinStore       // 签到记录存储
+	Prizes         PrizeStore         // 奖品库存与发放记录存储
+	Draws          DrawStore          // 抽奖记录存储
+	Rand           RandSource         // 随机数源，为空时使用全局随机数
+	Community      CommunityClient    // 社区服务客户端，用于验证帖子
+	Tx             Transactor         // 事务管理，为空时各存储操作单独提交
+}
+
+// Transactor 在同一事务中执行多个存储操作
+type Transactor interface {
+	// Transaction 在事务中执行fn，fn内通过传入的ctx访问的存储共用该事务，fn返回错误时回滚
+	// ctx已处于事务中时直接加入该事务
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// transaction 在运行时的事务中执行fn，未注入事务管理时直接执行
+func (rt *Runtime) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if rt == nil || rt.Tx == nil {
+		return fn(ctx)
+	}
+	return rt.Tx.Transaction(ctx, fn)
+}
+
+// ParticipationStore 用户参与记录存储
+type ParticipationStore interface {
+	// CountParticipations 统计用户在某个玩法中成功参与的次数
+	CountParticipations(ctx context.Context, activityID int64, gameName, uid string) (int64, error)
+}
+
+// countParticipations 统计用户参与次数，未注入存储时返回错误
+func (rt *Runtime) countParticipations(ctx context.Context, gameName string, user User) (int64, error) {
+	if rt == nil || rt.Participations == nil {
+		return 0, fmt.Errorf("%w: participation store", ErrRuntimeNotConfigured)
+	}
+	return rt.Participations.CountParticipations(ctx, rt.ActivityID, gameName, user.Uid)
+}
+
+// RuntimeBinder 需要运行时依赖的玩法实现该接口
+type RuntimeBinder interface {
+	BindRuntime(rt *Runtime)
+}
+
+// BindRuntime 为活动下的所有玩法注入运行时依赖
+func BindRuntime(activity ActivityInterface, rt *Runtime) {
+	for _, game := range activity.Games() {
+		if binder, ok := game.(RuntimeBinder); ok {
+			binder.BindRuntime(rt)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rule 令牌桶规则
+type Rule struct {
+	Rate  float64 // 每秒补充的令牌数
+	Burst int64   // 令牌桶容量
+}
+
+// Limiter 令牌桶限流器，相同key的请求共用一个令牌桶
+type Limiter interface {
+	// Allow 从key对应的令牌桶中取一个令牌，令牌不足时返回false以及预计可以重试的等待时间
+	Allow(ctx context.Context, key string, rule Rule) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// take 按经过的时间补充令牌后尝试取一个，返回取之后的令牌数、是否成功和令牌不足时的等待时间
+func take(tokens float64, elapsed time.Duration, rule Rule) (float64, bool, time.Duration) {
+	if elapsed > 0 {
+		tokens = math.Min(float64(rule.Burst), tokens+elapsed.Seconds()*rule.Rate)
+	}
+	if tokens >= 1 {
+		return tokens - 1, true, 0
+	}
+	wait := time.Duration((1 - tokens) / rule.Rate * float64(time.Second))
+	return tokens, false, wait
+}
+
+// idleTTL 令牌桶补满所需的时间，超过该时间未访问的令牌桶与新建的等价，可以丢弃
+func idleTTL(rule Rule) time.Duration {
+	return time.Duration(float64(rule.Burst)/rule.Rate*float64(time.Second)) + time.Second
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testLimiters 返回两种实现及拨动它们时钟的函数
+func testLimiters(t *testing.T) map[string]struct {
+	limiter Limiter
+	advance func(d time.Duration)
+} {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+
+	memory := NewMemoryLimiter().(*memoryLimiter)
+	memory.now = clock
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	shared := NewRedisLimiter(client).(*redisLimiter)
+	shared.now = clock
+
+	advance := func(d time.Duration) { now = now.Add(d) }
+	return map[string]struct {
+		limiter Limiter
+		advance func(d time.Duration)
+	}{
+		"memory": {memory, advance},
+		"redis":  {shared, advance},
+	}
+}
+
+func TestLimiterTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	rule := Rule{Rate: 2, Burst: 3}
+
+	for name, tc := range testLimiters(t) {
+		t.Run(name, func(t *testing.T) {
+			allow := func(key string) (bool, time.Duration) {
+				t.Helper()
+				allowed, wait, err := tc.limiter.Allow(ctx, key, rule)
+				if err != nil {
+					t.Fatalf("Allow() error = %v", err)
+				}
+				return allowed, wait
+			}
+
+			// 容量内的瞬时请求全部放行，超出后拒绝并给出等待时间
+			for i := 0; i < 3; i++ {
+				if allowed, _ := allow("user-1"); !allowed {
+					t.Fatalf("request %d rejected within burst", i)
+				}
+			}
+			allowed, wait := allow("user-1")
+			if allowed {
+				t.Fatal("request allowed beyond burst")
+			}
+			if wait != 500*time.Millisecond {
+				t.Errorf("retry after = %v, want 500ms", wait)
+			}
+
+			// 不同key使用各自的令牌桶
+			if allowed, _ := allow("user-2"); !allowed {
+				t.Error("another key was rejected")
+			}
+
+			// 按速率补充令牌，补充量不超过容量
+			tc.advance(500 * time.Millisecond)
+			if allowed, _ := allow("user-1"); !allowed {
+				t.Error("request rejected after refill")
+			}
+			if allowed, _ := allow("user-1"); allowed {
+				t.Error("refill exceeded rate")
+			}
+			tc.advance(time.Hour)
+			for i := 0; i < 3; i++ {
+				if allowed, _ := allow("user-1"); !allowed {
+					t.Fatalf("request %d rejected after full refill", i)
+				}
+			}
+			if allowed, _ := allow("user-1"); allowed {
+				t.Error("refill exceeded burst")
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 清理空闲令牌桶的间隔
+const sweepInterval = time.Minute
+
+// bucket 内存中的令牌桶
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time // 超过该时间未访问时令牌已补满，可以丢弃
+}
+
+// memoryLimiter 进程内的令牌桶限流器，多副本部署时每个实例单独计数
+type memoryLimiter struct {
+	now func() time.Time // 当前时间，测试时替换
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter 创建进程内的令牌桶限流器
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow 从令牌桶中取一个令牌，令牌桶不存在时按容量新建
+func (l *memoryLimiter) Allow(ctx context.Context, key string, rule Rule) (bool, time.Duration, error) {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(rule.Burst), updatedAt: now}
+		l.buckets[key] = b
+	}
+	tokens, allowed, wait := take(b.tokens, now.Sub(b.updatedAt), rule)
+	b.tokens = tokens
+	b.updatedAt = now
+	b.expiresAt = now.Add(idleTTL(rule))
+	return allowed, wait, nil
+}
+
+// sweep 定期丢弃已补满的令牌桶，避免大量一次性的key占用内存
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.After(b.expiresAt) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeScript 在Redis中按令牌桶算法取一个令牌，令牌数和更新时间保存在hash中
+// KEYS: 令牌桶；ARGV: 每秒补充的令牌数、容量、当前时间（毫秒）、空闲过期时间（毫秒）
+// 返回是否成功和令牌不足时的等待毫秒数
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at')
+local tokens = tonumber(bucket[1])
+local updated_at = tonumber(bucket[2])
+if not tokens then
+	tokens = burst
+	updated_at = now
+end
+if now > updated_at then
+	tokens = math.min(burst, tokens + (now - updated_at) / 1000 * rate)
+	updated_at = now
+end
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', updated_at)
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {allowed, wait}
+`)
+
+// redisLimiter 基于Redis的令牌桶限流器，多个实例共用令牌桶
+type redisLimiter struct {
+	client *redis.Client
+	now    func() time.Time // 当前时间，测试时替换
+}
+
+// NewRedisLimiter 创建基于Redis的令牌桶限流器
+func NewRedisLimiter(client *redis.Client) Limiter {
+	return &redisLimiter{client: client, now: time.Now}
+}
+
+// Allow 在Redis中原子地补充令牌并取一个令牌，时间以调用方为准，各实例的时钟偏差会影响补充速度
+func (l *redisLimiter) Allow(ctx context.Context, key string, rule Rule) (bool, time.Duration, error) {
+	args := []interface{}{rule.Rate, rule.Burst, l.now().UnixMilli(), idleTTL(rule).Milliseconds()}
+	result, err := takeScript.Run(ctx, l.client, []string{"ratelimit:" + key}, args...).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take token of %s: %w", key, err)
+	}
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,113 @@
+package risk
+
+import (
+	"Activity/storage/mysql/entity"
+	"context"
+	"log"
+	"time"
+)
+
+// Decision 风控决策
+type Decision = string
+
+const (
+	DecisionAllow     Decision = entity.RiskDecisionAllow     // 放行
+	DecisionDeny      Decision = entity.RiskDecisionDeny      // 拒绝
+	DecisionChallenge Decision = entity.RiskDecisionChallenge // 需要用户完成额外验证
+)
+
+// Subject 参与玩法请求的风控信息
+type Subject struct {
+	UserID       string
+	DeviceID     string    // 设备指纹，为空时设备相关的规则返回配置的决策
+	IP           string    // 客户端IP，只信任可信代理转发的X-Forwarded-For
+	RegisteredAt time.Time // 账号注册时间，为零值时账号相关的规则返回配置的决策
+	ActivityID   int64
+	GameName     string
+}
+
+// Rule 风控规则，返回决策和原因；规则出错时按放行处理
+type Rule interface {
+	Name() string
+	Check(ctx context.Context, subject Subject) (Decision, string, error)
+}
+
+// History 规则依赖的历史数据，按设备统计
+type History interface {
+	// CountDeviceUsers 统计since之后在设备上被放行过的其他用户数
+	CountDeviceUsers(ctx context.Context, deviceID, excludeUserID string, since time.Time) (int64, error)
+	// CountDeviceWins 统计since之后设备上的中奖次数
+	CountDeviceWins(ctx context.Context, deviceID string, since time.Time) (int64, error)
+}
+
+// EventStore 风控决策的存储，保存的决策同时作为History的数据来源
+type EventStore interface {
+	Create(ctx context.Context, event *entity.RiskEvent) error
+	MarkWon(ctx context.Context, id int64) error
+}
+
+// Verdict 风控链的最终决策
+type Verdict struct {
+	Decision Decision
+	Rule     string // 做出非放行决策的规则
+	Reason   string
+	EventID  int64 // 保存的风控事件ID，未保存时为0
+}
+
+// Chain 按顺序执行的风控规则链，遇到第一个非放行的决策即停止
+type Chain struct {
+	rules []Rule
+	store EventStore // 为nil时决策只写入日志
+}
+
+// NewChain 创建风控规则链，store为nil时不保存决策
+func NewChain(store EventStore, rules ...Rule) *Chain {
+	return &Chain{rules: rules, store: store}
+}
+
+// Check 依次执行规则得到决策，每次决策都写入日志并保存，便于按实际数据调整规则
+// 规则或存储出错时只记录日志，不影响用户参与
+func (c *Chain) Check(ctx context.Context, subject Subject) *Verdict {
+	verdict := &Verdict{Decision: DecisionAllow}
+	for _, rule := range c.rules {
+		decision, reason, err := rule.Check(ctx, subject)
+		if err != nil {
+			log.Printf("risk: rule %s failed: uid=%s err=%v", rule.Name(), subject.UserID, err)
+			continue
+		}
+		if decision != DecisionAllow {
+			verdict = &Verdict{Decision: decision, Rule: rule.Name(), Reason: reason}
+			break
+		}
+	}
+
+	log.Printf("risk: decision=%s rule=%s activity=%d game=%s uid=%s device=%s ip=%s reason=%q",
+		verdict.Decision, verdict.Rule, subject.ActivityID, subject.GameName, subject.UserID, subject.DeviceID, subject.IP, verdict.Reason)
+
+	if c.store != nil {
+		event := &entity.RiskEvent{
+			ActivityID: subject.ActivityID,
+			GameName:   subject.GameName,
+			UserID:     subject.UserID,
+			DeviceID:   subject.DeviceID,
+			IP:         subject.IP,
+			Decision:   verdict.Decision,
+			Rule:       verdict.Rule,
+			Reason:     verdict.Reason,
+		}
+		if err := c.store.Create(ctx, event); err != nil {
+			log.Printf("risk: failed to save event: uid=%s err=%v", subject.UserID, err)
+		} else {
+			verdict.EventID = event.ID
+		}
+	}
+	return verdict
+}
+
+// ReportWin 记录放行的请求获得了奖品，用于统计设备的中奖频率
+func (c *Chain) ReportWin(ctx context.Context, verdict *Verdict) error {
+	if c.store == nil || verdict == nil || verdict.EventID == 0 {
+		return nil
+	}
+	return c.store.MarkWon(ctx, verdict.EventID)
+}
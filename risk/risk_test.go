@@ -0,0 +1,133 @@
+package risk
+
+import (
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqliteSchema 测试使用的表结构，与 migrations 中的 MySQL 定义保持一致
+const sqliteSchema = `CREATE TABLE risk_events (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	activity_id INTEGER NOT NULL,
+	game_name TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	device_id TEXT NOT NULL DEFAULT '',
+	ip TEXT NOT NULL DEFAULT '',
+	decision TEXT NOT NULL,
+	rule TEXT NOT NULL DEFAULT '',
+	reason TEXT NOT NULL DEFAULT '',
+	won INTEGER NOT NULL DEFAULT 0,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+func newTestEvents(t *testing.T) (*gorm.DB, repository.RiskEventRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "activity.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	if err := db.Exec(sqliteSchema).Error; err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	return db, repository.NewRiskEventRepository(db)
+}
+
+func TestChainRules(t *testing.T) {
+	ctx := context.Background()
+	db, events := newTestEvents(t)
+	now := time.Now()
+
+	chain := NewChain(events,
+		NewBlocklist([]string{"blocked"}, nil, []string{"10.0.0.1"}),
+		NewDeviceSharing(events, 2, 24*time.Hour, DecisionChallenge),
+		NewDeviceWins(events, 1, 24*time.Hour, DecisionChallenge),
+		NewNewAccountCooldown(72*time.Hour, DecisionChallenge),
+	)
+	subject := func(uid, device string) Subject {
+		return Subject{UserID: uid, DeviceID: device, IP: "10.0.0.2", RegisteredAt: now.AddDate(-1, 0, 0), ActivityID: 1, GameName: "lottery"}
+	}
+	check := func(s Subject, want Decision, wantRule string) *Verdict {
+		t.Helper()
+		verdict := chain.Check(ctx, s)
+		if verdict.Decision != want || verdict.Rule != wantRule {
+			t.Fatalf("Check(%s, %s) = %s by %q, want %s by %q", s.UserID, s.DeviceID, verdict.Decision, verdict.Rule, want, wantRule)
+		}
+		if verdict.EventID == 0 {
+			t.Fatal("decision was not saved")
+		}
+		return verdict
+	}
+
+	// 黑名单命中后不再执行后续规则
+	check(subject("blocked", "d1"), DecisionDeny, "blocklist")
+	blockedIP := subject("u1", "d1")
+	blockedIP.IP = "10.0.0.1"
+	check(blockedIP, DecisionDeny, "blocklist")
+
+	// 新账号需要验证，没有注册时间或设备指纹时同样需要验证
+	fresh := subject("u1", "d1")
+	fresh.RegisteredAt = now.Add(-time.Hour)
+	check(fresh, DecisionChallenge, "new_account")
+	fresh.RegisteredAt = time.Time{}
+	check(fresh, DecisionChallenge, "new_account")
+	check(subject("u1", ""), DecisionChallenge, "device_sharing")
+
+	// 每个设备最多两个账号，被拒绝和需要验证的请求不计入，已放行的账号可以继续使用
+	check(subject("u1", "d1"), DecisionAllow, "")
+	check(subject("u2", "d1"), DecisionAllow, "")
+	check(subject("u3", "d1"), DecisionDeny, "device_sharing")
+	check(subject("u1", "d1"), DecisionAllow, "")
+	check(subject("u3", "d2"), DecisionAllow, "")
+
+	// 设备中奖一次后，窗口内不再放行
+	won := check(subject("u3", "d3"), DecisionAllow, "")
+	if err := chain.ReportWin(ctx, won); err != nil {
+		t.Fatalf("ReportWin() error = %v", err)
+	}
+	check(subject("u3", "d3"), DecisionDeny, "device_wins")
+
+	// 每次决策都保存，包括放行
+	var saved int64
+	if err := db.Model(&entity.RiskEvent{}).Count(&saved).Error; err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if saved != 12 {
+		t.Errorf("saved events = %d, want 12", saved)
+	}
+}
+
+func TestRulesMissingSignal(t *testing.T) {
+	ctx := context.Background()
+	anonymous := Subject{UserID: "u1", IP: "10.0.0.2", ActivityID: 1, GameName: "lottery"}
+
+	for _, missing := range []Decision{DecisionAllow, DecisionDeny, DecisionChallenge} {
+		// 缺少数据时不访问历史数据，直接返回配置的决策
+		rules := []Rule{
+			NewDeviceSharing(nil, 2, 24*time.Hour, missing),
+			NewDeviceWins(nil, 1, 24*time.Hour, missing),
+			NewNewAccountCooldown(72*time.Hour, missing),
+		}
+		for _, rule := range rules {
+			decision, reason, err := rule.Check(ctx, anonymous)
+			if err != nil {
+				t.Fatalf("%s: Check() error = %v", rule.Name(), err)
+			}
+			if decision != missing {
+				t.Errorf("%s: Check() = %s, want %s", rule.Name(), decision, missing)
+			}
+			if (reason == "") != (missing == DecisionAllow) {
+				t.Errorf("%s: reason = %q for %s", rule.Name(), reason, missing)
+			}
+		}
+	}
+}
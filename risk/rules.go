@@ -0,0 +1,149 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Blocklist 黑名单规则，用户、设备或IP在名单中时拒绝
+type Blocklist struct {
+	users   map[string]bool
+	devices map[string]bool
+	ips     map[string]bool
+}
+
+// NewBlocklist 创建黑名单规则
+func NewBlocklist(users, devices, ips []string) *Blocklist {
+	toSet := func(values []string) map[string]bool {
+		set := make(map[string]bool, len(values))
+		for _, value := range values {
+			set[value] = true
+		}
+		return set
+	}
+	return &Blocklist{users: toSet(users), devices: toSet(devices), ips: toSet(ips)}
+}
+
+// Name 规则名称，保存在风控事件的rule字段中
+func (r *Blocklist) Name() string {
+	return "blocklist"
+}
+
+// Check 用户、设备或IP任一在黑名单中时拒绝，设备和IP为空时不检查
+func (r *Blocklist) Check(ctx context.Context, subject Subject) (Decision, string, error) {
+	switch {
+	case r.users[subject.UserID]:
+		return DecisionDeny, "user is blocked", nil
+	case subject.DeviceID != "" && r.devices[subject.DeviceID]:
+		return DecisionDeny, "device is blocked", nil
+	case subject.IP != "" && r.ips[subject.IP]:
+		return DecisionDeny, "ip is blocked", nil
+	}
+	return DecisionAllow, "", nil
+}
+
+// DeviceSharing 设备共用规则，窗口内同一设备上参与的账号数超过上限时拒绝
+type DeviceSharing struct {
+	history  History
+	maxUsers int64         // 每个设备允许的账号数，包含当前用户
+	window   time.Duration // 统计窗口
+	missing  Decision      // 未上报设备指纹时的决策
+	now      func() time.Time
+}
+
+// NewDeviceSharing 创建设备共用规则，missing为未上报设备指纹时的决策
+func NewDeviceSharing(history History, maxUsers int64, window time.Duration, missing Decision) *DeviceSharing {
+	return &DeviceSharing{history: history, maxUsers: maxUsers, window: window, missing: missing, now: time.Now}
+}
+
+// Name 规则名称，保存在风控事件的rule字段中
+func (r *DeviceSharing) Name() string {
+	return "device_sharing"
+}
+
+// Check 统计窗口内设备上被放行过的其他账号，加上当前用户超过上限时拒绝
+// 未上报设备指纹时无法统计，返回配置的决策，避免客户端不上报指纹即可绕过规则
+func (r *DeviceSharing) Check(ctx context.Context, subject Subject) (Decision, string, error) {
+	if subject.DeviceID == "" {
+		return r.missing, missingReason(r.missing, "device id"), nil
+	}
+	others, err := r.history.CountDeviceUsers(ctx, subject.DeviceID, subject.UserID, r.now().Add(-r.window))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to count device users: %w", err)
+	}
+	if others+1 > r.maxUsers {
+		return DecisionDeny, fmt.Sprintf("device shared by %d users", others+1), nil
+	}
+	return DecisionAllow, "", nil
+}
+
+// DeviceWins 设备中奖频率规则，窗口内同一设备的中奖次数达到上限时拒绝
+type DeviceWins struct {
+	history History
+	maxWins int64         // 窗口内每个设备允许的中奖次数
+	window  time.Duration // 统计窗口，如24小时
+	missing Decision      // 未上报设备指纹时的决策
+	now     func() time.Time
+}
+
+// NewDeviceWins 创建设备中奖频率规则，missing为未上报设备指纹时的决策
+func NewDeviceWins(history History, maxWins int64, window time.Duration, missing Decision) *DeviceWins {
+	return &DeviceWins{history: history, maxWins: maxWins, window: window, missing: missing, now: time.Now}
+}
+
+// Name 规则名称，保存在风控事件的rule字段中
+func (r *DeviceWins) Name() string {
+	return "device_wins"
+}
+
+// Check 统计窗口内设备的中奖次数，达到上限时拒绝；未上报设备指纹时返回配置的决策
+func (r *DeviceWins) Check(ctx context.Context, subject Subject) (Decision, string, error) {
+	if subject.DeviceID == "" {
+		return r.missing, missingReason(r.missing, "device id"), nil
+	}
+	wins, err := r.history.CountDeviceWins(ctx, subject.DeviceID, r.now().Add(-r.window))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to count device wins: %w", err)
+	}
+	if wins >= r.maxWins {
+		return DecisionDeny, fmt.Sprintf("device won %d times in %s", wins, r.window), nil
+	}
+	return DecisionAllow, "", nil
+}
+
+// NewAccountCooldown 新账号冷静期规则，注册时间不足冷静期的账号需要完成验证
+type NewAccountCooldown struct {
+	cooldown time.Duration
+	missing  Decision // 令牌未携带注册时间时的决策
+	now      func() time.Time
+}
+
+// NewNewAccountCooldown 创建新账号冷静期规则，missing为令牌未携带注册时间时的决策
+func NewNewAccountCooldown(cooldown time.Duration, missing Decision) *NewAccountCooldown {
+	return &NewAccountCooldown{cooldown: cooldown, missing: missing, now: time.Now}
+}
+
+// Name 规则名称，保存在风控事件的rule字段中
+func (r *NewAccountCooldown) Name() string {
+	return "new_account"
+}
+
+// Check 注册时间不足冷静期时需要验证；令牌未携带注册时间时无法判断账号年龄，返回配置的决策
+func (r *NewAccountCooldown) Check(ctx context.Context, subject Subject) (Decision, string, error) {
+	if subject.RegisteredAt.IsZero() {
+		return r.missing, missingReason(r.missing, "registered_at"), nil
+	}
+	if age := r.now().Sub(subject.RegisteredAt); age < r.cooldown {
+		return DecisionChallenge, fmt.Sprintf("account registered %s ago", age.Truncate(time.Second)), nil
+	}
+	return DecisionAllow, "", nil
+}
+
+// missingReason 缺少规则所需数据时的原因，放行时为空
+func missingReason(decision Decision, field string) string {
+	if decision == DecisionAllow {
+		return ""
+	}
+	return "missing " + field
+}
@@ -0,0 +1,21 @@
+package scheduler
+
+import "time"
+
+// Clock 时钟，调度器通过它获取当前时间，测试时可以替换为可控的时钟
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock 系统时钟
+type systemClock struct{}
+
+// Now 返回系统当前时间
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock 返回系统时钟
+func SystemClock() Clock {
+	return systemClock{}
+}
@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// HookPoint 调度器触发钩子的时机
+type HookPoint string
+
+const (
+	HookWarmUp     HookPoint = "warm_up"     // 活动即将开始，用于预热缓存、库存等
+	HookOpened     HookPoint = "opened"      // 活动到达开始时间，已自动上线
+	HookSettle     HookPoint = "settle"      // 活动到达结束时间，已自动结束，用于结算
+	HookGameClosed HookPoint = "game_closed" // 玩法奖品库存耗尽，已自动关闭
+)
+
+// HookPoints 所有的钩子时机，用于注册通知类钩子
+var HookPoints = []HookPoint{HookWarmUp, HookOpened, HookSettle, HookGameClosed}
+
+// Event 钩子事件
+type Event struct {
+	Point      HookPoint // 触发时机
+	ActivityID int64     // 活动ID
+	GameName   string    // 玩法名称，仅玩法相关的事件有值
+	At         time.Time // 触发时间，取自调度器时钟
+}
+
+// Hook 钩子函数，返回的错误只记录日志，不会重试，因此钩子需要自行保证幂等
+type Hook func(ctx context.Context, event Event) error
+
+// LogHook 将事件写入日志的通知钩子
+func LogHook(ctx context.Context, event Event) error {
+	if event.GameName != "" {
+		log.Printf("scheduler: %s activity=%d game=%s at=%s", event.Point, event.ActivityID, event.GameName, event.At.Format(time.RFC3339))
+		return nil
+	}
+	log.Printf("scheduler: %s activity=%d at=%s", event.Point, event.ActivityID, event.At.Format(time.RFC3339))
+	return nil
+}
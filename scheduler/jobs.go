@@ -0,0 +1,30 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// JobDiscountCodeExpire 折扣码过期任务的名称，同时作为任务锁的名称
+const JobDiscountCodeExpire = "discount_code_expire"
+
+// DiscountCodeExpirer 折扣码过期处理，由折扣码池仓储实现
+type DiscountCodeExpirer interface {
+	// Expire 将已过期但未核销的折扣码标记为过期，返回处理的数量
+	Expire(ctx context.Context, now time.Time) (int64, error)
+}
+
+// ExpireDiscountCodes 返回折扣码过期任务，通过AddJob注册后每轮将到期的折扣码标记为过期
+func ExpireDiscountCodes(codes DiscountCodeExpirer) func(ctx context.Context, now time.Time) error {
+	return func(ctx context.Context, now time.Time) error {
+		expired, err := codes.Expire(ctx, now)
+		if err != nil {
+			return err
+		}
+		if expired > 0 {
+			log.Printf("scheduler: expired %d discount codes at=%s", expired, now.Format(time.RFC3339))
+		}
+		return nil
+	}
+}
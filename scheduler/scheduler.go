@@ -0,0 +1,340 @@
+package scheduler
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 默认配置
+const (
+	defaultInterval   = 10 * time.Second
+	defaultWarmUpLead = 10 * time.Minute
+)
+
+// 任务名称，同时作为锁表中的锁名
+const (
+	jobActivityOpen  = "activity_open"
+	jobActivityClose = "activity_close"
+	jobGameStock     = "game_stock"
+)
+
+// ActivityRepository 调度器使用的活动仓储
+type ActivityRepository interface {
+	FindByStatus(ctx context.Context, statuses ...int64) ([]*entity.Activity, error)
+	Transition(ctx context.Context, activity *entity.Activity, history *entity.ActivityStatusHistory) error
+	Update(ctx context.Context, activity *entity.Activity, stockDeltas map[string]int64) error
+	GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error)
+}
+
+// Config 调度器配置
+type Config struct {
+	Interval   time.Duration // 轮询间隔，默认10秒
+	WarmUpLead time.Duration // 活动开始前多久触发预热钩子，默认10分钟
+	LockTTL    time.Duration // 任务锁有效期，持有者宕机后超过该时间由其他实例接管，默认3倍轮询间隔
+	Holder     string        // 当前实例标识，默认为 主机名-进程号
+	Clock      Clock         // 时钟，默认系统时钟
+}
+
+// job 调度任务，每个任务由持有对应锁的实例执行
+type job struct {
+	name string
+	run  func(ctx context.Context, now time.Time) error
+}
+
+// Scheduler 活动调度器：到达开始时间自动上线、到达结束时间自动结束、奖品库存耗尽时关闭玩法，并在这些时刻触发钩子
+// 多副本部署时通过锁表选举，每个任务同一时刻只有一个实例执行
+type Scheduler struct {
+	activityRepo ActivityRepository
+	locks        repository.SchedulerLockRepository
+	cfg          Config
+	jobs         []job
+
+	mu       sync.Mutex
+	hooks    map[HookPoint][]Hook
+	warmedUp map[int64]int64 // 已触发预热的活动ID及当时的开始时间，开始时间被修改后会重新预热
+}
+
+// NewScheduler 创建调度器，未设置的配置使用默认值
+func NewScheduler(activityRepo ActivityRepository, locks repository.SchedulerLockRepository, cfg Config) *Scheduler {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.WarmUpLead <= 0 {
+		cfg.WarmUpLead = defaultWarmUpLead
+	}
+	if cfg.LockTTL <= 0 {
+		cfg.LockTTL = 3 * cfg.Interval
+	}
+	if cfg.Holder == "" {
+		hostname, _ := os.Hostname()
+		cfg.Holder = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock()
+	}
+
+	s := &Scheduler{
+		activityRepo: activityRepo,
+		locks:        locks,
+		cfg:          cfg,
+		hooks:        make(map[HookPoint][]Hook),
+		warmedUp:     make(map[int64]int64),
+	}
+	s.jobs = []job{
+		{name: jobActivityOpen, run: s.openActivities},
+		{name: jobActivityClose, run: s.closeActivities},
+		{name: jobGameStock, run: s.closeSoldOutGames},
+	}
+	return s
+}
+
+// On 注册钩子，同一时机的钩子按注册顺序执行
+func (s *Scheduler) On(point HookPoint, hook Hook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks[point] = append(s.hooks[point], hook)
+}
+
+// AddJob 注册自定义任务，与内置任务一样按轮询间隔执行，并通过同名的锁保证同一时刻只有一个实例执行
+// 需要在Run之前调用
+func (s *Scheduler) AddJob(name string, run func(ctx context.Context, now time.Time) error) {
+	s.jobs = append(s.jobs, job{name: name, run: run})
+}
+
+// Run 按轮询间隔执行任务，直到ctx取消；退出时释放持有的任务锁
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+	defer s.release()
+
+	for {
+		s.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick 执行一轮任务，只执行当前实例获取到锁的任务
+func (s *Scheduler) Tick(ctx context.Context) {
+	for _, j := range s.jobs {
+		now := s.cfg.Clock.Now()
+		acquired, err := s.locks.TryAcquire(ctx, j.name, s.cfg.Holder, now, s.cfg.LockTTL)
+		if err != nil {
+			log.Printf("scheduler: failed to acquire lock %s: %v", j.name, err)
+			continue
+		}
+		if !acquired {
+			continue
+		}
+		if err := j.run(ctx, now); err != nil {
+			log.Printf("scheduler: job %s failed: %v", j.name, err)
+		}
+	}
+}
+
+// release 释放当前实例持有的任务锁，使用独立的上下文，避免ctx取消后无法释放
+func (s *Scheduler) release() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, j := range s.jobs {
+		if err := s.locks.Release(ctx, j.name, s.cfg.Holder); err != nil {
+			log.Printf("scheduler: failed to release lock %s: %v", j.name, err)
+		}
+	}
+}
+
+// openActivities 已排期的活动在开始前触发预热，到达开始时间后上线
+func (s *Scheduler) openActivities(ctx context.Context, now time.Time) error {
+	activities, err := s.activityRepo.FindByStatus(ctx, models.ActivityStatusScheduled)
+	if err != nil {
+		return fmt.Errorf("failed to find scheduled activities: %w", err)
+	}
+
+	for _, activity := range activities {
+		if now.Unix() < activity.StartAt {
+			if now.Add(s.cfg.WarmUpLead).Unix() >= activity.StartAt && s.markWarmedUp(activity) {
+				s.fire(ctx, Event{Point: HookWarmUp, ActivityID: activity.ID, At: now})
+			}
+			continue
+		}
+
+		opened, err := s.transition(ctx, activity, models.ActivityEventPublish, "start_at reached")
+		if err != nil {
+			log.Printf("scheduler: %v", err)
+			continue
+		}
+		if opened {
+			s.forgetWarmedUp(activity.ID)
+			s.fire(ctx, Event{Point: HookOpened, ActivityID: activity.ID, At: now})
+		}
+	}
+	return nil
+}
+
+// closeActivities 进行中或暂停的活动到达结束时间后结束，并触发结算
+func (s *Scheduler) closeActivities(ctx context.Context, now time.Time) error {
+	activities, err := s.activityRepo.FindByStatus(ctx, models.ActivityStatusOnline, models.ActivityStatusPaused)
+	if err != nil {
+		return fmt.Errorf("failed to find running activities: %w", err)
+	}
+
+	for _, activity := range activities {
+		if now.Unix() <= activity.EndAt {
+			continue
+		}
+		ended, err := s.transition(ctx, activity, models.ActivityEventEnd, "end_at reached")
+		if err != nil {
+			log.Printf("scheduler: %v", err)
+			continue
+		}
+		if ended {
+			s.fire(ctx, Event{Point: HookSettle, ActivityID: activity.ID, At: now})
+		}
+	}
+	return nil
+}
+
+// closeSoldOutGames 进行中活动的玩法奖品库存全部发完后，将玩法状态改为CLOSED
+func (s *Scheduler) closeSoldOutGames(ctx context.Context, now time.Time) error {
+	activities, err := s.activityRepo.FindByStatus(ctx, models.ActivityStatusOnline)
+	if err != nil {
+		return fmt.Errorf("failed to find online activities: %w", err)
+	}
+
+	for _, activity := range activities {
+		if err := s.closeSoldOutGamesOf(ctx, activity, now); err != nil {
+			log.Printf("scheduler: %v", err)
+		}
+	}
+	return nil
+}
+
+// closeSoldOutGamesOf 检查单个活动的玩法库存，一个活动出错不影响其他活动
+func (s *Scheduler) closeSoldOutGamesOf(ctx context.Context, activity *entity.Activity, now time.Time) error {
+	domain, err := s.activityRepo.GetActivity(ctx, strconv.FormatInt(activity.ID, 10))
+	if err != nil {
+		return fmt.Errorf("failed to get activity %d: %w", activity.ID, err)
+	}
+
+	soldOut := make(map[string]bool)
+	for _, game := range domain.Games() {
+		if game.GameState(ctx) != models.GameStateOPEN {
+			continue
+		}
+		exhausted, err := models.StockExhausted(ctx, game)
+		if err != nil {
+			return fmt.Errorf("failed to check stock of activity %d: %w", activity.ID, err)
+		}
+		if exhausted {
+			soldOut[game.Name(ctx)] = true
+		}
+	}
+	if len(soldOut) == 0 {
+		return nil
+	}
+
+	closed, err := s.closeGames(ctx, activity, soldOut)
+	if err != nil || !closed {
+		return err
+	}
+	for name := range soldOut {
+		s.fire(ctx, Event{Point: HookGameClosed, ActivityID: activity.ID, GameName: name, At: now})
+	}
+	return nil
+}
+
+// closeGames 将活动配置中指定玩法的状态改为CLOSED，活动在此期间被修改时跳过，等待下一轮重新检查
+func (s *Scheduler) closeGames(ctx context.Context, activity *entity.Activity, names map[string]bool) (bool, error) {
+	var config models.ActivityConfigJSON
+	if err := json.Unmarshal([]byte(activity.Config), &config); err != nil {
+		return false, fmt.Errorf("failed to unmarshal activity %d config: %w", activity.ID, err)
+	}
+	for i, game := range config.Games {
+		if !names[game.Name] {
+			continue
+		}
+		closed, err := models.SetGameConfigState(game, models.GameStateCLOSED)
+		if err != nil {
+			return false, err
+		}
+		config.Games[i] = closed
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal activity %d config: %w", activity.ID, err)
+	}
+
+	updated := *activity
+	updated.Config = string(data)
+	if err := s.activityRepo.Update(ctx, &updated, nil); err != nil {
+		if errors.Is(err, repository.ErrRevisionConflict) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to close games of activity %d: %w", activity.ID, err)
+	}
+	return true, nil
+}
+
+// transition 按操作变更活动状态并记录原因，活动在此期间被修改时跳过，等待下一轮重新检查
+func (s *Scheduler) transition(ctx context.Context, activity *entity.Activity, event models.ActivityEvent, reason string) (bool, error) {
+	to, err := models.NextActivityStatus(activity.Status, event)
+	if err != nil {
+		return false, err
+	}
+	err = s.activityRepo.Transition(ctx, activity, &entity.ActivityStatusHistory{
+		Event:    event,
+		ToStatus: to,
+		Reason:   "scheduler: " + reason,
+	})
+	if errors.Is(err, repository.ErrRevisionConflict) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to %s activity %d: %w", event, activity.ID, err)
+	}
+	return true, nil
+}
+
+// markWarmedUp 记录活动已预热，已经按当前开始时间预热过时返回false
+func (s *Scheduler) markWarmedUp(activity *entity.Activity) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if startAt, ok := s.warmedUp[activity.ID]; ok && startAt == activity.StartAt {
+		return false
+	}
+	s.warmedUp[activity.ID] = activity.StartAt
+	return true
+}
+
+// forgetWarmedUp 活动上线后清除预热记录
+func (s *Scheduler) forgetWarmedUp(activityID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.warmedUp, activityID)
+}
+
+// fire 依次执行时机对应的钩子，钩子出错只记录日志
+func (s *Scheduler) fire(ctx context.Context, event Event) {
+	s.mu.Lock()
+	hooks := append([]Hook(nil), s.hooks[event.Point]...)
+	s.mu.Unlock()
+
+	for _, hook := range hooks {
+		if err := hook(ctx, event); err != nil {
+			log.Printf("scheduler: hook %s for activity %d failed: %v", event.Point, event.ActivityID, err)
+		}
+	}
+}
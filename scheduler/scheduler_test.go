@@ -0,0 +1,300 @@
+package scheduler
+
+import (
+	"Activity/config"
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock 可手动拨动的时钟
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// fakeLocks 内存中的任务锁，语义与锁表一致
+type fakeLocks struct {
+	mu    sync.Mutex
+	locks map[string]entity.SchedulerLock
+}
+
+func newFakeLocks() *fakeLocks {
+	return &fakeLocks{locks: make(map[string]entity.SchedulerLock)}
+}
+
+func (l *fakeLocks) TryAcquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lock, ok := l.locks[name]; ok && lock.Holder != holder && !lock.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	l.locks[name] = entity.SchedulerLock{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (l *fakeLocks) Release(ctx context.Context, name, holder string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks[name].Holder == holder {
+		delete(l.locks, name)
+	}
+	return nil
+}
+
+// fakeActivities 内存中的活动仓储，状态变更和更新同样以状态、修订号为前置条件
+type fakeActivities struct {
+	activities map[int64]*entity.Activity
+	histories  []*entity.ActivityStatusHistory
+	remain     int64 // 所有奖品的剩余库存
+}
+
+func (r *fakeActivities) FindByStatus(ctx context.Context, statuses ...int64) ([]*entity.Activity, error) {
+	var result []*entity.Activity
+	for _, activity := range r.activities {
+		for _, status := range statuses {
+			if activity.Status == status {
+				copied := *activity
+				result = append(result, &copied)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *fakeActivities) Transition(ctx context.Context, activity *entity.Activity, history *entity.ActivityStatusHistory) error {
+	stored := r.activities[activity.ID]
+	if stored.Status != activity.Status || stored.Revision != activity.Revision {
+		return repository.ErrRevisionConflict
+	}
+	history.ActivityID = activity.ID
+	history.FromStatus = activity.Status
+	r.histories = append(r.histories, history)
+	stored.Status = history.ToStatus
+	stored.Revision++
+	return nil
+}
+
+func (r *fakeActivities) Update(ctx context.Context, activity *entity.Activity, stockDeltas map[string]int64) error {
+	stored := r.activities[activity.ID]
+	if stored.Revision != activity.Revision {
+		return repository.ErrRevisionConflict
+	}
+	stored.Config = activity.Config
+	stored.Revision++
+	return nil
+}
+
+func (r *fakeActivities) GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error) {
+	id, _ := strconv.ParseInt(activityID, 10, 64)
+	domain, err := models.NewActivityFromConfig([]byte(r.activities[id].Config))
+	if err != nil {
+		return nil, err
+	}
+	models.BindRuntime(domain, &models.Runtime{ActivityID: id, Prizes: r})
+	return domain, nil
+}
+
+func (r *fakeActivities) Issue(ctx context.Context, req models.PrizeIssue) (*models.PrizeGrant, error) {
+	return nil, models.ErrPrizeStockEmpty
+}
+
+func (r *fakeActivities) Remain(ctx context.Context, activityID int64, prizeKey string) (int64, bool, error) {
+	return r.remain, true, nil
+}
+
+// recordHooks 在所有时机注册钩子，返回按顺序记录的事件
+func recordHooks(s *Scheduler) *[]Event {
+	events := &[]Event{}
+	for _, point := range HookPoints {
+		s.On(point, func(ctx context.Context, event Event) error {
+			*events = append(*events, event)
+			return nil
+		})
+	}
+	return events
+}
+
+func TestSchedulerOpensAndClosesActivities(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	repo := &fakeActivities{activities: map[int64]*entity.Activity{
+		1: {
+			ID:      1,
+			Config:  config.LotteryActivityExample,
+			StartAt: clock.now.Add(5 * time.Minute).Unix(),
+			EndAt:   clock.now.Add(time.Hour).Unix(),
+			Status:  models.ActivityStatusScheduled,
+		},
+	}, remain: 10}
+	s := NewScheduler(repo, newFakeLocks(), Config{WarmUpLead: 10 * time.Minute, Clock: clock})
+	events := recordHooks(s)
+
+	// 开始前进入预热窗口，只预热一次
+	s.Tick(ctx)
+	s.Tick(ctx)
+	if len(*events) != 1 || (*events)[0].Point != HookWarmUp {
+		t.Fatalf("events = %+v, want one warm-up", *events)
+	}
+
+	// 到达开始时间自动上线
+	clock.Advance(5 * time.Minute)
+	s.Tick(ctx)
+	if got := repo.activities[1].Status; got != models.ActivityStatusOnline {
+		t.Fatalf("status = %s, want online", models.ActivityStatusName(got))
+	}
+
+	// 到达结束时间自动结束并结算
+	clock.Advance(time.Hour)
+	s.Tick(ctx)
+	if got := repo.activities[1].Status; got != models.ActivityStatusEnded {
+		t.Fatalf("status = %s, want ended", models.ActivityStatusName(got))
+	}
+
+	want := []HookPoint{HookWarmUp, HookOpened, HookSettle}
+	if len(*events) != len(want) {
+		t.Fatalf("events = %+v, want %v", *events, want)
+	}
+	for i, point := range want {
+		if (*events)[i].Point != point {
+			t.Errorf("events[%d] = %s, want %s", i, (*events)[i].Point, point)
+		}
+	}
+	if len(repo.histories) != 2 || repo.histories[0].Event != models.ActivityEventPublish || repo.histories[1].Event != models.ActivityEventEnd {
+		t.Errorf("histories = %+v, want publish then end", repo.histories)
+	}
+}
+
+func TestSchedulerOnlyLeaderRunsJobs(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	repo := &fakeActivities{activities: map[int64]*entity.Activity{
+		1: {
+			ID:      1,
+			Config:  config.LotteryActivityExample,
+			StartAt: clock.now.Add(-time.Minute).Unix(),
+			EndAt:   clock.now.Add(time.Hour).Unix(),
+			Status:  models.ActivityStatusScheduled,
+		},
+	}, remain: 10}
+	locks := newFakeLocks()
+	leader := NewScheduler(repo, locks, Config{Interval: time.Second, Holder: "leader", Clock: clock})
+	follower := NewScheduler(repo, locks, Config{Interval: time.Second, Holder: "follower", Clock: clock})
+
+	// 锁被其他实例持有时不执行任务
+	if _, err := locks.TryAcquire(ctx, jobActivityOpen, "leader", clock.now, 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	follower.Tick(ctx)
+	if got := repo.activities[1].Status; got != models.ActivityStatusScheduled {
+		t.Fatalf("follower changed status to %s", models.ActivityStatusName(got))
+	}
+
+	// 持有者宕机，锁过期后由其他实例接管
+	clock.Advance(4 * time.Second)
+	follower.Tick(ctx)
+	if got := repo.activities[1].Status; got != models.ActivityStatusOnline {
+		t.Fatalf("status = %s, want online after takeover", models.ActivityStatusName(got))
+	}
+	if got := locks.locks[jobActivityOpen].Holder; got != "follower" {
+		t.Errorf("lock holder = %s, want follower", got)
+	}
+
+	// 原持有者恢复后不能抢占未过期的锁
+	if acquired, _ := locks.TryAcquire(ctx, jobActivityOpen, leader.cfg.Holder, clock.now, time.Second); acquired {
+		t.Error("leader acquired a lock held by follower")
+	}
+}
+
+func TestSchedulerClosesSoldOutGames(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	repo := &fakeActivities{activities: map[int64]*entity.Activity{
+		1: {
+			ID:      1,
+			Config:  config.LotteryActivityExample,
+			StartAt: clock.now.Add(-time.Minute).Unix(),
+			EndAt:   clock.now.Add(time.Hour).Unix(),
+			Status:  models.ActivityStatusOnline,
+		},
+	}, remain: 1}
+	s := NewScheduler(repo, newFakeLocks(), Config{Clock: clock})
+	events := recordHooks(s)
+
+	// 还有库存时不关闭
+	s.Tick(ctx)
+	if len(*events) != 0 {
+		t.Fatalf("events = %+v, want none", *events)
+	}
+
+	// 库存耗尽后关闭玩法，只触发一次
+	repo.remain = 0
+	s.Tick(ctx)
+	s.Tick(ctx)
+	if len(*events) != 1 || (*events)[0].Point != HookGameClosed || (*events)[0].GameName != "幸运抽奖" {
+		t.Fatalf("events = %+v, want one game_closed for 幸运抽奖", *events)
+	}
+
+	var config models.ActivityConfigJSON
+	if err := json.Unmarshal([]byte(repo.activities[1].Config), &config); err != nil {
+		t.Fatal(err)
+	}
+	var game struct {
+		State   string `json:"state"`
+		MaxDraw int64  `json:"max_draws"`
+	}
+	if err := json.Unmarshal(config.Games[0].Config, &game); err != nil {
+		t.Fatal(err)
+	}
+	if game.State != models.GameStateCLOSED || game.MaxDraw != 3 {
+		t.Errorf("game config = %+v, want CLOSED with other fields kept", game)
+	}
+}
+
+// fakeExpirer 记录每次过期处理时间的折扣码池
+type fakeExpirer struct {
+	calls []time.Time
+}
+
+func (e *fakeExpirer) Expire(ctx context.Context, now time.Time) (int64, error) {
+	e.calls = append(e.calls, now)
+	return 1, nil
+}
+
+func TestSchedulerExpiresDiscountCodes(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	repo := &fakeActivities{activities: map[int64]*entity.Activity{}}
+	locks := newFakeLocks()
+	expirer := &fakeExpirer{}
+	leader := NewScheduler(repo, locks, Config{Holder: "leader", Clock: clock})
+	follower := NewScheduler(repo, locks, Config{Holder: "follower", Clock: clock})
+	for _, s := range []*Scheduler{leader, follower} {
+		s.AddJob(JobDiscountCodeExpire, ExpireDiscountCodes(expirer))
+	}
+
+	// 每轮按调度器时钟处理一次，多个实例中只有持有锁的实例执行
+	leader.Tick(ctx)
+	follower.Tick(ctx)
+	clock.Advance(time.Second)
+	leader.Tick(ctx)
+	if len(expirer.calls) != 2 || !expirer.calls[1].Equal(clock.now) {
+		t.Fatalf("expire calls = %v, want 2 by leader at clock time", expirer.calls)
+	}
+	if got := locks.locks[JobDiscountCodeExpire].Holder; got != "leader" {
+		t.Errorf("lock holder = %s, want leader", got)
+	}
+}
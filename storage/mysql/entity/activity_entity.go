@@ -8,17 +8,19 @@ import (
 
 // Activity 活动表实体
 type Activity struct {
-	ID        int64          `gorm:"primaryKey;autoIncrement"`
-	Category  string         `gorm:"type:varchar(50);not null;index:idx_category"`
-	Version   string         `gorm:"type:varchar(20);not null"`
-	Name      string         `gorm:"type:varchar(100);not null"`
-	Config    string         `gorm:"type:json;not null"`
-	StartAt   int64          `gorm:"not null;index:idx_status_time"`
-	EndAt     int64          `gorm:"not null;index:idx_status_time"`
-	Status    int64          `gorm:"type:tinyint;not null;default:0;index:idx_status_time"`
-	CreatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID               int64          `gorm:"primaryKey;autoIncrement"`
+	Category         string         `gorm:"type:varchar(50);not null;index:idx_category"`
+	Version          string         `gorm:"type:varchar(20);not null"`
+	Name             string         `gorm:"type:varchar(100);not null"`
+	Config           string         `gorm:"type:json;not null"`
+	StartAt          int64          `gorm:"not null;index:idx_status_time"`
+	EndAt            int64          `gorm:"not null;index:idx_status_time"`
+	Status           int64          `gorm:"type:tinyint;not null;default:0;index:idx_status_time"`
+	ParticipantCount int64          `gorm:"not null;default:0"` // 已报名人数
+	Revision         int64          `gorm:"not null;default:0"` // 修订号，每次更新加1
+	CreatedAt        time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt        time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
 }
 
 // TableName 指定表名
@@ -26,15 +28,47 @@ func (Activity) TableName() string {
 	return "activities"
 }
 
+// ActivityStatusHistory 活动状态变更记录表实体
+type ActivityStatusHistory struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	ActivityID int64     `gorm:"not null;index:idx_activity"`
+	Event      string    `gorm:"type:varchar(20);not null"`
+	FromStatus int64     `gorm:"type:tinyint;not null"`
+	ToStatus   int64     `gorm:"type:tinyint;not null"`
+	Operator   string    `gorm:"type:varchar(50);not null;default:''"`
+	Reason     string    `gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (ActivityStatusHistory) TableName() string {
+	return "activity_status_histories"
+}
+
+// ActivityEnrollment 活动报名表实体
+type ActivityEnrollment struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	ActivityID int64     `gorm:"not null;uniqueIndex:uk_activity_user"`
+	UserID     string    `gorm:"type:varchar(50);not null;uniqueIndex:uk_activity_user"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (ActivityEnrollment) TableName() string {
+	return "activity_enrollments"
+}
+
 // ActivityParticipation 用户参与记录表实体
 type ActivityParticipation struct {
 	ID         int64          `gorm:"primaryKey;autoIncrement"`
-	ActivityID int64          `gorm:"not null;index:idx_activity_user"`
-	UserID     string         `gorm:"type:varchar(50);not null;index:idx_activity_user,idx_user_state"`
+	ActivityID int64          `gorm:"not null;index:idx_activity_user;uniqueIndex:uk_user_game_seq,priority:1"`
+	UserID     string         `gorm:"type:varchar(50);not null;index:idx_activity_user,idx_user_state;uniqueIndex:uk_user_game_seq,priority:2"`
 	GameType   string         `gorm:"type:varchar(50);not null"`
-	GameTarget string         `gorm:"type:varchar(50);not null"`
+	GameTarget string         `gorm:"type:varchar(100);not null;uniqueIndex:uk_user_game_seq,priority:3"`
 	State      string         `gorm:"type:varchar(20);not null;index:idx_user_state"`
+	Seq        *int64         `gorm:"uniqueIndex:uk_user_game_seq,priority:4"` // 用户在玩法中的第几次参与，Redis异步落库的记录为空
 	Extra      string         `gorm:"type:json"`
+	FlushID    *string        `gorm:"type:varchar(64);uniqueIndex:uk_flush_id"` // 由Redis异步落库时的流水ID，用于重复落库时去重
 	CreatedAt  time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
 	UpdatedAt  time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
 	DeletedAt  gorm.DeletedAt `gorm:"index"`
@@ -45,14 +79,109 @@ func (ActivityParticipation) TableName() string {
 	return "activity_participations"
 }
 
+// 参与请求状态
+const (
+	ParticipationRequestStatusPending   = 0 // 处理中
+	ParticipationRequestStatusCompleted = 1 // 已完成
+	ParticipationRequestStatusFailed    = 2 // 玩法执行后失败
+)
+
+// ParticipationRequest 参与请求表实体，记录携带幂等键的参与请求及其响应，客户端重试时直接返回原响应
+type ParticipationRequest struct {
+	ID             int64     `gorm:"primaryKey;autoIncrement"`
+	UserID         string    `gorm:"type:varchar(50);not null;uniqueIndex:uk_user_key"`
+	IdempotencyKey string    `gorm:"type:varchar(64);not null;uniqueIndex:uk_user_key"`
+	ActivityID     int64     `gorm:"not null"`
+	GameName       string    `gorm:"type:varchar(100);not null"`
+	Status         int64     `gorm:"type:tinyint;not null;default:0"`
+	Response       *string   `gorm:"type:json"` // 首次执行的响应数据，失败时为错误信息
+	ExpiresAt      time.Time `gorm:"not null"`  // 处理中请求的租约到期时间，处理请求的实例宕机后由重试接管
+	CreatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (ParticipationRequest) TableName() string {
+	return "participation_requests"
+}
+
+// 风控决策
+const (
+	RiskDecisionAllow     = "allow"     // 放行
+	RiskDecisionDeny      = "deny"      // 拒绝
+	RiskDecisionChallenge = "challenge" // 需要用户完成额外验证
+)
+
+// RiskEvent 风控事件表实体，记录每次参与玩法前的风控决策，放行后是否中奖用于统计设备的中奖频率
+type RiskEvent struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	ActivityID int64     `gorm:"not null"`
+	GameName   string    `gorm:"type:varchar(100);not null"`
+	UserID     string    `gorm:"type:varchar(50);not null"`
+	DeviceID   string    `gorm:"type:varchar(128);not null;default:'';index:idx_device_created,priority:1"`
+	IP         string    `gorm:"type:varchar(64);not null;default:''"`
+	Decision   string    `gorm:"type:varchar(20);not null"`
+	Rule       string    `gorm:"type:varchar(50);not null;default:''"` // 做出非放行决策的规则
+	Reason     string    `gorm:"type:varchar(255);not null;default:''"`
+	Won        bool      `gorm:"not null;default:false"` // 放行后是否获得奖品
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_device_created,priority:2"`
+}
+
+// TableName 指定表名
+func (RiskEvent) TableName() string {
+	return "risk_events"
+}
+
+// CheckinRecord 用户签到记录表实体，每个用户每个自然日一条
+type CheckinRecord struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement"`
+	ActivityID  int64     `gorm:"not null;uniqueIndex:uk_activity_game_user_date"`
+	GameName    string    `gorm:"type:varchar(100);not null;uniqueIndex:uk_activity_game_user_date"`
+	UserID      string    `gorm:"type:varchar(50);not null;uniqueIndex:uk_activity_game_user_date"`
+	CheckinDate string    `gorm:"type:char(10);not null;uniqueIndex:uk_activity_game_user_date"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (CheckinRecord) TableName() string {
+	return "checkin_records"
+}
+
+// PrizeInventory 奖品库存表实体，库存扣减通过条件更新保证不超发
+type PrizeInventory struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	ActivityID int64     `gorm:"not null;uniqueIndex:uk_activity_prize"`
+	PrizeKey   string    `gorm:"type:varchar(100);not null;uniqueIndex:uk_activity_prize"`
+	PrizeType  string    `gorm:"type:varchar(50);not null"`
+	TotalNum   int64     `gorm:"not null"`
+	RemainNum  int64     `gorm:"not null"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (PrizeInventory) TableName() string {
+	return "prize_inventories"
+}
+
+// 奖品发放状态
+const (
+	PrizeRecordStatusPending = 0 // 待发放
+	PrizeRecordStatusIssued  = 1 // 已发放
+	PrizeRecordStatusFailed  = 2 // 发放失败
+)
+
 // PrizeRecord 奖品发放记录表实体
 type PrizeRecord struct {
 	ID         int64          `gorm:"primaryKey;autoIncrement"`
 	ActivityID int64          `gorm:"not null;index:idx_activity_user"`
 	UserID     string         `gorm:"type:varchar(50);not null;index:idx_activity_user"`
+	GameName   string         `gorm:"type:varchar(100);not null;default:''"`
+	PrizeKey   string         `gorm:"type:varchar(100);not null;default:''"`
 	PrizeType  string         `gorm:"type:varchar(50);not null"`
 	PrizeID    string         `gorm:"type:varchar(50);not null"`
 	Status     int64          `gorm:"type:tinyint;not null;default:0;index:idx_status"`
+	FlushID    *string        `gorm:"type:varchar(64);uniqueIndex:uk_flush_id"` // 由Redis异步落库时的流水ID，用于重复落库时去重
 	CreatedAt  time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
 	UpdatedAt  time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
 	DeletedAt  gorm.DeletedAt `gorm:"index"`
@@ -62,3 +191,35 @@ type PrizeRecord struct {
 func (PrizeRecord) TableName() string {
 	return "prize_records"
 }
+
+// LotteryDraw 抽奖记录表实体
+type LotteryDraw struct {
+	ID            int64     `gorm:"primaryKey;autoIncrement"`
+	ActivityID    int64     `gorm:"not null;index:idx_activity_game;index:idx_activity_user"`
+	GameName      string    `gorm:"type:varchar(100);not null;index:idx_activity_game"`
+	UserID        string    `gorm:"type:varchar(50);not null;index:idx_activity_user"`
+	SlotIndex     int       `gorm:"not null"`
+	SlotType      string    `gorm:"type:varchar(50);not null"`
+	PrizeKey      string    `gorm:"type:varchar(100);not null;default:''"`
+	PrizeID       string    `gorm:"type:varchar(50);not null;default:''"`
+	FallbackSlots string    `gorm:"type:varchar(255);not null;default:''"`
+	CreatedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (LotteryDraw) TableName() string {
+	return "lottery_draws"
+}
+
+// SchedulerLock 定时任务锁表实体，每个任务一行，持有者在有效期内独占执行该任务
+type SchedulerLock struct {
+	Name      string    `gorm:"type:varchar(100);primaryKey"`
+	Holder    string    `gorm:"type:varchar(100);not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (SchedulerLock) TableName() string {
+	return "scheduler_locks"
+}
@@ -0,0 +1,23 @@
+package entity
+
+import (
+	"time"
+)
+
+// AdminAuditLog 后台操作日志表实体
+type AdminAuditLog struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement"`
+	Actor      string    `gorm:"type:varchar(50);not null;index:idx_actor"`
+	Roles      string    `gorm:"type:varchar(255);not null;default:''"`
+	Method     string    `gorm:"type:varchar(10);not null"`
+	Path       string    `gorm:"type:varchar(255);not null"`
+	ResourceID string    `gorm:"type:varchar(50);not null;default:''"`
+	StatusCode int       `gorm:"not null"`
+	ClientIP   string    `gorm:"type:varchar(64);not null;default:''"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_created_at"`
+}
+
+// TableName 指定表名
+func (AdminAuditLog) TableName() string {
+	return "admin_audit_logs"
+}
@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"time"
+)
+
+// 折扣码状态
+const (
+	DiscountCodeStatusAvailable = 0 // 未分配
+	DiscountCodeStatusIssued    = 1 // 已发放
+	DiscountCodeStatusRedeemed  = 2 // 已核销
+	DiscountCodeStatusExpired   = 3 // 已过期
+	DiscountCodeStatusReserved  = 4 // 已预留到Redis库存，发放后落库时改为已发放
+)
+
+// DiscountCode 折扣码池表实体，每个码只能分配给一个用户
+type DiscountCode struct {
+	ID            int64      `gorm:"primaryKey;autoIncrement"`
+	ActivityID    int64      `gorm:"not null;index:idx_pool_status"`
+	PrizeKey      string     `gorm:"type:varchar(100);not null;index:idx_pool_status"`
+	Code          string     `gorm:"type:varchar(50);not null;uniqueIndex:uk_code"`
+	Status        int64      `gorm:"type:tinyint;not null;default:0;index:idx_pool_status"`
+	UserID        string     `gorm:"type:varchar(50);not null;default:''"`
+	PrizeRecordID int64      `gorm:"not null;default:0"`
+	ExpiresAt     *time.Time `gorm:"index:idx_expires_at"`
+	IssuedAt      *time.Time
+	RedeemedAt    *time.Time
+	CreatedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"`
+}
+
+// TableName 指定表名
+func (DiscountCode) TableName() string {
+	return "discount_codes"
+}
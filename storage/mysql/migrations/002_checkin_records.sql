@@ -0,0 +1,10 @@
+-- 用户签到记录表
+CREATE TABLE IF NOT EXISTS checkin_records (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    game_name VARCHAR(100) NOT NULL COMMENT '玩法名称',
+    user_id VARCHAR(50) NOT NULL COMMENT '用户ID',
+    checkin_date CHAR(10) NOT NULL COMMENT '签到自然日(YYYY-MM-DD)，按玩法配置的时区划分',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_activity_game_user_date (activity_id, game_name, user_id, checkin_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='用户签到记录表';
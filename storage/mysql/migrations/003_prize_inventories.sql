@@ -0,0 +1,12 @@
+-- 奖品库存表
+CREATE TABLE IF NOT EXISTS prize_inventories (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    prize_key VARCHAR(100) NOT NULL COMMENT '奖品在活动内的唯一标识',
+    prize_type VARCHAR(50) NOT NULL COMMENT '奖品类型',
+    total_num BIGINT NOT NULL COMMENT '总库存',
+    remain_num BIGINT NOT NULL COMMENT '剩余库存',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_activity_prize (activity_id, prize_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='奖品库存表';
@@ -0,0 +1,23 @@
+-- 折扣码池表
+CREATE TABLE IF NOT EXISTS discount_codes (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    prize_key VARCHAR(100) NOT NULL COMMENT '奖品在活动内的唯一标识',
+    code VARCHAR(50) NOT NULL COMMENT '折扣码',
+    status TINYINT NOT NULL DEFAULT 0 COMMENT '状态：0-未分配，1-已发放，2-已核销，3-已过期',
+    user_id VARCHAR(50) NOT NULL DEFAULT '' COMMENT '获得折扣码的用户ID',
+    prize_record_id BIGINT NOT NULL DEFAULT 0 COMMENT '奖品发放记录ID',
+    expires_at TIMESTAMP NULL COMMENT '过期时间，为空表示不过期',
+    issued_at TIMESTAMP NULL COMMENT '发放时间',
+    redeemed_at TIMESTAMP NULL COMMENT '核销时间',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_code (code),
+    INDEX idx_pool_status (activity_id, prize_key, status),
+    INDEX idx_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='折扣码池表';
+
+-- 奖品发放记录关联玩法和奖品
+ALTER TABLE prize_records
+    ADD COLUMN game_name VARCHAR(100) NOT NULL DEFAULT '' COMMENT '玩法名称' AFTER user_id,
+    ADD COLUMN prize_key VARCHAR(100) NOT NULL DEFAULT '' COMMENT '奖品在活动内的唯一标识' AFTER game_name;
@@ -0,0 +1,15 @@
+-- 抽奖记录表
+CREATE TABLE IF NOT EXISTS lottery_draws (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    game_name VARCHAR(100) NOT NULL COMMENT '玩法名称',
+    user_id VARCHAR(50) NOT NULL COMMENT '用户ID',
+    slot_index INT NOT NULL COMMENT '命中的槽位，奖品全部无库存时为-1',
+    slot_type VARCHAR(50) NOT NULL COMMENT '命中的槽位类型',
+    prize_key VARCHAR(100) NOT NULL DEFAULT '' COMMENT '奖品库存标识',
+    prize_id VARCHAR(50) NOT NULL DEFAULT '' COMMENT '发放的奖品标识',
+    fallback_slots VARCHAR(255) NOT NULL DEFAULT '' COMMENT '因无库存被跳过的槽位，逗号分隔',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_activity_game (activity_id, game_name),
+    INDEX idx_activity_user (activity_id, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='抽奖记录表';
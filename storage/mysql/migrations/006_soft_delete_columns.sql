@@ -0,0 +1,12 @@
+-- 实体使用软删除，补齐 deleted_at 列
+ALTER TABLE activities
+    ADD COLUMN deleted_at TIMESTAMP NULL COMMENT '删除时间',
+    ADD INDEX idx_deleted_at (deleted_at);
+
+ALTER TABLE activity_participations
+    ADD COLUMN deleted_at TIMESTAMP NULL COMMENT '删除时间',
+    ADD INDEX idx_deleted_at (deleted_at);
+
+ALTER TABLE prize_records
+    ADD COLUMN deleted_at TIMESTAMP NULL COMMENT '删除时间',
+    ADD INDEX idx_deleted_at (deleted_at);
@@ -0,0 +1,14 @@
+-- 后台操作日志表
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    actor VARCHAR(50) NOT NULL COMMENT '操作人用户ID',
+    roles VARCHAR(255) NOT NULL DEFAULT '' COMMENT '操作人角色，逗号分隔',
+    method VARCHAR(10) NOT NULL COMMENT 'HTTP方法',
+    path VARCHAR(255) NOT NULL COMMENT '路由模板',
+    resource_id VARCHAR(50) NOT NULL DEFAULT '' COMMENT '操作的资源ID',
+    status_code INT NOT NULL COMMENT 'HTTP响应状态码',
+    client_ip VARCHAR(64) NOT NULL DEFAULT '' COMMENT '客户端IP',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_actor (actor),
+    INDEX idx_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='后台操作日志表';
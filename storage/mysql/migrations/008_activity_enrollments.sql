@@ -0,0 +1,12 @@
+-- 活动报名表
+CREATE TABLE IF NOT EXISTS activity_enrollments (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    user_id VARCHAR(50) NOT NULL COMMENT '用户ID',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_activity_user (activity_id, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='活动报名表';
+
+-- 报名人数计数，用于人数上限的条件更新
+ALTER TABLE activities
+    ADD COLUMN participant_count BIGINT NOT NULL DEFAULT 0 COMMENT '已报名人数' AFTER status;
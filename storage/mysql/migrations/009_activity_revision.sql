@@ -0,0 +1,3 @@
+-- 活动修订号，用于更新时的乐观锁
+ALTER TABLE activities
+    ADD COLUMN revision BIGINT NOT NULL DEFAULT 0 COMMENT '修订号，每次更新加1' AFTER participant_count;
@@ -0,0 +1,16 @@
+-- 活动状态变更记录表
+CREATE TABLE IF NOT EXISTS activity_status_histories (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    event VARCHAR(20) NOT NULL COMMENT '触发变更的操作',
+    from_status TINYINT NOT NULL COMMENT '变更前状态',
+    to_status TINYINT NOT NULL COMMENT '变更后状态',
+    operator VARCHAR(50) NOT NULL DEFAULT '' COMMENT '操作人用户ID，系统触发时为空',
+    reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '变更原因',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_activity (activity_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='活动状态变更记录表';
+
+-- 活动状态扩展为生命周期状态，0和1沿用原有取值
+ALTER TABLE activities
+    MODIFY COLUMN status TINYINT NOT NULL DEFAULT 0 COMMENT '活动状态：0-草稿，1-进行中，2-审核中，3-已排期，4-已暂停，5-已结束，6-已归档';
@@ -0,0 +1,7 @@
+-- 定时任务锁表，多副本部署时每个任务只由持有锁的实例执行
+CREATE TABLE IF NOT EXISTS scheduler_locks (
+    name VARCHAR(100) PRIMARY KEY COMMENT '任务名称',
+    holder VARCHAR(100) NOT NULL COMMENT '持有锁的实例标识',
+    expires_at DATETIME NOT NULL COMMENT '锁过期时间，过期后其他实例可以接管',
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='定时任务锁表';
@@ -0,0 +1,12 @@
+-- Redis库存异步落库时的流水ID，重复落库时依靠唯一索引去重，直接写入MySQL的记录为NULL
+ALTER TABLE prize_records
+    ADD COLUMN flush_id VARCHAR(64) NULL COMMENT 'Redis异步落库流水ID' AFTER status,
+    ADD UNIQUE KEY uk_flush_id (flush_id);
+
+ALTER TABLE activity_participations
+    ADD COLUMN flush_id VARCHAR(64) NULL COMMENT 'Redis异步落库流水ID' AFTER extra,
+    ADD UNIQUE KEY uk_flush_id (flush_id);
+
+-- 折扣码状态增加 4-已预留到Redis库存
+ALTER TABLE discount_codes
+    MODIFY COLUMN status TINYINT NOT NULL DEFAULT 0 COMMENT '状态：0-未分配，1-已发放，2-已核销，3-已过期，4-已预留';
@@ -0,0 +1,14 @@
+-- 参与请求表，同一用户的同一幂等键只执行一次玩法，重试时返回首次成功的响应
+CREATE TABLE IF NOT EXISTS participation_requests (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    user_id VARCHAR(50) NOT NULL COMMENT '用户ID',
+    idempotency_key VARCHAR(64) NOT NULL COMMENT '幂等键，来自Idempotency-Key请求头或请求体的request_id',
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    game_name VARCHAR(100) NOT NULL COMMENT '玩法名称',
+    status TINYINT NOT NULL DEFAULT 0 COMMENT '状态：0-处理中，1-已完成',
+    response JSON NULL COMMENT '首次成功时的响应数据',
+    expires_at DATETIME NOT NULL COMMENT '处理中请求的租约到期时间，到期后重试可以接管',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE KEY uk_user_key (user_id, idempotency_key)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='参与请求表';
@@ -0,0 +1,15 @@
+-- 风控事件表，记录参与玩法前的风控决策，用于统计设备共用和设备中奖频率并调优规则
+CREATE TABLE IF NOT EXISTS risk_events (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    activity_id BIGINT NOT NULL COMMENT '活动ID',
+    game_name VARCHAR(100) NOT NULL COMMENT '玩法名称',
+    user_id VARCHAR(50) NOT NULL COMMENT '用户ID',
+    device_id VARCHAR(128) NOT NULL DEFAULT '' COMMENT '设备指纹',
+    ip VARCHAR(64) NOT NULL DEFAULT '' COMMENT '客户端IP',
+    decision VARCHAR(20) NOT NULL COMMENT '决策：allow-放行，deny-拒绝，challenge-需要验证',
+    rule VARCHAR(50) NOT NULL DEFAULT '' COMMENT '做出非放行决策的规则',
+    reason VARCHAR(255) NOT NULL DEFAULT '' COMMENT '决策原因',
+    won TINYINT(1) NOT NULL DEFAULT 0 COMMENT '放行后是否获得奖品',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_device_created (device_id, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='风控事件表';
@@ -0,0 +1,6 @@
+-- 参与记录增加用户在玩法中的参与次序，直接写入MySQL的记录按已有记录数加1填写
+-- 并发请求读到相同的参与次数时只有一个能写入，其余请求连同已发放的奖品一起回滚，保证每个用户的参与次数不超过上限
+-- Redis异步落库的记录为NULL，参与次数由Redis保证
+ALTER TABLE activity_participations
+    ADD COLUMN seq INT NULL COMMENT '用户在玩法中的第几次参与' AFTER state,
+    ADD UNIQUE KEY uk_user_game_seq (activity_id, user_id, game_target, seq);
@@ -0,0 +1,4 @@
+-- 参与请求增加失败状态：玩法已开始执行后出现的业务错误保存为失败，相同幂等键的重试返回相同的错误，不会再次执行玩法
+ALTER TABLE participation_requests
+    MODIFY COLUMN status TINYINT NOT NULL DEFAULT 0 COMMENT '状态：0-处理中，1-已完成，2-执行后失败',
+    MODIFY COLUMN response JSON NULL COMMENT '首次执行的响应数据，失败时为错误码和错误信息';
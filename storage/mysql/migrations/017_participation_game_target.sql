@@ -0,0 +1,3 @@
+-- 参与记录的玩法标识保存玩法名称，与其他表的玩法名称和奖品标识一样使用100个字符，避免较长的玩法名称写入失败或被截断
+ALTER TABLE activity_participations
+    MODIFY COLUMN game_target VARCHAR(100) NOT NULL COMMENT '具体玩法标识，即玩法名称';
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultActivityCacheTTL 活动缓存默认有效期
+const defaultActivityCacheTTL = 5 * time.Second
+
+// cachedActivityRepository 带进程内缓存的活动仓储，缓存GetActivity解析后的领域模型
+// 缓存条目按活动ID存放并记录修订号，较旧修订号的加载结果不会覆盖较新的条目；
+// 本实例内的Create、Update、Transition会立即失效对应条目，其他实例的修改在有效期过后生效
+type cachedActivityRepository struct {
+	ActivityRepository
+	ttl time.Duration
+	now func() time.Time // 当前时间，测试时替换
+
+	mu      sync.Mutex
+	entries map[int64]*activityCacheEntry
+	loading map[int64]*activityLoad
+}
+
+// activityCacheEntry 缓存条目
+type activityCacheEntry struct {
+	activity  *indexedActivity
+	revision  int64
+	expiresAt time.Time
+}
+
+// activityLoad 正在进行的加载，同一活动的并发请求共享一次加载，避免缓存击穿
+type activityLoad struct {
+	done     chan struct{}
+	activity *indexedActivity
+	err      error
+}
+
+// indexedActivity 为玩法建立名称索引的活动，实现models.GameFinder
+type indexedActivity struct {
+	models.ActivityInterface
+	games map[string]models.GameInterface
+}
+
+// Game 按名称查找玩法
+func (a *indexedActivity) Game(ctx context.Context, name string) (models.GameInterface, bool) {
+	game, ok := a.games[name]
+	return game, ok
+}
+
+// NewCachedActivityRepository 为活动仓储增加进程内缓存，ttl不大于0时使用默认有效期
+func NewCachedActivityRepository(repo ActivityRepository, ttl time.Duration) ActivityRepository {
+	if ttl <= 0 {
+		ttl = defaultActivityCacheTTL
+	}
+	return &cachedActivityRepository{
+		ActivityRepository: repo,
+		ttl:                ttl,
+		now:                time.Now,
+		entries:            make(map[int64]*activityCacheEntry),
+		loading:            make(map[int64]*activityLoad),
+	}
+}
+
+// GetActivity 读取活动领域模型，缓存未命中或已过期时从仓储加载
+func (r *cachedActivityRepository) GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error) {
+	id, err := strconv.ParseInt(activityID, 10, 64)
+	if err != nil {
+		return r.ActivityRepository.GetActivity(ctx, activityID)
+	}
+
+	r.mu.Lock()
+	if entry, ok := r.entries[id]; ok && r.now().Before(entry.expiresAt) {
+		r.mu.Unlock()
+		return entry.activity, nil
+	}
+	load, ok := r.loading[id]
+	if !ok {
+		load = &activityLoad{done: make(chan struct{})}
+		r.loading[id] = load
+		go r.load(id, activityID, load)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-load.done:
+		if load.err != nil {
+			return nil, load.err
+		}
+		return load.activity, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load 从仓储加载活动并写入缓存，加载期间条目被失效时只返回结果，不写入缓存
+// 加载不随单个请求取消，等待中的其他请求仍然可以拿到结果
+func (r *cachedActivityRepository) load(id int64, activityID string, load *activityLoad) {
+	defer close(load.done)
+
+	activity, err := r.ActivityRepository.GetActivity(context.Background(), activityID)
+	if err == nil {
+		load.activity = indexGames(activity)
+	}
+	load.err = err
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.loading[id] != load {
+		return
+	}
+	delete(r.loading, id)
+	if err != nil {
+		return
+	}
+	revision := activity.Meta().Revision
+	if entry, ok := r.entries[id]; ok && entry.revision > revision {
+		return
+	}
+	r.entries[id] = &activityCacheEntry{
+		activity:  load.activity,
+		revision:  revision,
+		expiresAt: r.now().Add(r.ttl),
+	}
+}
+
+// Create 创建活动并失效同ID的缓存条目
+func (r *cachedActivityRepository) Create(ctx context.Context, activity *entity.Activity) error {
+	if err := r.ActivityRepository.Create(ctx, activity); err != nil {
+		return err
+	}
+	r.invalidate(activity.ID)
+	return nil
+}
+
+// Update 更新活动并失效缓存，更新失败时同样失效，以免保留已被其他实例修改的旧配置
+func (r *cachedActivityRepository) Update(ctx context.Context, activity *entity.Activity, stockDeltas map[string]int64) error {
+	defer r.invalidate(activity.ID)
+	return r.ActivityRepository.Update(ctx, activity, stockDeltas)
+}
+
+// Transition 变更活动状态并失效缓存，暂停等状态变更在本实例立即生效
+func (r *cachedActivityRepository) Transition(ctx context.Context, activity *entity.Activity, history *entity.ActivityStatusHistory) error {
+	defer r.invalidate(activity.ID)
+	return r.ActivityRepository.Transition(ctx, activity, history)
+}
+
+// invalidate 失效活动的缓存条目，正在进行的加载结果不再写入缓存
+func (r *cachedActivityRepository) invalidate(id int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, id)
+	delete(r.loading, id)
+}
+
+// indexGames 为活动下的玩法建立名称索引
+func indexGames(activity models.ActivityInterface) *indexedActivity {
+	ctx := context.Background()
+	games := make(map[string]models.GameInterface, len(activity.Games()))
+	for _, game := range activity.Games() {
+		games[game.Name(ctx)] = game
+	}
+	return &indexedActivity{ActivityInterface: activity, games: games}
+}
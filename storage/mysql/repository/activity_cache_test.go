@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"Activity/config"
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingActivityRepository 统计GetActivity调用次数的活动仓储，release关闭前加载会阻塞
+type countingActivityRepository struct {
+	ActivityRepository
+	config   string
+	revision int64
+	loads    int32
+	release  chan struct{}
+}
+
+func (r *countingActivityRepository) GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error) {
+	atomic.AddInt32(&r.loads, 1)
+	if r.release != nil {
+		<-r.release
+	}
+	activity, err := models.NewActivityFromConfig([]byte(r.config))
+	if err != nil {
+		return nil, err
+	}
+	activity.Meta().ID, _ = strconv.ParseInt(activityID, 10, 64)
+	activity.Meta().Revision = atomic.LoadInt64(&r.revision)
+	return activity, nil
+}
+
+func (r *countingActivityRepository) Update(ctx context.Context, activity *entity.Activity, stockDeltas map[string]int64) error {
+	atomic.AddInt64(&r.revision, 1)
+	return nil
+}
+
+func TestCachedActivityRepositorySingleLoad(t *testing.T) {
+	const goroutines = 100
+
+	base := &countingActivityRepository{config: config.LotteryActivityExample, release: make(chan struct{})}
+	repo := NewCachedActivityRepository(base, time.Minute)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := repo.GetActivity(ctx, "1"); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	// 等待所有请求进入等待后再放行加载
+	time.Sleep(50 * time.Millisecond)
+	close(base.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("GetActivity() error = %v", err)
+	}
+	if loads := atomic.LoadInt32(&base.loads); loads != 1 {
+		t.Errorf("loads = %d, want 1", loads)
+	}
+
+	activity, _ := repo.GetActivity(ctx, "1")
+	if _, ok := models.FindGame(ctx, activity, "幸运抽奖"); !ok {
+		t.Error("cached activity is missing game 幸运抽奖")
+	}
+}
+
+func TestCachedActivityRepositoryExpiresAndInvalidates(t *testing.T) {
+	base := &countingActivityRepository{config: config.LotteryActivityExample}
+	repo := NewCachedActivityRepository(base, time.Minute).(*cachedActivityRepository)
+	now := time.Unix(1700000000, 0)
+	repo.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	get := func() models.ActivityInterface {
+		t.Helper()
+		activity, err := repo.GetActivity(ctx, "1")
+		if err != nil {
+			t.Fatalf("GetActivity() error = %v", err)
+		}
+		return activity
+	}
+
+	get()
+	get()
+	if loads := atomic.LoadInt32(&base.loads); loads != 1 {
+		t.Fatalf("loads = %d, want 1 before expiry", loads)
+	}
+
+	// 过期后重新加载
+	now = now.Add(time.Minute)
+	get()
+	if loads := atomic.LoadInt32(&base.loads); loads != 2 {
+		t.Fatalf("loads = %d, want 2 after expiry", loads)
+	}
+
+	// 更新后立即失效，读到新的修订号
+	if err := repo.Update(ctx, &entity.Activity{ID: 1}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if revision := get().Meta().Revision; revision != 1 {
+		t.Errorf("revision = %d, want 1 after update", revision)
+	}
+	if loads := atomic.LoadInt32(&base.loads); loads != 3 {
+		t.Errorf("loads = %d, want 3 after update", loads)
+	}
+}
+
+// benchmarkParticipatePath 参与玩法的读路径：读取活动并按名称查找玩法
+func benchmarkParticipatePath(b *testing.B, repo ActivityRepository) {
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			activity, err := repo.GetActivity(ctx, "1")
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, ok := models.FindGame(ctx, activity, "幸运抽奖"); !ok {
+				b.Fatal("game not found")
+			}
+		}
+	})
+}
+
+func newBenchmarkActivityRepository(b *testing.B) ActivityRepository {
+	db := newTestDB(b)
+	repo := NewActivityRepository(db)
+	activity := &entity.Activity{
+		Category: "community",
+		Version:  "v1",
+		Name:     "社区抽奖活动",
+		Config:   config.LotteryActivityExample,
+		StartAt:  1679000000,
+		EndAt:    1679086400,
+		Status:   models.ActivityStatusOnline,
+	}
+	if err := repo.Create(context.Background(), activity); err != nil {
+		b.Fatal(err)
+	}
+	return repo
+}
+
+func BenchmarkGetActivityUncached(b *testing.B) {
+	benchmarkParticipatePath(b, newBenchmarkActivityRepository(b))
+}
+
+func BenchmarkGetActivityCached(b *testing.B) {
+	benchmarkParticipatePath(b, NewCachedActivityRepository(newBenchmarkActivityRepository(b), time.Minute))
+}
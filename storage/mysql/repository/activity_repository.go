@@ -4,6 +4,8 @@ import (
 	"Activity/models"
 	"Activity/storage/mysql/entity"
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -13,21 +15,44 @@ import (
 // ActivityRepository 活动仓储接口
 type ActivityRepository interface {
 	Create(ctx context.Context, activity *entity.Activity) error
-	Update(ctx context.Context, activity *entity.Activity) error
+	Update(ctx context.Context, activity *entity.Activity, stockDeltas map[string]int64) error
+	Transition(ctx context.Context, activity *entity.Activity, history *entity.ActivityStatusHistory) error
+	ListStatusHistory(ctx context.Context, activityID int64) ([]*entity.ActivityStatusHistory, error)
 	FindByID(ctx context.Context, id int64) (*entity.Activity, error)
 	FindByCategory(ctx context.Context, category string) ([]*entity.Activity, error)
 	FindActive(ctx context.Context) ([]*entity.Activity, error)
+	FindByStatus(ctx context.Context, statuses ...int64) ([]*entity.Activity, error)
 	GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error)
 }
 
 // activityRepository 活动仓储实现
 type activityRepository struct {
-	db *gorm.DB
+	db             *gorm.DB
+	participations ParticipationRepository
+	checkins       CheckinRepository
+	prizes         PrizeRepository
+	draws          DrawRepository
+	community      models.CommunityClient
+	transactor     models.Transactor
 }
 
-// NewActivityRepository 创建活动仓储实例
+// NewActivityRepository 创建活动仓储实例，玩法运行时的参与记录和奖品库存使用MySQL，不配置社区服务
 func NewActivityRepository(db *gorm.DB) ActivityRepository {
-	return &activityRepository{db: db}
+	return NewActivityRepositoryWithStores(db, NewParticipationRepository(db), NewPrizeRepository(db), nil, NewTransactor(db))
+}
+
+// NewActivityRepositoryWithStores 创建活动仓储实例，玩法运行时的参与记录和奖品库存使用指定的实现，如Redis库存
+// community为发帖玩法验证帖子使用的社区服务客户端，transactor为玩法在同一事务中写入多个存储使用的事务管理
+func NewActivityRepositoryWithStores(db *gorm.DB, participations ParticipationRepository, prizes PrizeRepository, community models.CommunityClient, transactor models.Transactor) ActivityRepository {
+	return &activityRepository{
+		db:             db,
+		participations: participations,
+		checkins:       NewCheckinRepository(db),
+		prizes:         prizes,
+		draws:          NewDrawRepository(db),
+		community:      community,
+		transactor:     transactor,
+	}
 }
 
 // Create 创建活动
@@ -35,15 +60,94 @@ func (r *activityRepository) Create(ctx context.Context, activity *entity.Activi
 	return r.db.WithContext(ctx).Create(activity).Error
 }
 
-// Update 更新活动
-func (r *activityRepository) Update(ctx context.Context, activity *entity.Activity) error {
-	return r.db.WithContext(ctx).Save(activity).Error
+// Update 更新活动，以activity.Revision为前置条件，成功后修订号加1
+// 修订号不匹配时返回ErrRevisionConflict；stockDeltas为奖品库存的变化量，和活动更新在同一事务中调整库存
+// 报名人数由报名流程维护，状态由Transition维护，均不随活动信息覆盖
+func (r *activityRepository) Update(ctx context.Context, activity *entity.Activity, stockDeltas map[string]int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Activity{}).
+			Where("id = ? AND revision = ?", activity.ID, activity.Revision).
+			Updates(map[string]interface{}{
+				"name":     activity.Name,
+				"config":   activity.Config,
+				"start_at": activity.StartAt,
+				"end_at":   activity.EndAt,
+				"revision": gorm.Expr("revision + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRevisionConflict
+		}
+
+		// 库存记录尚未初始化时无需调整，首次发放会按新配置初始化
+		for prizeKey, delta := range stockDeltas {
+			err := tx.Model(&entity.PrizeInventory{}).
+				Where("activity_id = ? AND prize_key = ?", activity.ID, prizeKey).
+				Updates(map[string]interface{}{
+					"total_num":  gorm.Expr("total_num + ?", delta),
+					"remain_num": gorm.Expr("CASE WHEN remain_num + ? < 0 THEN 0 ELSE remain_num + ? END", delta, delta),
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		activity.Revision++
+		return nil
+	})
+}
+
+// Transition 将活动从activity.Status变更为history.ToStatus，并写入状态变更记录
+// 以当前状态和修订号为前置条件，期间活动被修改或状态已变化时返回ErrRevisionConflict
+func (r *activityRepository) Transition(ctx context.Context, activity *entity.Activity, history *entity.ActivityStatusHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Activity{}).
+			Where("id = ? AND status = ? AND revision = ?", activity.ID, activity.Status, activity.Revision).
+			Updates(map[string]interface{}{
+				"status":   history.ToStatus,
+				"revision": gorm.Expr("revision + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRevisionConflict
+		}
+
+		history.ActivityID = activity.ID
+		history.FromStatus = activity.Status
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		activity.Status = history.ToStatus
+		activity.Revision++
+		return nil
+	})
+}
+
+// ListStatusHistory 按时间顺序返回活动的状态变更记录
+func (r *activityRepository) ListStatusHistory(ctx context.Context, activityID int64) ([]*entity.ActivityStatusHistory, error) {
+	var histories []*entity.ActivityStatusHistory
+	err := r.db.WithContext(ctx).
+		Where("activity_id = ?", activityID).
+		Order("id ASC").
+		Find(&histories).Error
+	if err != nil {
+		return nil, err
+	}
+	return histories, nil
 }
 
-// FindByID 根据ID查找活动
+// FindByID 根据ID查找活动，不存在时返回ErrActivityNotFound
 func (r *activityRepository) FindByID(ctx context.Context, id int64) (*entity.Activity, error) {
 	var activity entity.Activity
 	err := r.db.WithContext(ctx).First(&activity, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrActivityNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -60,12 +164,12 @@ func (r *activityRepository) FindByCategory(ctx context.Context, category string
 	return activities, nil
 }
 
-// FindActive 查找当前有效的活动
+// FindActive 查找进行中且在活动时间内的活动
 func (r *activityRepository) FindActive(ctx context.Context) ([]*entity.Activity, error) {
 	now := time.Now().Unix()
 	var activities []*entity.Activity
 	err := r.db.WithContext(ctx).
-		Where("status = ? AND start_at <= ? AND end_at >= ?", 1, now, now).
+		Where("status = ? AND start_at <= ? AND end_at >= ?", models.ActivityStatusOnline, now, now).
 		Find(&activities).Error
 	if err != nil {
 		return nil, err
@@ -73,11 +177,21 @@ func (r *activityRepository) FindActive(ctx context.Context) ([]*entity.Activity
 	return activities, nil
 }
 
+// FindByStatus 查找处于指定状态的活动
+func (r *activityRepository) FindByStatus(ctx context.Context, statuses ...int64) ([]*entity.Activity, error) {
+	var activities []*entity.Activity
+	err := r.db.WithContext(ctx).Where("status IN ?", statuses).Find(&activities).Error
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
 // GetActivity 获取活动信息
 func (r *activityRepository) GetActivity(ctx context.Context, activityID string) (models.ActivityInterface, error) {
 	id, err := strconv.ParseInt(activityID, 10, 64)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: invalid id %q", ErrActivityNotFound, activityID)
 	}
 
 	activity, err := r.FindByID(ctx, id)
@@ -85,28 +199,37 @@ func (r *activityRepository) GetActivity(ctx context.Context, activityID string)
 		return nil, err
 	}
 
-	// 将数据库实体转换为领域模型
-	return &models.CommunityActivity{
-		MetaActivity: models.MetaActivity{
-			ID:       activity.ID,
-			Category: activity.Category,
-			Version:  activity.Version,
-			StartAt:  activity.StartAt,
-			EndAt:    activity.EndAt,
-			Status:   activity.Status,
-		},
-		GameList: []models.GameInterface{
-			&models.CommunityPostGame{
-				Name_: "发帖奖励",
-				Prize: &models.DiscountCodePrize{
-					DiscountCode: "COMMUNITY_2024",
-					PriceRuleID:  123,
-					Probability:  100,
-					TotalNum:     1000,
-					RemainNum:    1000,
-				},
-				State: models.GameStateOPEN,
-			},
-		},
-	}, nil
+	// 根据存储的配置构建领域模型，玩法、奖品和状态均来自配置
+	domain, err := models.NewActivityFromConfig([]byte(activity.Config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build activity %d from config: %w", activity.ID, err)
+	}
+
+	// 元信息以数据库列为准
+	meta := domain.Meta()
+	meta.ID = activity.ID
+	meta.CreatedAt = activity.CreatedAt
+	meta.UpdatedAt = activity.UpdatedAt
+	meta.Category = activity.Category
+	meta.Version = activity.Version
+	meta.Name = activity.Name
+	meta.StartAt = activity.StartAt
+	meta.EndAt = activity.EndAt
+	meta.Status = activity.Status
+	meta.Revision = activity.Revision
+
+	// 注入玩法运行时依赖
+	models.BindRuntime(domain, &models.Runtime{
+		ActivityID:     activity.ID,
+		StartAt:        activity.StartAt,
+		EndAt:          activity.EndAt,
+		Participations: r.participations,
+		Checkins:       r.checkins,
+		Prizes:         r.prizes,
+		Draws:          r.draws,
+		Community:      r.community,
+		Tx:             r.transactor,
+	})
+
+	return domain, nil
 }
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"Activity/storage/mysql/entity"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestActivityRepositoryUpdate(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewActivityRepository(db)
+	ctx := context.Background()
+
+	activity := &entity.Activity{Category: "lottery", Version: "v1", Name: "幸运抽奖", Config: "{}", StartAt: 1000, EndAt: 2000}
+	if err := repo.Create(ctx, activity); err != nil {
+		t.Fatal(err)
+	}
+	inventories := []entity.PrizeInventory{
+		{ActivityID: activity.ID, PrizeKey: "幸运抽奖#0", PrizeType: "discount_code", TotalNum: 500, RemainNum: 200},
+		{ActivityID: activity.ID, PrizeKey: "幸运抽奖#1", PrizeType: "product", TotalNum: 20, RemainNum: 3},
+	}
+	if err := db.Create(&inventories).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 库存变化同步到库存记录，剩余库存不会减为负数，未初始化的库存记录被忽略
+	stale := *activity
+	activity.Name = "超级抽奖"
+	activity.EndAt = 3000
+	err := repo.Update(ctx, activity, map[string]int64{"幸运抽奖#0": 100, "幸运抽奖#1": -5, "幸运抽奖#2": 10})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if activity.Revision != stale.Revision+1 {
+		t.Errorf("revision = %d, want %d", activity.Revision, stale.Revision+1)
+	}
+	want := map[string][2]int64{"幸运抽奖#0": {600, 300}, "幸运抽奖#1": {15, 0}}
+	var stored []entity.PrizeInventory
+	if err := db.Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != len(want) {
+		t.Fatalf("inventories = %d, want %d", len(stored), len(want))
+	}
+	for _, inventory := range stored {
+		if got := [2]int64{inventory.TotalNum, inventory.RemainNum}; got != want[inventory.PrizeKey] {
+			t.Errorf("%s total/remain = %v, want %v", inventory.PrizeKey, got, want[inventory.PrizeKey])
+		}
+	}
+
+	// 基于旧修订号的更新被拒绝，库存保持不变
+	stale.Name = "旧名称"
+	if err := repo.Update(ctx, &stale, map[string]int64{"幸运抽奖#0": 100}); !errors.Is(err, ErrRevisionConflict) {
+		t.Fatalf("Update(stale) error = %v, want ErrRevisionConflict", err)
+	}
+	saved, err := repo.FindByID(ctx, activity.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Name != "超级抽奖" || saved.EndAt != 3000 {
+		t.Errorf("saved = %s %d, want 超级抽奖 3000", saved.Name, saved.EndAt)
+	}
+	var inventory entity.PrizeInventory
+	if err := db.Where("prize_key = ?", "幸运抽奖#0").First(&inventory).Error; err != nil {
+		t.Fatal(err)
+	}
+	if inventory.TotalNum != 600 {
+		t.Errorf("total after stale update = %d, want 600", inventory.TotalNum)
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"Activity/storage/mysql/entity"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository 后台操作日志仓储接口
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *entity.AdminAuditLog) error
+	List(ctx context.Context, actor string, offset, limit int) ([]*entity.AdminAuditLog, int64, error)
+}
+
+// auditLogRepository 后台操作日志仓储实现
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository 创建后台操作日志仓储实例
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// Create 写入操作日志
+func (r *auditLogRepository) Create(ctx context.Context, log *entity.AdminAuditLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+// List 分页查询操作日志，actor为空时查询全部，按时间倒序
+func (r *auditLogRepository) List(ctx context.Context, actor string, offset, limit int) ([]*entity.AdminAuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entity.AdminAuditLog{})
+	if actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var logs []*entity.AdminAuditLog
+	if err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
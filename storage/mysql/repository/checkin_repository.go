@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CheckinRepository 签到记录仓储接口
+type CheckinRepository interface {
+	Checkin(ctx context.Context, activityID int64, gameName, uid, date string) error
+	CheckinDates(ctx context.Context, activityID int64, gameName, uid string) ([]string, error)
+}
+
+// checkinRepository 签到记录仓储实现
+type checkinRepository struct {
+	db *gorm.DB
+}
+
+// NewCheckinRepository 创建签到记录仓储实例
+func NewCheckinRepository(db *gorm.DB) CheckinRepository {
+	return &checkinRepository{db: db}
+}
+
+// Checkin 记录签到，依赖唯一索引拦截同一自然日的重复签到
+func (r *checkinRepository) Checkin(ctx context.Context, activityID int64, gameName, uid, date string) error {
+	record := &entity.CheckinRecord{
+		ActivityID:  activityID,
+		GameName:    gameName,
+		UserID:      uid,
+		CheckinDate: date,
+	}
+	result := dbFrom(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(record)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrAlreadyCheckedIn
+	}
+	return nil
+}
+
+// CheckinDates 查询用户的签到自然日，按日期升序
+func (r *checkinRepository) CheckinDates(ctx context.Context, activityID int64, gameName, uid string) ([]string, error) {
+	var dates []string
+	err := dbFrom(ctx, r.db).
+		Model(&entity.CheckinRecord{}).
+		Where("activity_id = ? AND game_name = ? AND user_id = ?", activityID, gameName, uid).
+		Order("checkin_date ASC").
+		Pluck("checkin_date", &dates).Error
+	if err != nil {
+		return nil, err
+	}
+	return dates, nil
+}
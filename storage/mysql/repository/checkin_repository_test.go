@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"Activity/models"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCheckinRollsBackWhenPrizeFails(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	game := &models.CheckinGame{
+		Name_:  "每日签到",
+		State:  models.GameStateOPEN,
+		Prize:  &models.DiscountCodePrize{DiscountCode: "CHECKIN", TotalNum: 10},
+		Config: models.CheckinConfig{RequiredDays: 1, TimeZone: "UTC"},
+	}
+	checkins := NewCheckinRepository(db)
+	game.BindRuntime(&models.Runtime{
+		ActivityID: 1,
+		Checkins:   checkins,
+		Prizes:     NewPrizeRepository(db),
+		Tx:         NewTransactor(db),
+	})
+	user := models.User{Uid: "user-1"}
+	action := &models.CheckinAction{CheckinTime: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)}
+
+	// 码池为空时发奖失败，签到一并回滚，奖励不会因为已签到而丢失
+	if _, err := game.Perform(ctx, user, action); !errors.Is(err, models.ErrPrizeStockEmpty) {
+		t.Fatalf("Perform() error = %v, want ErrPrizeStockEmpty", err)
+	}
+	dates, err := checkins.CheckinDates(ctx, 1, "每日签到", user.Uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dates) != 0 {
+		t.Fatalf("checkin dates = %v, want none", dates)
+	}
+
+	// 补充码池后同一天可以重新签到并领奖
+	if _, err := NewDiscountCodeRepository(db).Import(ctx, 1, "每日签到", []string{"CHECKIN-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	result, err := game.Perform(ctx, user, action)
+	if err != nil {
+		t.Fatalf("Perform() error = %v", err)
+	}
+	checkin := result.(*models.CheckinResult)
+	if !checkin.Completed || checkin.Grant == nil || checkin.Grant.PrizeID != "CHECKIN-1" {
+		t.Errorf("Perform() = %+v, want prize CHECKIN-1", checkin)
+	}
+	if state := checkin.StateAfter(); state != models.UserStateCLOSED {
+		t.Errorf("StateAfter() = %s, want CLOSED", state)
+	}
+}
+
+func TestCheckinRepositoryRejectsSameDay(t *testing.T) {
+	db := newTestDB(t)
+	checkins := NewCheckinRepository(db)
+	ctx := context.Background()
+
+	// 唯一索引拦截同一自然日的重复签到，其他玩法和其他用户不受影响
+	if err := checkins.Checkin(ctx, 1, "每日签到", "user-1", "2024-03-01"); err != nil {
+		t.Fatalf("Checkin() error = %v", err)
+	}
+	if err := checkins.Checkin(ctx, 1, "每日签到", "user-1", "2024-03-01"); !errors.Is(err, models.ErrAlreadyCheckedIn) {
+		t.Fatalf("Checkin(same day) error = %v, want ErrAlreadyCheckedIn", err)
+	}
+	for _, other := range []struct{ gameName, uid string }{{"每周签到", "user-1"}, {"每日签到", "user-2"}} {
+		if err := checkins.Checkin(ctx, 1, other.gameName, other.uid, "2024-03-01"); err != nil {
+			t.Errorf("Checkin(%s, %s) error = %v", other.gameName, other.uid, err)
+		}
+	}
+	if err := checkins.Checkin(ctx, 1, "每日签到", "user-1", "2024-03-02"); err != nil {
+		t.Fatalf("Checkin(next day) error = %v", err)
+	}
+	dates, err := checkins.CheckinDates(ctx, 1, "每日签到", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2024-03-01", "2024-03-02"}; !reflect.DeepEqual(dates, want) {
+		t.Errorf("CheckinDates() = %v, want %v", dates, want)
+	}
+}
+
+func TestCheckinGroupsByLocalDay(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	game := &models.CheckinGame{
+		Name_:  "每日签到",
+		State:  models.GameStateOPEN,
+		Prize:  &models.DiscountCodePrize{DiscountCode: "CHECKIN", TotalNum: 10},
+		Config: models.CheckinConfig{RequiredDays: 3, TimeZone: "Asia/Shanghai"},
+	}
+	checkins := NewCheckinRepository(db)
+	game.BindRuntime(&models.Runtime{ActivityID: 1, Checkins: checkins, Prizes: NewPrizeRepository(db), Tx: NewTransactor(db)})
+	user := models.User{Uid: "user-1"}
+	perform := func(at time.Time) (*models.CheckinResult, error) {
+		result, err := game.Perform(ctx, user, &models.CheckinAction{CheckinTime: at})
+		if err != nil {
+			return nil, err
+		}
+		return result.(*models.CheckinResult), nil
+	}
+
+	// 同一个UTC日的两个时间点在东八区分别是3月1日23点和3月2日0点半，算作连续两天
+	tests := []struct {
+		at       time.Time
+		wantDate string
+		wantDays int64
+	}{
+		{time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC), "2024-03-01", 1},
+		{time.Date(2024, 3, 1, 16, 30, 0, 0, time.UTC), "2024-03-02", 2},
+	}
+	for _, tt := range tests {
+		result, err := perform(tt.at)
+		if err != nil {
+			t.Fatalf("Perform(%s) error = %v", tt.at, err)
+		}
+		if result.CheckinDate != tt.wantDate || result.ConsecutiveDays != tt.wantDays {
+			t.Errorf("Perform(%s) = %s, %d days, want %s, %d days", tt.at, result.CheckinDate, result.ConsecutiveDays, tt.wantDate, tt.wantDays)
+		}
+	}
+
+	// 跨UTC日但仍在东八区3月2日内的签到被拦截
+	if _, err := perform(time.Date(2024, 3, 2, 10, 0, 0, 0, time.UTC)); !errors.Is(err, models.ErrAlreadyCheckedIn) {
+		t.Errorf("Perform(same local day) error = %v, want ErrAlreadyCheckedIn", err)
+	}
+	dates, err := checkins.CheckinDates(ctx, 1, "每日签到", user.Uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2024-03-01", "2024-03-02"}; !reflect.DeepEqual(dates, want) {
+		t.Errorf("CheckinDates() = %v, want %v", dates, want)
+	}
+}
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// discountCodeImportBatchSize 批量导入折扣码时每批写入的数量
+const discountCodeImportBatchSize = 500
+
+// discountCodeAllocateRetries 分配折扣码时遇到并发抢占的重试次数
+const discountCodeAllocateRetries = 3
+
+// DiscountCodeRepository 折扣码池仓储接口
+type DiscountCodeRepository interface {
+	Import(ctx context.Context, activityID int64, prizeKey string, codes []string, expiresAt *time.Time) (int64, error)
+	Redeem(ctx context.Context, code, uid string) error
+	Expire(ctx context.Context, now time.Time) (int64, error)
+	CountAvailable(ctx context.Context, activityID int64, prizeKey string) (int64, error)
+	Reserve(ctx context.Context, activityID int64, prizeKey string, n int) ([]string, error)
+}
+
+// discountCodeRepository 折扣码池仓储实现
+type discountCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewDiscountCodeRepository 创建折扣码池仓储实例
+func NewDiscountCodeRepository(db *gorm.DB) DiscountCodeRepository {
+	return &discountCodeRepository{db: db}
+}
+
+// Import 批量导入折扣码，已存在的码会被跳过，返回实际导入的数量
+func (r *discountCodeRepository) Import(ctx context.Context, activityID int64, prizeKey string, codes []string, expiresAt *time.Time) (int64, error) {
+	records := make([]*entity.DiscountCode, 0, len(codes))
+	for _, code := range codes {
+		records = append(records, &entity.DiscountCode{
+			ActivityID: activityID,
+			PrizeKey:   prizeKey,
+			Code:       code,
+			Status:     entity.DiscountCodeStatusAvailable,
+			ExpiresAt:  expiresAt,
+		})
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	var imported int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(records); start += discountCodeImportBatchSize {
+			end := min(start+discountCodeImportBatchSize, len(records))
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(records[start:end])
+			if result.Error != nil {
+				return result.Error
+			}
+			imported += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return imported, nil
+}
+
+// Redeem 核销用户已获得的折扣码
+func (r *discountCodeRepository) Redeem(ctx context.Context, code, uid string) error {
+	now := time.Now()
+	result := r.db.WithContext(ctx).
+		Model(&entity.DiscountCode{}).
+		Where("code = ? AND user_id = ? AND status = ?", code, uid, entity.DiscountCodeStatusIssued).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Updates(map[string]interface{}{
+			"status":      entity.DiscountCodeStatusRedeemed,
+			"redeemed_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrDiscountCodeUnavailable
+	}
+	return nil
+}
+
+// Expire 将已过期但未核销的折扣码标记为过期，返回处理的数量
+func (r *discountCodeRepository) Expire(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.DiscountCode{}).
+		Where("status IN ? AND expires_at IS NOT NULL AND expires_at <= ?",
+			[]int64{entity.DiscountCodeStatusAvailable, entity.DiscountCodeStatusIssued}, now).
+		Update("status", entity.DiscountCodeStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// CountAvailable 统计码池中尚未发放的折扣码数量，包括已预留到Redis库存的码
+func (r *discountCodeRepository) CountAvailable(ctx context.Context, activityID int64, prizeKey string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.DiscountCode{}).
+		Where("activity_id = ? AND prize_key = ? AND status IN ?", activityID, prizeKey,
+			[]int64{entity.DiscountCodeStatusAvailable, entity.DiscountCodeStatusReserved}).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Count(&count).Error
+	return count, err
+}
+
+// Reserve 从码池中预留至多n个可分配的折扣码，供Redis库存发放
+// 逐个条件更新状态，只返回本次成功预留的码，并发预留时同一个码不会被返回两次
+func (r *discountCodeRepository) Reserve(ctx context.Context, activityID int64, prizeKey string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx)
+	var candidates []*entity.DiscountCode
+	err := availableDiscountCodes(db, activityID, prizeKey, time.Now()).
+		Order("id ASC").
+		Limit(n).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		result := db.Model(&entity.DiscountCode{}).
+			Where("id = ? AND status = ?", candidate.ID, entity.DiscountCodeStatusAvailable).
+			Update("status", entity.DiscountCodeStatusReserved)
+		if result.Error != nil {
+			return codes, result.Error
+		}
+		if result.RowsAffected == 1 {
+			codes = append(codes, candidate.Code)
+		}
+	}
+	return codes, nil
+}
+
+// availableDiscountCodes 可分配的折扣码查询条件
+func availableDiscountCodes(db *gorm.DB, activityID int64, prizeKey string, now time.Time) *gorm.DB {
+	return db.Model(&entity.DiscountCode{}).
+		Where("activity_id = ? AND prize_key = ? AND status = ?", activityID, prizeKey, entity.DiscountCodeStatusAvailable).
+		Where("expires_at IS NULL OR expires_at > ?", now)
+}
+
+// allocateDiscountCode 在事务中为用户分配一个未使用的折扣码
+// 同一奖品的发放已经被库存行锁串行化，条件更新用于兜底防止同一个码被重复分配
+func allocateDiscountCode(tx *gorm.DB, activityID int64, prizeKey, uid string) (*entity.DiscountCode, error) {
+	now := time.Now()
+	for i := 0; i < discountCodeAllocateRetries; i++ {
+		var code entity.DiscountCode
+		err := availableDiscountCodes(tx, activityID, prizeKey, now).
+			Order("id ASC").
+			First(&code).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, models.ErrPrizeStockEmpty
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result := tx.Model(&entity.DiscountCode{}).
+			Where("id = ? AND status = ?", code.ID, entity.DiscountCodeStatusAvailable).
+			Updates(map[string]interface{}{
+				"status":    entity.DiscountCodeStatusIssued,
+				"user_id":   uid,
+				"issued_at": now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return &code, nil
+		}
+	}
+	return nil, models.ErrPrizeStockEmpty
+}
+
+// bindDiscountCode 回填折扣码对应的发放记录
+func bindDiscountCode(tx *gorm.DB, codeID, prizeRecordID int64) error {
+	return tx.Model(&entity.DiscountCode{}).
+		Where("id = ?", codeID).
+		Update("prize_record_id", prizeRecordID).Error
+}
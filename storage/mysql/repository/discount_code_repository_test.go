@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDiscountCodeRepositoryImport(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewDiscountCodeRepository(db)
+	ctx := context.Background()
+
+	// 超过一批的数量分批写入，重复的码只导入一次
+	codes := make([]string, discountCodeImportBatchSize+10)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("CODE-%04d", i)
+	}
+	imported, err := repo.Import(ctx, 1, "发帖奖励", append(codes, codes[0]), nil)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if imported != int64(len(codes)) {
+		t.Errorf("imported = %d, want %d", imported, len(codes))
+	}
+
+	// 再次导入时已存在的码被跳过
+	imported, err = repo.Import(ctx, 1, "发帖奖励", []string{codes[1], "CODE-NEW"}, nil)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if imported != 1 {
+		t.Errorf("imported = %d, want 1", imported)
+	}
+	if available, _ := repo.CountAvailable(ctx, 1, "发帖奖励"); available != int64(len(codes)+1) {
+		t.Errorf("available = %d, want %d", available, len(codes)+1)
+	}
+	if imported, err := repo.Import(ctx, 1, "发帖奖励", nil, nil); err != nil || imported != 0 {
+		t.Errorf("Import(empty) = %d, %v, want 0", imported, err)
+	}
+}
+
+func TestDiscountCodeRepositoryAllocateAndRedeem(t *testing.T) {
+	db := newTestDB(t)
+	codes := NewDiscountCodeRepository(db)
+	prizes := NewPrizeRepository(db)
+	ctx := context.Background()
+
+	expired := time.Now().Add(-time.Hour)
+	if _, err := codes.Import(ctx, 1, "发帖奖励", []string{"EXPIRED-1"}, &expired); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codes.Import(ctx, 1, "发帖奖励", []string{"CODE-1", "CODE-2"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codes.Import(ctx, 1, "签到奖励", []string{"OTHER-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	issue := func(uid string) (*models.PrizeGrant, error) {
+		return prizes.Issue(ctx, models.PrizeIssue{
+			ActivityID:   1,
+			PrizeKey:     "发帖奖励",
+			PrizeType:    models.PrizeTypeDiscountCode,
+			PrizeID:      "COMMUNITY_2024",
+			TotalNum:     10,
+			UserID:       uid,
+			AllocateCode: true,
+		})
+	}
+
+	// 按导入顺序分配本奖品码池中未过期的码，码池用完时库存不足
+	for i, want := range []string{"CODE-1", "CODE-2"} {
+		grant, err := issue(fmt.Sprintf("user-%d", i+1))
+		if err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+		if grant.PrizeID != want {
+			t.Errorf("allocated %s, want %s", grant.PrizeID, want)
+		}
+	}
+	if _, err := issue("user-3"); !errors.Is(err, models.ErrPrizeStockEmpty) {
+		t.Fatalf("Issue() error = %v, want ErrPrizeStockEmpty", err)
+	}
+
+	var code entity.DiscountCode
+	if err := db.Where("code = ?", "CODE-1").First(&code).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code.Status != entity.DiscountCodeStatusIssued || code.UserID != "user-1" || code.PrizeRecordID == 0 {
+		t.Errorf("code = %+v, want issued to user-1 with prize record", code)
+	}
+
+	// 只能核销自己获得的码，同一个码只能核销一次
+	if err := codes.Redeem(ctx, "CODE-1", "user-2"); !errors.Is(err, models.ErrDiscountCodeUnavailable) {
+		t.Errorf("Redeem(other user) error = %v, want ErrDiscountCodeUnavailable", err)
+	}
+	if err := codes.Redeem(ctx, "CODE-1", "user-1"); err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+	if err := codes.Redeem(ctx, "CODE-1", "user-1"); !errors.Is(err, models.ErrDiscountCodeUnavailable) {
+		t.Errorf("Redeem(twice) error = %v, want ErrDiscountCodeUnavailable", err)
+	}
+	if err := codes.Redeem(ctx, "OTHER-1", "user-1"); !errors.Is(err, models.ErrDiscountCodeUnavailable) {
+		t.Errorf("Redeem(not issued) error = %v, want ErrDiscountCodeUnavailable", err)
+	}
+}
+
+func TestDiscountCodeRepositoryExpire(t *testing.T) {
+	db := newTestDB(t)
+	codes := NewDiscountCodeRepository(db)
+	prizes := NewPrizeRepository(db)
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Hour)
+	if _, err := codes.Import(ctx, 1, "发帖奖励", []string{"ISSUED-1", "REDEEMED-1", "AVAILABLE-1"}, &expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := codes.Import(ctx, 1, "发帖奖励", []string{"FOREVER-1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, uid := range []string{"user-1", "user-2"} {
+		if _, err := prizes.Issue(ctx, models.PrizeIssue{
+			ActivityID:   1,
+			PrizeKey:     "发帖奖励",
+			PrizeType:    models.PrizeTypeDiscountCode,
+			TotalNum:     10,
+			UserID:       uid,
+			AllocateCode: true,
+		}); err != nil {
+			t.Fatalf("Issue() error = %v", err)
+		}
+	}
+	if err := codes.Redeem(ctx, "REDEEMED-1", "user-2"); err != nil {
+		t.Fatalf("Redeem() error = %v", err)
+	}
+
+	// 到期前不处理
+	if expired, err := codes.Expire(ctx, time.Now()); err != nil || expired != 0 {
+		t.Fatalf("Expire(before) = %d, %v, want 0", expired, err)
+	}
+
+	// 到期后未核销的码（已发放和未分配）标记为过期，已核销和不过期的码不受影响
+	expired, err := codes.Expire(ctx, expiresAt.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Expire() error = %v", err)
+	}
+	if expired != 2 {
+		t.Errorf("expired = %d, want 2", expired)
+	}
+	want := map[string]int64{
+		"ISSUED-1":    entity.DiscountCodeStatusExpired,
+		"REDEEMED-1":  entity.DiscountCodeStatusRedeemed,
+		"AVAILABLE-1": entity.DiscountCodeStatusExpired,
+		"FOREVER-1":   entity.DiscountCodeStatusAvailable,
+	}
+	var stored []entity.DiscountCode
+	if err := db.Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range stored {
+		if code.Status != want[code.Code] {
+			t.Errorf("%s status = %d, want %d", code.Code, code.Status, want[code.Code])
+		}
+	}
+
+	// 过期的码不能再核销，重复执行不会重复处理
+	if err := codes.Redeem(ctx, "ISSUED-1", "user-1"); !errors.Is(err, models.ErrDiscountCodeUnavailable) {
+		t.Errorf("Redeem(expired) error = %v, want ErrDiscountCodeUnavailable", err)
+	}
+	if expired, _ := codes.Expire(ctx, expiresAt.Add(time.Second)); expired != 0 {
+		t.Errorf("Expire(again) = %d, want 0", expired)
+	}
+}
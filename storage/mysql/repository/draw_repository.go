@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DrawRepository 抽奖记录仓储接口
+type DrawRepository interface {
+	RecordDraw(ctx context.Context, record models.DrawRecord) error
+}
+
+// drawRepository 抽奖记录仓储实现
+type drawRepository struct {
+	db *gorm.DB
+}
+
+// NewDrawRepository 创建抽奖记录仓储实例
+func NewDrawRepository(db *gorm.DB) DrawRepository {
+	return &drawRepository{db: db}
+}
+
+// RecordDraw 写入抽奖记录
+func (r *drawRepository) RecordDraw(ctx context.Context, record models.DrawRecord) error {
+	fallbacks := make([]string, 0, len(record.FallbackSlots))
+	for _, slot := range record.FallbackSlots {
+		fallbacks = append(fallbacks, strconv.Itoa(slot))
+	}
+	return dbFrom(ctx, r.db).Create(&entity.LotteryDraw{
+		ActivityID:    record.ActivityID,
+		GameName:      record.GameName,
+		UserID:        record.UserID,
+		SlotIndex:     record.SlotIndex,
+		SlotType:      record.SlotType,
+		PrizeKey:      record.PrizeKey,
+		PrizeID:       record.PrizeID,
+		FallbackSlots: strings.Join(fallbacks, ","),
+	}).Error
+}
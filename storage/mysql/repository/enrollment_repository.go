@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EnrollmentRepository 活动报名仓储接口
+type EnrollmentRepository interface {
+	// Enroll 报名活动，已报名时返回已有记录且created为false
+	Enroll(ctx context.Context, activityID int64, userID string, maxParticipants int64) (enrollment *entity.ActivityEnrollment, created bool, err error)
+	IsEnrolled(ctx context.Context, activityID int64, userID string) (bool, error)
+}
+
+// enrollmentRepository 活动报名仓储实现
+type enrollmentRepository struct {
+	db *gorm.DB
+}
+
+// NewEnrollmentRepository 创建活动报名仓储实例
+func NewEnrollmentRepository(db *gorm.DB) EnrollmentRepository {
+	return &enrollmentRepository{db: db}
+}
+
+// Enroll 写入报名记录并累加报名人数
+// 人数上限通过 participant_count 的条件更新保证，和报名记录在同一事务中提交，并发下不会超员
+func (r *enrollmentRepository) Enroll(ctx context.Context, activityID int64, userID string, maxParticipants int64) (*entity.ActivityEnrollment, bool, error) {
+	enrollment := &entity.ActivityEnrollment{
+		ActivityID: activityID,
+		UserID:     userID,
+	}
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(enrollment)
+		if result.Error != nil {
+			return result.Error
+		}
+		// 重复报名直接返回已有记录，不占用名额
+		if result.RowsAffected == 0 {
+			return tx.Where("activity_id = ? AND user_id = ?", activityID, userID).First(enrollment).Error
+		}
+
+		query := tx.Model(&entity.Activity{}).Where("id = ?", activityID)
+		if maxParticipants > 0 {
+			query = query.Where("participant_count < ?", maxParticipants)
+		}
+		update := query.UpdateColumn("participant_count", gorm.Expr("participant_count + 1"))
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return models.ErrActivityFull
+		}
+		created = true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return enrollment, created, nil
+}
+
+// IsEnrolled 查询用户是否已报名
+func (r *enrollmentRepository) IsEnrolled(ctx context.Context, activityID int64, userID string) (bool, error) {
+	var enrollment entity.ActivityEnrollment
+	err := r.db.WithContext(ctx).
+		Where("activity_id = ? AND user_id = ?", activityID, userID).
+		First(&enrollment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
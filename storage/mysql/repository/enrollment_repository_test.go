@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestEnrollmentRepositoryEnrollConcurrent(t *testing.T) {
+	const (
+		maxParticipants = 10
+		users           = 30
+		retries         = 3 // 每个用户并发重复报名的次数
+	)
+
+	db := newTestDB(t)
+	repo := NewEnrollmentRepository(db)
+	ctx := context.Background()
+	activity := &entity.Activity{Category: "community", Version: "v1", Name: "社区发帖活动", Config: "{}"}
+	if err := db.Create(activity).Error; err != nil {
+		t.Fatalf("failed to create activity: %v", err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		created  = make(map[string]int)
+		enrolled = make(map[string]bool)
+		full     int
+		others   []error
+	)
+	for i := 0; i < users; i++ {
+		uid := fmt.Sprintf("user-%d", i)
+		for j := 0; j < retries; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				enrollment, ok, err := repo.Enroll(ctx, activity.ID, uid, maxParticipants)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					enrolled[uid] = true
+					if ok {
+						created[uid]++
+					}
+					if enrollment.UserID != uid {
+						others = append(others, fmt.Errorf("enrollment of %s returned for %s", enrollment.UserID, uid))
+					}
+				case errors.Is(err, models.ErrActivityFull):
+					full++
+				default:
+					others = append(others, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+
+	if len(others) > 0 {
+		t.Fatalf("unexpected errors: %v", others)
+	}
+	// 名额不超过上限，同一用户的重复报名只占用一个名额
+	if len(created) != maxParticipants || len(enrolled) != maxParticipants {
+		t.Errorf("created = %d users, enrolled = %d users, want %d", len(created), len(enrolled), maxParticipants)
+	}
+	for uid, n := range created {
+		if n != 1 {
+			t.Errorf("%s created %d times, want 1", uid, n)
+		}
+	}
+	if full == 0 {
+		t.Error("no enrollment was rejected as full")
+	}
+
+	var stored entity.Activity
+	if err := db.First(&stored, activity.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var rows int64
+	if err := db.Model(&entity.ActivityEnrollment{}).Count(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.ParticipantCount != maxParticipants || rows != maxParticipants {
+		t.Errorf("participant_count = %d, enrollments = %d, want %d", stored.ParticipantCount, rows, maxParticipants)
+	}
+
+	// 已报名的用户满员后再次报名仍返回已有记录
+	for uid := range enrolled {
+		enrollment, ok, err := repo.Enroll(ctx, activity.ID, uid, maxParticipants)
+		if err != nil || ok || enrollment.UserID != uid {
+			t.Errorf("Enroll(%s) again = %+v, %v, %v, want existing enrollment", uid, enrollment, ok, err)
+		}
+	}
+	if enrolled, err := repo.IsEnrolled(ctx, activity.ID, "user-none"); err != nil || enrolled {
+		t.Errorf("IsEnrolled(user-none) = %v, %v, want false", enrolled, err)
+	}
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"errors"
+)
+
+var (
+	// ErrActivityNotFound 活动不存在或ID不合法
+	ErrActivityNotFound = errors.New("activity not found")
+	// ErrRevisionConflict 活动已被其他请求修改，修订号不匹配
+	ErrRevisionConflict = errors.New("activity revision conflict")
+)
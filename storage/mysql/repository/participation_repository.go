@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ParticipationRepository 用户参与记录仓储接口
+type ParticipationRepository interface {
+	Create(ctx context.Context, participation *entity.ActivityParticipation) error
+	FindByUser(ctx context.Context, activityID int64, userID string, offset, limit int) ([]*entity.ActivityParticipation, int64, error)
+	CountParticipations(ctx context.Context, activityID int64, gameName, uid string) (int64, error)
+	CreateFlushed(ctx context.Context, participation *entity.ActivityParticipation) error
+	CountByGame(ctx context.Context, activityID int64, gameName string) (map[string]int64, error)
+}
+
+// ParticipationDeduper 支持并发去重的参与记录仓储实现该接口，同一用户对同一玩法同一时刻只允许一个请求参与
+type ParticipationDeduper interface {
+	// Acquire 占住用户对玩法的参与，已被其他请求占住时返回false
+	Acquire(ctx context.Context, activityID int64, gameName, uid string) (bool, error)
+	// Release 释放占位
+	Release(ctx context.Context, activityID int64, gameName, uid string) error
+}
+
+// participationRepository 用户参与记录仓储实现
+type participationRepository struct {
+	db *gorm.DB
+}
+
+// NewParticipationRepository 创建用户参与记录仓储实例
+func NewParticipationRepository(db *gorm.DB) ParticipationRepository {
+	return &participationRepository{db: db}
+}
+
+// Create 写入参与记录，记录中需已填写本次参与后的用户状态；ctx处于事务中时随事务提交
+// 记录的seq为用户在玩法中的第几次参与，唯一索引保证同一次序只能写入一条：并发请求读到相同的参与次数、
+// 都通过了玩法的次数检查时只有一个能写入，其余返回 ErrUserCannotParticipate，所在事务连同发放的奖品一起回滚
+func (r *participationRepository) Create(ctx context.Context, participation *entity.ActivityParticipation) error {
+	db := dbFrom(ctx, r.db)
+	var count int64
+	err := db.Unscoped().
+		Model(&entity.ActivityParticipation{}).
+		Where("activity_id = ? AND user_id = ? AND game_target = ?", participation.ActivityID, participation.UserID, participation.GameTarget).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	seq := count + 1
+	participation.Seq = &seq
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(participation)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrUserCannotParticipate
+	}
+	return nil
+}
+
+// FindByUser 分页查询用户在活动中的参与记录，按参与时间倒序，同时返回总数
+func (r *participationRepository) FindByUser(ctx context.Context, activityID int64, userID string, offset, limit int) ([]*entity.ActivityParticipation, int64, error) {
+	query := r.db.WithContext(ctx).
+		Model(&entity.ActivityParticipation{}).
+		Where("activity_id = ? AND user_id = ?", activityID, userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var participations []*entity.ActivityParticipation
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&participations).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return participations, total, nil
+}
+
+// CountParticipations 统计用户在某个玩法中的参与次数，玩法以game_target区分
+func (r *participationRepository) CountParticipations(ctx context.Context, activityID int64, gameName, uid string) (int64, error) {
+	var count int64
+	err := dbFrom(ctx, r.db).
+		Model(&entity.ActivityParticipation{}).
+		Where("activity_id = ? AND user_id = ? AND game_target = ?", activityID, uid, gameName).
+		Count(&count).Error
+	return count, err
+}
+
+// CreateFlushed 写入异步落库的参与记录，相同FlushID的记录已存在时忽略
+func (r *participationRepository) CreateFlushed(ctx context.Context, participation *entity.ActivityParticipation) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(participation).Error
+}
+
+// CountByGame 统计玩法中每个用户的参与次数
+func (r *participationRepository) CountByGame(ctx context.Context, activityID int64, gameName string) (map[string]int64, error) {
+	var rows []struct {
+		UserID string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&entity.ActivityParticipation{}).
+		Select("user_id, COUNT(*) AS count").
+		Where("activity_id = ? AND game_target = ?", activityID, gameName).
+		Group("user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, nil
+}
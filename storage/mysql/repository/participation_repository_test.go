@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// authorClient 任意帖子都由帖子ID对应的用户在活动期间发布的社区服务
+type authorClient struct{}
+
+func (authorClient) GetPost(ctx context.Context, postID string) (*models.Post, error) {
+	return &models.Post{ID: postID, AuthorID: postID, CreatedAt: time.Unix(1700000100, 0)}, nil
+}
+
+func TestParticipationLimitConcurrent(t *testing.T) {
+	const goroutines = 20
+
+	db := newTestDB(t)
+	participations := NewParticipationRepository(db)
+	transactor := NewTransactor(db)
+	ctx := context.Background()
+
+	codes := make([]string, goroutines)
+	for i := range codes {
+		codes[i] = fmt.Sprintf("POST-%02d", i)
+	}
+	if _, err := NewDiscountCodeRepository(db).Import(ctx, 1, "发帖奖励", codes, nil); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	post := &models.CommunityPostGame{
+		Name_: "发帖奖励",
+		State: models.GameStateOPEN,
+		Prize: &models.DiscountCodePrize{DiscountCode: "POST", TotalNum: goroutines},
+	}
+	lottery := &models.LotteryGame{
+		Name_:    "幸运抽奖",
+		State:    models.GameStateOPEN,
+		MaxDraws: 2,
+		Slots: []models.LotterySlot{
+			{Type: models.LotterySlotProduct, Name: "周边礼品", Product: &models.ProductPrize{Sku: "GIFT-001", Probability: 1, TotalNum: goroutines}},
+		},
+	}
+	rt := &models.Runtime{
+		ActivityID:     1,
+		StartAt:        1700000000,
+		EndAt:          1700086400,
+		Participations: participations,
+		Checkins:       NewCheckinRepository(db),
+		Prizes:         NewPrizeRepository(db),
+		Draws:          NewDrawRepository(db),
+		Community:      authorClient{},
+		Tx:             transactor,
+	}
+	post.BindRuntime(rt)
+	lottery.BindRuntime(rt)
+
+	// 与应用层一致：玩法执行和参与记录在同一事务中提交
+	participate := func(game models.GameInterface, action models.ActionInterface) error {
+		user := models.User{Uid: "user-1"}
+		return transactor.Transaction(ctx, func(ctx context.Context) error {
+			result, err := game.Perform(ctx, user, action)
+			if err != nil {
+				return err
+			}
+			return participations.Create(ctx, &entity.ActivityParticipation{
+				ActivityID: 1,
+				UserID:     user.Uid,
+				GameType:   game.Type(ctx),
+				GameTarget: result.Target(ctx),
+				State:      result.(models.StateResult).StateAfter(),
+			})
+		})
+	}
+
+	cases := []struct {
+		game   models.GameInterface
+		action models.ActionInterface
+		limit  int
+	}{
+		{post, &models.CommunityPostAction{PostID: "user-1"}, 1},
+		{lottery, &models.LotteryAction{}, 2},
+	}
+	for _, tc := range cases {
+		name := tc.game.Name(ctx)
+		var (
+			wg      sync.WaitGroup
+			mu      sync.Mutex
+			won     int
+			limited int
+			others  []error
+		)
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := participate(tc.game, tc.action)
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case err == nil:
+					won++
+				case errors.Is(err, models.ErrUserCannotParticipate):
+					limited++
+				default:
+					others = append(others, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(others) > 0 {
+			t.Fatalf("%s: unexpected errors: %v", name, others)
+		}
+		if won != tc.limit || limited != goroutines-tc.limit {
+			t.Errorf("%s: won = %d, limited = %d, want %d, %d", name, won, limited, tc.limit, goroutines-tc.limit)
+		}
+
+		// 超出上限的请求连同发放的奖品一起回滚
+		var records, rows int64
+		db.Table("prize_records").Where("game_name = ?", name).Count(&records)
+		db.Table("activity_participations").Where("game_target = ?", name).Count(&rows)
+		if records != int64(tc.limit) || rows != int64(tc.limit) {
+			t.Errorf("%s: prize records = %d, participations = %d, want %d", name, records, rows, tc.limit)
+		}
+	}
+}
+
+func TestParticipationRepositoryCreateRejectsTakenSeq(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewParticipationRepository(db)
+	ctx := context.Background()
+
+	newParticipation := func() *entity.ActivityParticipation {
+		return &entity.ActivityParticipation{ActivityID: 1, UserID: "user-1", GameType: "lottery", GameTarget: "幸运抽奖", State: models.UserStateOPEN}
+	}
+	first := newParticipation()
+	if err := repo.Create(ctx, first); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if first.Seq == nil || *first.Seq != 1 {
+		t.Fatalf("seq = %v, want 1", first.Seq)
+	}
+
+	// 模拟MySQL可重复读下本事务读到的参与次数落后于其他事务已提交的记录：按次数得到的次序已被占用时写入失败
+	seq := int64(3)
+	taken := newParticipation()
+	taken.Seq = &seq
+	if err := db.Create(taken).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Create(ctx, newParticipation()); !errors.Is(err, models.ErrUserCannotParticipate) {
+		t.Errorf("Create() error = %v, want ErrUserCannotParticipate", err)
+	}
+}
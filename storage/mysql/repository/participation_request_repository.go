@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"Activity/storage/mysql/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ParticipationRequestRepository 参与请求仓储接口，按用户和幂等键保证同一请求只执行一次
+type ParticipationRequestRepository interface {
+	// Begin 登记处理中的请求，相同用户和幂等键的请求已存在时返回已有记录且created为false
+	Begin(ctx context.Context, request *entity.ParticipationRequest) (existing *entity.ParticipationRequest, created bool, err error)
+	// Takeover 接管租约已过期的处理中请求，续期成功时返回true
+	Takeover(ctx context.Context, id int64, now time.Time, lease time.Duration) (bool, error)
+	// Complete 保存请求的响应并标记为已完成
+	Complete(ctx context.Context, id int64, response string) error
+	// Fail 保存请求的错误信息并标记为失败，重试时返回相同的错误
+	Fail(ctx context.Context, id int64, response string) error
+	// Abort 删除处理中的请求，客户端可以使用相同的幂等键重试
+	Abort(ctx context.Context, id int64) error
+}
+
+// participationRequestRepository 参与请求仓储实现
+type participationRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewParticipationRequestRepository 创建参与请求仓储实例
+func NewParticipationRequestRepository(db *gorm.DB) ParticipationRequestRepository {
+	return &participationRequestRepository{db: db}
+}
+
+// Begin 写入请求记录，依靠用户和幂等键的唯一索引去重，并发的相同请求只有一个写入成功
+func (r *participationRequestRepository) Begin(ctx context.Context, request *entity.ParticipationRequest) (*entity.ParticipationRequest, bool, error) {
+	db := r.db.WithContext(ctx)
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(request)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return request, true, nil
+	}
+
+	var existing entity.ParticipationRequest
+	err := db.Where("user_id = ? AND idempotency_key = ?", request.UserID, request.IdempotencyKey).
+		First(&existing).Error
+	if err != nil {
+		return nil, false, err
+	}
+	return &existing, false, nil
+}
+
+// Takeover 通过条件更新续期租约，多个重试同时接管时只有一个成功
+func (r *participationRequestRepository) Takeover(ctx context.Context, id int64, now time.Time, lease time.Duration) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&entity.ParticipationRequest{}).
+		Where("id = ? AND status = ? AND expires_at < ?", id, entity.ParticipationRequestStatusPending, now).
+		Update("expires_at", now.Add(lease))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Complete 保存请求的响应并标记为已完成
+func (r *participationRequestRepository) Complete(ctx context.Context, id int64, response string) error {
+	return r.finish(ctx, id, entity.ParticipationRequestStatusCompleted, response)
+}
+
+// Fail 保存请求的错误信息并标记为失败
+func (r *participationRequestRepository) Fail(ctx context.Context, id int64, response string) error {
+	return r.finish(ctx, id, entity.ParticipationRequestStatusFailed, response)
+}
+
+// finish 保存请求的最终状态和响应
+func (r *participationRequestRepository) finish(ctx context.Context, id int64, status int64, response string) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.ParticipationRequest{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":   status,
+			"response": response,
+		}).Error
+}
+
+// Abort 删除处理中的请求，已完成的请求不受影响
+func (r *participationRequestRepository) Abort(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).
+		Where("id = ? AND status = ?", id, entity.ParticipationRequestStatusPending).
+		Delete(&entity.ParticipationRequest{}).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"Activity/storage/mysql/entity"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParticipationRequestRepositoryBeginOnce(t *testing.T) {
+	const goroutines = 20
+
+	repo := NewParticipationRequestRepository(newTestDB(t))
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+
+	var (
+		wg      sync.WaitGroup
+		created int32
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, ok, err := repo.Begin(ctx, &entity.ParticipationRequest{
+				UserID:         "user-1",
+				IdempotencyKey: "key-1",
+				ActivityID:     1,
+				GameName:       "幸运抽奖",
+				ExpiresAt:      now.Add(30 * time.Second),
+			})
+			if err != nil {
+				t.Errorf("Begin() error = %v", err)
+			}
+			if ok {
+				atomic.AddInt32(&created, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 1 {
+		t.Fatalf("created = %d, want 1", created)
+	}
+
+	request, _, err := repo.Begin(ctx, &entity.ParticipationRequest{UserID: "user-1", IdempotencyKey: "key-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 租约未到期时不能接管，到期后只有一个重试接管成功
+	if taken, _ := repo.Takeover(ctx, request.ID, now, 30*time.Second); taken {
+		t.Fatal("took over a request before its lease expired")
+	}
+	later := now.Add(time.Minute)
+	if taken, err := repo.Takeover(ctx, request.ID, later, 30*time.Second); err != nil || !taken {
+		t.Fatalf("Takeover() = %v, %v, want true", taken, err)
+	}
+	if taken, _ := repo.Takeover(ctx, request.ID, later, 30*time.Second); taken {
+		t.Fatal("took over a request twice")
+	}
+
+	// 完成后返回保存的响应，不能再被删除
+	if err := repo.Complete(ctx, request.ID, `{"success":true}`); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Abort(ctx, request.ID); err != nil {
+		t.Fatal(err)
+	}
+	completed, ok, err := repo.Begin(ctx, &entity.ParticipationRequest{UserID: "user-1", IdempotencyKey: "key-1"})
+	if err != nil || ok {
+		t.Fatalf("Begin() = %v, %v after complete", ok, err)
+	}
+	if completed.Status != entity.ParticipationRequestStatusCompleted || completed.Response == nil || *completed.Response != `{"success":true}` {
+		t.Errorf("request = %+v, want completed with response", completed)
+	}
+}
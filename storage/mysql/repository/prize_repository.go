@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PrizeRepository 奖品库存与发放记录仓储接口
+type PrizeRepository interface {
+	Issue(ctx context.Context, req models.PrizeIssue) (*models.PrizeGrant, error)
+	Remain(ctx context.Context, activityID int64, prizeKey string) (int64, bool, error)
+	FindUserPrizes(ctx context.Context, activityID int64, gameName, uid string) ([]*entity.PrizeRecord, error)
+	SaveFlushed(ctx context.Context, record *entity.PrizeRecord, totalNum int64) error
+}
+
+// prizeRepository 奖品仓储实现
+type prizeRepository struct {
+	db *gorm.DB
+}
+
+// NewPrizeRepository 创建奖品仓储实例
+func NewPrizeRepository(db *gorm.DB) PrizeRepository {
+	return &prizeRepository{db: db}
+}
+
+// Issue 扣减库存并写入发放记录
+// 库存扣减使用 remain_num > 0 的条件更新，和发放记录在同一事务中提交，并发下不会超发
+// ctx处于事务中时作为嵌套事务执行，库存不足等失败只回滚本次发放
+func (r *prizeRepository) Issue(ctx context.Context, req models.PrizeIssue) (*models.PrizeGrant, error) {
+	var record entity.PrizeRecord
+	err := dbFrom(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		deducted, err := r.deduct(tx, req.ActivityID, req.PrizeKey)
+		if err != nil {
+			return err
+		}
+		if !deducted {
+			// 库存记录不存在时按配置初始化，已存在则忽略
+			inventory := &entity.PrizeInventory{
+				ActivityID: req.ActivityID,
+				PrizeKey:   req.PrizeKey,
+				PrizeType:  req.PrizeType,
+				TotalNum:   req.TotalNum,
+				RemainNum:  req.TotalNum,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(inventory).Error; err != nil {
+				return err
+			}
+			if deducted, err = r.deduct(tx, req.ActivityID, req.PrizeKey); err != nil {
+				return err
+			}
+		}
+		if !deducted {
+			return models.ErrPrizeStockEmpty
+		}
+
+		// 折扣码奖品从码池中分配一个码，作为发放记录的奖品标识
+		prizeID := req.PrizeID
+		var code *entity.DiscountCode
+		if req.AllocateCode {
+			if code, err = allocateDiscountCode(tx, req.ActivityID, req.PrizeKey, req.UserID); err != nil {
+				return err
+			}
+			prizeID = code.Code
+		}
+
+		record = entity.PrizeRecord{
+			ActivityID: req.ActivityID,
+			UserID:     req.UserID,
+			GameName:   req.GameName,
+			PrizeKey:   req.PrizeKey,
+			PrizeType:  req.PrizeType,
+			PrizeID:    prizeID,
+			Status:     entity.PrizeRecordStatusIssued,
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return err
+		}
+		if code != nil {
+			return bindDiscountCode(tx, code.ID, record.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PrizeGrant{
+		RecordID:  record.ID,
+		PrizeType: record.PrizeType,
+		PrizeID:   record.PrizeID,
+	}, nil
+}
+
+// SaveFlushed 写入由Redis库存发放、异步落库的发放记录，同时扣减MySQL库存并将预留的折扣码标记为已发放
+// 相同FlushID的记录已存在时忽略，重复落库不会重复扣减
+func (r *prizeRepository) SaveFlushed(ctx context.Context, record *entity.PrizeRecord, totalNum int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		// 库存以Redis为准，MySQL库存只跟随扣减，不足时由对账修正
+		inventory := &entity.PrizeInventory{
+			ActivityID: record.ActivityID,
+			PrizeKey:   record.PrizeKey,
+			PrizeType:  record.PrizeType,
+			TotalNum:   totalNum,
+			RemainNum:  totalNum,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(inventory).Error; err != nil {
+			return err
+		}
+		if _, err := r.deduct(tx, record.ActivityID, record.PrizeKey); err != nil {
+			return err
+		}
+
+		if record.PrizeType != models.PrizeTypeDiscountCode {
+			return nil
+		}
+		return tx.Model(&entity.DiscountCode{}).
+			Where("activity_id = ? AND prize_key = ? AND code = ? AND status = ?", record.ActivityID, record.PrizeKey, record.PrizeID, entity.DiscountCodeStatusReserved).
+			Updates(map[string]interface{}{
+				"status":          entity.DiscountCodeStatusIssued,
+				"user_id":         record.UserID,
+				"prize_record_id": record.ID,
+				"issued_at":       record.CreatedAt,
+			}).Error
+	})
+}
+
+// deduct 条件扣减一件库存，返回是否扣减成功
+func (r *prizeRepository) deduct(tx *gorm.DB, activityID int64, prizeKey string) (bool, error) {
+	result := tx.Model(&entity.PrizeInventory{}).
+		Where("activity_id = ? AND prize_key = ? AND remain_num > 0", activityID, prizeKey).
+		UpdateColumn("remain_num", gorm.Expr("remain_num - 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Remain 查询剩余库存
+func (r *prizeRepository) Remain(ctx context.Context, activityID int64, prizeKey string) (int64, bool, error) {
+	var inventory entity.PrizeInventory
+	err := dbFrom(ctx, r.db).
+		Where("activity_id = ? AND prize_key = ?", activityID, prizeKey).
+		First(&inventory).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return inventory.RemainNum, true, nil
+}
+
+// FindUserPrizes 查询用户在某个玩法中获得的奖品，按发放时间倒序
+func (r *prizeRepository) FindUserPrizes(ctx context.Context, activityID int64, gameName, uid string) ([]*entity.PrizeRecord, error) {
+	var records []*entity.PrizeRecord
+	err := r.db.WithContext(ctx).
+		Where("activity_id = ? AND game_name = ? AND user_id = ?", activityID, gameName, uid).
+		Order("id DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
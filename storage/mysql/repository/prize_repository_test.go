@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"Activity/models"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqliteSchema 测试使用的表结构，与 migrations 中的 MySQL 定义保持一致
+var sqliteSchema = []string{
+	`CREATE TABLE activities (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		category TEXT NOT NULL,
+		version TEXT NOT NULL,
+		name TEXT NOT NULL,
+		config TEXT NOT NULL,
+		start_at INTEGER NOT NULL,
+		end_at INTEGER NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		participant_count INTEGER NOT NULL DEFAULT 0,
+		revision INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		deleted_at DATETIME
+	)`,
+	`CREATE TABLE activity_enrollments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (activity_id, user_id)
+	)`,
+	`CREATE TABLE prize_inventories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		prize_key TEXT NOT NULL,
+		prize_type TEXT NOT NULL,
+		total_num INTEGER NOT NULL,
+		remain_num INTEGER NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (activity_id, prize_key)
+	)`,
+	`CREATE TABLE prize_records (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		game_name TEXT NOT NULL DEFAULT '',
+		prize_key TEXT NOT NULL DEFAULT '',
+		prize_type TEXT NOT NULL,
+		prize_id TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		flush_id TEXT UNIQUE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		deleted_at DATETIME
+	)`,
+	`CREATE TABLE participation_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		idempotency_key TEXT NOT NULL,
+		activity_id INTEGER NOT NULL,
+		game_name TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		response TEXT,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, idempotency_key)
+	)`,
+	`CREATE TABLE activity_participations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		game_type TEXT NOT NULL,
+		game_target TEXT NOT NULL,
+		state TEXT NOT NULL,
+		seq INTEGER,
+		extra TEXT,
+		flush_id TEXT UNIQUE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		deleted_at DATETIME,
+		UNIQUE (activity_id, user_id, game_target, seq)
+	)`,
+	`CREATE TABLE discount_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		prize_key TEXT NOT NULL,
+		code TEXT NOT NULL UNIQUE,
+		status INTEGER NOT NULL DEFAULT 0,
+		user_id TEXT NOT NULL DEFAULT '',
+		prize_record_id INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME,
+		issued_at DATETIME,
+		redeemed_at DATETIME,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE lottery_draws (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		game_name TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		slot_index INTEGER NOT NULL,
+		slot_type TEXT NOT NULL,
+		prize_key TEXT NOT NULL DEFAULT '',
+		prize_id TEXT NOT NULL DEFAULT '',
+		fallback_slots TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE checkin_records (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		game_name TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		checkin_date TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (activity_id, game_name, user_id, checkin_date)
+	)`,
+}
+
+// newTestDB 创建基于文件的SQLite数据库，作为MySQL的本地替身
+func newTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "activity.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	for _, ddl := range sqliteSchema {
+		if err := db.Exec(ddl).Error; err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+	return db
+}
+
+func TestPrizeRepositoryIssueConcurrent(t *testing.T) {
+	const (
+		totalNum   = 50
+		goroutines = 200
+	)
+
+	db := newTestDB(t)
+	repo := NewPrizeRepository(db)
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		issued  int
+		empties int
+		others  []error
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := repo.Issue(ctx, models.PrizeIssue{
+				ActivityID: 1,
+				PrizeKey:   "发帖奖励",
+				PrizeType:  models.PrizeTypeDiscountCode,
+				PrizeID:    "COMMUNITY_2024",
+				TotalNum:   totalNum,
+				UserID:     fmt.Sprintf("user-%d", i),
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				issued++
+			case errors.Is(err, models.ErrPrizeStockEmpty):
+				empties++
+			default:
+				others = append(others, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(others) > 0 {
+		t.Fatalf("unexpected errors: %v", others)
+	}
+	if issued != totalNum {
+		t.Errorf("issued = %d, want %d", issued, totalNum)
+	}
+	if empties != goroutines-totalNum {
+		t.Errorf("stock empty errors = %d, want %d", empties, goroutines-totalNum)
+	}
+
+	remain, exists, err := repo.Remain(ctx, 1, "发帖奖励")
+	if err != nil || !exists {
+		t.Fatalf("Remain() = %d, %v, %v", remain, exists, err)
+	}
+	if remain != 0 {
+		t.Errorf("remain = %d, want 0", remain)
+	}
+
+	var records int64
+	if err := db.Table("prize_records").Count(&records).Error; err != nil {
+		t.Fatalf("failed to count prize records: %v", err)
+	}
+	if records != totalNum {
+		t.Errorf("prize records = %d, want %d", records, totalNum)
+	}
+}
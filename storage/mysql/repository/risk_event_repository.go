@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"Activity/storage/mysql/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RiskEventRepository 风控事件仓储接口，保存风控决策并提供按设备统计的历史数据
+type RiskEventRepository interface {
+	// Create 保存一次风控决策
+	Create(ctx context.Context, event *entity.RiskEvent) error
+	// MarkWon 标记放行的请求获得了奖品
+	MarkWon(ctx context.Context, id int64) error
+	// CountDeviceUsers 统计since之后在设备上被放行过的其他用户数
+	CountDeviceUsers(ctx context.Context, deviceID, excludeUserID string, since time.Time) (int64, error)
+	// CountDeviceWins 统计since之后设备上的中奖次数
+	CountDeviceWins(ctx context.Context, deviceID string, since time.Time) (int64, error)
+}
+
+// riskEventRepository 风控事件仓储实现
+type riskEventRepository struct {
+	db *gorm.DB
+}
+
+// NewRiskEventRepository 创建风控事件仓储实例
+func NewRiskEventRepository(db *gorm.DB) RiskEventRepository {
+	return &riskEventRepository{db: db}
+}
+
+// Create 保存一次风控决策
+func (r *riskEventRepository) Create(ctx context.Context, event *entity.RiskEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// MarkWon 标记放行的请求获得了奖品
+func (r *riskEventRepository) MarkWon(ctx context.Context, id int64) error {
+	return r.db.WithContext(ctx).
+		Model(&entity.RiskEvent{}).
+		Where("id = ?", id).
+		Update("won", true).Error
+}
+
+// CountDeviceUsers 按设备和时间索引统计被放行过的不同用户数，不含当前用户
+func (r *riskEventRepository) CountDeviceUsers(ctx context.Context, deviceID, excludeUserID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.RiskEvent{}).
+		Where("device_id = ? AND created_at >= ? AND decision = ? AND user_id <> ?", deviceID, since, entity.RiskDecisionAllow, excludeUserID).
+		Distinct("user_id").
+		Count(&count).Error
+	return count, err
+}
+
+// CountDeviceWins 统计设备上的中奖次数
+func (r *riskEventRepository) CountDeviceWins(ctx context.Context, deviceID string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entity.RiskEvent{}).
+		Where("device_id = ? AND created_at >= ? AND won = ?", deviceID, since, true).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"Activity/storage/mysql/entity"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchedulerLockRepository 定时任务锁仓储接口，基于锁表的行实现多副本间的领导者选举
+type SchedulerLockRepository interface {
+	TryAcquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error)
+	Release(ctx context.Context, name, holder string) error
+}
+
+// schedulerLockRepository 定时任务锁仓储实现
+type schedulerLockRepository struct {
+	db *gorm.DB
+}
+
+// NewSchedulerLockRepository 创建定时任务锁仓储实例
+func NewSchedulerLockRepository(db *gorm.DB) SchedulerLockRepository {
+	return &schedulerLockRepository{db: db}
+}
+
+// TryAcquire 尝试获取或续期任务锁，锁不存在、已由自己持有或已过期时获取成功
+// 获取和续期都通过条件更新完成，同一时刻只有一个实例能持有未过期的锁
+func (r *schedulerLockRepository) TryAcquire(ctx context.Context, name, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	db := r.db.WithContext(ctx)
+	lock := &entity.SchedulerLock{
+		Name:      name,
+		Holder:    holder,
+		ExpiresAt: now.Add(ttl),
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(lock)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	result = db.Model(&entity.SchedulerLock{}).
+		Where("name = ? AND (holder = ? OR expires_at < ?)", name, holder, now).
+		Updates(map[string]interface{}{
+			"holder":     holder,
+			"expires_at": now.Add(ttl),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release 释放自己持有的任务锁，其他实例可以立即接管
+func (r *schedulerLockRepository) Release(ctx context.Context, name, holder string) error {
+	return r.db.WithContext(ctx).
+		Where("name = ? AND holder = ?", name, holder).
+		Delete(&entity.SchedulerLock{}).Error
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"Activity/models"
+	"context"
+	"log"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// txKey ctx中保存事务的键
+type txKey struct{}
+
+// txHooksKey ctx中保存事务结束后回调的键
+type txHooksKey struct{}
+
+// txHooks 事务提交或回滚后执行的回调，用于同步事务之外的存储（如Redis）
+type txHooks struct {
+	mu            sync.Mutex
+	afterCommit   []func(ctx context.Context) error
+	afterRollback []func(ctx context.Context) error
+}
+
+// run 按事务结果执行回调，提交后按注册顺序执行，回滚后按注册的逆序执行
+// 事务已经结束，回调失败时只记录日志
+func (h *txHooks) run(ctx context.Context, committed bool) {
+	h.mu.Lock()
+	hooks := h.afterCommit
+	if !committed {
+		hooks = make([]func(ctx context.Context) error, 0, len(h.afterRollback))
+		for i := len(h.afterRollback) - 1; i >= 0; i-- {
+			hooks = append(hooks, h.afterRollback[i])
+		}
+	}
+	h.mu.Unlock()
+
+	for _, fn := range hooks {
+		if err := fn(ctx); err != nil {
+			log.Printf("transaction: after committed=%t hook failed: %v", committed, err)
+		}
+	}
+}
+
+// transactor 基于gorm的事务管理，事务通过ctx传递给仓储
+type transactor struct {
+	db *gorm.DB
+}
+
+// NewTransactor 创建事务管理实例，事务内的仓储操作需要使用fn传入的ctx
+func NewTransactor(db *gorm.DB) models.Transactor {
+	return &transactor{db: db}
+}
+
+// Transaction 开启事务执行fn，ctx已处于事务中时直接加入该事务，由最外层提交或回滚
+func (t *transactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	hooks := &txHooks{}
+	err := t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(context.WithValue(ctx, txKey{}, tx), txHooksKey{}, hooks))
+	})
+	hooks.run(context.WithoutCancel(ctx), err == nil)
+	return err
+}
+
+// InTransaction ctx是否处于NewTransactor开启的事务中
+func InTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	return ok
+}
+
+// AfterCommit 在ctx所在事务提交后执行fn，返回true；ctx不在事务中时不注册，返回false，由调用方直接执行
+func AfterCommit(ctx context.Context, fn func(ctx context.Context) error) bool {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok {
+		return false
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.afterCommit = append(hooks.afterCommit, fn)
+	return true
+}
+
+// AfterRollback 在ctx所在事务回滚后执行fn，用于撤销事务之外已经生效的写入；ctx不在事务中时不注册，返回false
+func AfterRollback(ctx context.Context, fn func(ctx context.Context) error) bool {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok {
+		return false
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.afterRollback = append(hooks.afterRollback, fn)
+	return true
+}
+
+// dbFrom 返回ctx中的事务，不在事务中时返回db
+func dbFrom(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
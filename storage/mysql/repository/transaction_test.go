@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"Activity/models"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTransactorRollsBackIssue(t *testing.T) {
+	db := newTestDB(t)
+	prizes := NewPrizeRepository(db)
+	transactor := NewTransactor(db)
+	ctx := context.Background()
+
+	issue := func(ctx context.Context, prizeKey string, totalNum int64) error {
+		_, err := prizes.Issue(ctx, models.PrizeIssue{
+			ActivityID: 1,
+			PrizeKey:   prizeKey,
+			PrizeType:  models.PrizeTypeProduct,
+			PrizeID:    "SKU001",
+			TotalNum:   totalNum,
+			UserID:     "user-1",
+		})
+		return err
+	}
+
+	// 事务中后续写入失败时，已发放的奖品一并回滚
+	failed := errors.New("failed to save participation")
+	err := transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := issue(ctx, "幸运抽奖#0", 10); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Transaction() error = %v, want %v", err, failed)
+	}
+	if _, exists, _ := prizes.Remain(ctx, 1, "幸运抽奖#0"); exists {
+		t.Error("stock was initialized by a rolled back transaction")
+	}
+
+	// 库存不足只回滚本次发放，事务可以继续发放其他奖品
+	err = transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := issue(ctx, "幸运抽奖#1", 0); !errors.Is(err, models.ErrPrizeStockEmpty) {
+			t.Errorf("Issue(empty) error = %v, want ErrPrizeStockEmpty", err)
+		}
+		return issue(ctx, "幸运抽奖#0", 10)
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if remain, _, _ := prizes.Remain(ctx, 1, "幸运抽奖#0"); remain != 9 {
+		t.Errorf("remain = %d, want 9", remain)
+	}
+
+	var records int64
+	if err := db.Table("prize_records").Count(&records).Error; err != nil {
+		t.Fatalf("failed to count prize records: %v", err)
+	}
+	if records != 1 {
+		t.Errorf("prize records = %d, want 1", records)
+	}
+}
+
+func TestTransactorHooks(t *testing.T) {
+	db := newTestDB(t)
+	transactor := NewTransactor(db)
+	ctx := context.Background()
+
+	var calls []string
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	// 不在事务中时不注册，由调用方直接执行
+	if InTransaction(ctx) || AfterCommit(ctx, record("commit")) || AfterRollback(ctx, record("rollback")) {
+		t.Fatal("hooks registered outside a transaction")
+	}
+
+	// 提交后按注册顺序执行提交回调，嵌套的事务由最外层统一执行
+	err := transactor.Transaction(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, record("commit-1"))
+		AfterRollback(ctx, record("rollback-1"))
+		return transactor.Transaction(ctx, func(ctx context.Context) error {
+			AfterCommit(ctx, record("commit-2"))
+			if len(calls) != 0 {
+				t.Errorf("hooks ran before the transaction ended: %v", calls)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if want := []string{"commit-1", "commit-2"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls after commit = %v, want %v", calls, want)
+	}
+
+	// 回滚后按注册的逆序执行回滚回调
+	calls = nil
+	failed := errors.New("failed")
+	err = transactor.Transaction(ctx, func(ctx context.Context) error {
+		AfterCommit(ctx, record("commit-1"))
+		AfterRollback(ctx, record("rollback-1"))
+		AfterRollback(ctx, record("rollback-2"))
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Transaction() error = %v, want %v", err, failed)
+	}
+	if want := []string{"rollback-2", "rollback-1"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls after rollback = %v, want %v", calls, want)
+	}
+}
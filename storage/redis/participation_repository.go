@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// inflightTTL 用户参与占位的有效期，请求异常退出未释放时到期自动释放
+const inflightTTL = 10 * time.Second
+
+// seedCountScript 参与次数不存在时写入并登记玩法，已存在则忽略
+// KEYS: 参与次数、玩法登记；ARGV: 用户ID、次数、玩法名称、活动ID
+var seedCountScript = redis.NewScript(`
+redis.call('HSETNX', KEYS[1], ARGV[1], ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[3])
+redis.call('SADD', '` + activitiesKey + `', ARGV[4])
+return redis.call('HGET', KEYS[1], ARGV[1])
+`)
+
+// incrCountScript 参与次数已存在时加1并返回新的次数，不存在时返回-1
+// KEYS: 参与次数；ARGV: 用户ID
+var incrCountScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], ARGV[1], 1)
+`)
+
+// decrCountScript 参与次数已存在时减1，用于撤销回滚事务中增加的次数
+// KEYS: 参与次数；ARGV: 用户ID
+var decrCountScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 0 then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], ARGV[1], -1)
+`)
+
+// ParticipationRepository 基于Redis的参与记录仓储，参与次数记录在Redis中，参与记录写入待落库流，由WriteBehind异步写入MySQL
+// 同时实现 repository.ParticipationDeduper，同一用户对同一玩法的并发请求只有一个能进入
+type ParticipationRepository struct {
+	repository.ParticipationRepository // MySQL参与记录仓储，分页查询等读操作直接使用
+	client                             *redis.Client
+}
+
+// NewParticipationRepository 创建基于Redis的参与记录仓储，participations为MySQL参与记录仓储
+func NewParticipationRepository(client *redis.Client, participations repository.ParticipationRepository) *ParticipationRepository {
+	return &ParticipationRepository{ParticipationRepository: participations, client: client}
+}
+
+// Acquire 占住用户对玩法的参与，已被占住时返回false
+func (r *ParticipationRepository) Acquire(ctx context.Context, activityID int64, gameName, uid string) (bool, error) {
+	return r.client.SetNX(ctx, inflightKey(activityID, gameName, uid), 1, inflightTTL).Result()
+}
+
+// Release 释放用户对玩法的参与占位
+func (r *ParticipationRepository) Release(ctx context.Context, activityID int64, gameName, uid string) error {
+	return r.client.Del(ctx, inflightKey(activityID, gameName, uid)).Err()
+}
+
+// Create 增加用户在玩法中的参与次数，并将参与记录写入待落库流，记录中需已填写本次参与后的用户状态
+// ctx处于MySQL事务中时，参与记录在事务提交后才写入待落库流，事务回滚时减回参与次数
+func (r *ParticipationRepository) Create(ctx context.Context, participation *entity.ActivityParticipation) error {
+	if err := r.incr(ctx, participation.ActivityID, participation.GameTarget, participation.UserID); err != nil {
+		return err
+	}
+	queue := func(ctx context.Context) error {
+		return r.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: pendingParticipationsKey(participation.ActivityID),
+			Values: map[string]interface{}{
+				"user_id":     participation.UserID,
+				"game_type":   participation.GameType,
+				"game_target": participation.GameTarget,
+				"state":       participation.State,
+				"extra":       participation.Extra,
+			},
+		}).Err()
+	}
+	if !repository.AfterCommit(ctx, queue) {
+		return queue(ctx)
+	}
+	repository.AfterRollback(ctx, func(ctx context.Context) error {
+		key := participationsKey(participation.ActivityID, participation.GameTarget)
+		return decrCountScript.Run(ctx, r.client, []string{key}, participation.UserID).Err()
+	})
+	return nil
+}
+
+// incr 用户在玩法中的参与次数加1，Redis中尚无记录时先从MySQL加载
+func (r *ParticipationRepository) incr(ctx context.Context, activityID int64, gameName, uid string) error {
+	key := participationsKey(activityID, gameName)
+	count, err := incrCountScript.Run(ctx, r.client, []string{key}, uid).Int64()
+	if err != nil || count >= 0 {
+		return err
+	}
+	if _, err := r.seed(ctx, activityID, gameName, uid); err != nil {
+		return err
+	}
+	return r.client.HIncrBy(ctx, key, uid, 1).Err()
+}
+
+// CountParticipations 查询用户在玩法中的参与次数，Redis中尚无记录时从MySQL加载
+func (r *ParticipationRepository) CountParticipations(ctx context.Context, activityID int64, gameName, uid string) (int64, error) {
+	count, err := r.client.HGet(ctx, participationsKey(activityID, gameName), uid).Int64()
+	if errors.Is(err, redis.Nil) {
+		return r.seed(ctx, activityID, gameName, uid)
+	}
+	return count, err
+}
+
+// seed 从MySQL加载用户在玩法中的参与次数写入Redis，已存在时不覆盖，返回Redis中的次数
+func (r *ParticipationRepository) seed(ctx context.Context, activityID int64, gameName, uid string) (int64, error) {
+	key := participationsKey(activityID, gameName)
+	count, err := r.ParticipationRepository.CountParticipations(ctx, activityID, gameName, uid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load participations of %s: %w", uid, err)
+	}
+	result, err := seedCountScript.Run(ctx, r.client, []string{key, gamesKey(activityID)}, uid, count, gameName, activityID).Text()
+	if err != nil {
+		return 0, fmt.Errorf("failed to seed participations of %s: %w", uid, err)
+	}
+	return strconv.ParseInt(result, 10, 64)
+}
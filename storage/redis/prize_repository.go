@@ -0,0 +1,316 @@
+package redis
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 库存初始化参数
+const (
+	stockInitLockTTL  = 10 * time.Second      // 初始化锁有效期，持有者宕机后由其他请求重新初始化
+	stockInitRetries  = 50                    // 等待其他请求完成初始化的最大次数
+	stockInitInterval = 20 * time.Millisecond // 等待初始化的间隔
+)
+
+// 发奖脚本的返回状态
+const (
+	issueUninitialized = -1 // 库存尚未初始化
+	issueEmpty         = 0  // 库存不足或折扣码已发完
+	issueOK            = 1  // 发放成功
+)
+
+// issueScript 扣减库存，折扣码奖品同时从预留队列中弹出一个码
+// 不在事务中时直接写入待落库流；在事务中时只记为未提交，提交后由commitIssueScript写入流，回滚后由rollbackIssueScript归还
+// KEYS: 库存、折扣码队列、待落库流、未提交的发放数；ARGV: 是否分配折扣码、是否在事务中及流中的字段
+var issueScript = redis.NewScript(`
+local stock = redis.call('GET', KEYS[1])
+if not stock then
+	return {-1, ''}
+end
+if tonumber(stock) <= 0 then
+	return {0, ''}
+end
+local prize_id = ARGV[3]
+if ARGV[1] == '1' then
+	local code = redis.call('LPOP', KEYS[2])
+	if not code then
+		return {0, ''}
+	end
+	prize_id = code
+end
+redis.call('DECR', KEYS[1])
+if ARGV[2] == '1' then
+	redis.call('HINCRBY', KEYS[4], ARGV[6], 1)
+	return {1, prize_id}
+end
+redis.call('XADD', KEYS[3], '*',
+	'activity_id', ARGV[4], 'game_name', ARGV[5], 'prize_key', ARGV[6],
+	'prize_type', ARGV[7], 'prize_id', prize_id, 'total_num', ARGV[8], 'user_id', ARGV[9])
+return {1, prize_id}
+`)
+
+// commitIssueScript 事务提交后将发放记录写入待落库流，并减少未提交的发放数
+// KEYS: 待落库流、未提交的发放数；ARGV: 库存标识及流中的字段
+var commitIssueScript = redis.NewScript(`
+redis.call('XADD', KEYS[1], '*', unpack(ARGV, 2))
+redis.call('HINCRBY', KEYS[2], ARGV[1], -1)
+return 1
+`)
+
+// rollbackIssueScript 事务回滚后归还库存和折扣码，并减少未提交的发放数；库存已被清除时不归还，由重新初始化加载
+// KEYS: 库存、折扣码队列、未提交的发放数；ARGV: 库存标识、归还的折扣码，非折扣码奖品为空
+var rollbackIssueScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('INCR', KEYS[1])
+	if ARGV[2] ~= '' then
+		redis.call('LPUSH', KEYS[2], ARGV[2])
+	end
+end
+redis.call('HINCRBY', KEYS[3], ARGV[1], -1)
+return 1
+`)
+
+// initStockScript 库存不存在时写入库存并登记奖品，已存在则忽略
+// KEYS: 库存、奖品登记；ARGV: 库存、库存标识、奖品配置、活动ID
+var initStockScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[3])
+redis.call('SADD', '` + activitiesKey + `', ARGV[4])
+return 1
+`)
+
+// prizeMeta 已初始化库存的奖品配置，对账时使用
+type prizeMeta struct {
+	PrizeType    string `json:"prize_type"`
+	TotalNum     int64  `json:"total_num"`
+	AllocateCode bool   `json:"allocate_code"`
+}
+
+// PrizeRepository 基于Redis库存的奖品仓储，发奖只访问Redis，发放记录写入待落库流，由WriteBehind异步写入MySQL
+// 库存首次使用时从MySQL加载剩余数量，折扣码奖品同时从码池中预留对应数量的码
+type PrizeRepository struct {
+	client *redis.Client
+	mysql  repository.PrizeRepository
+	codes  repository.DiscountCodeRepository
+}
+
+// NewPrizeRepository 创建基于Redis库存的奖品仓储，prizes为MySQL奖品仓储
+func NewPrizeRepository(client *redis.Client, prizes repository.PrizeRepository, codes repository.DiscountCodeRepository) *PrizeRepository {
+	return &PrizeRepository{client: client, mysql: prizes, codes: codes}
+}
+
+// Issue 扣减Redis库存并生成待落库的发放记录，库存不足时返回 ErrPrizeStockEmpty
+// 发放记录异步落库，返回结果中的 RecordID 为0
+// ctx处于MySQL事务中时，发放记录在事务提交后才写入待落库流，事务回滚时归还库存和折扣码
+func (r *PrizeRepository) Issue(ctx context.Context, req models.PrizeIssue) (*models.PrizeGrant, error) {
+	allocate, deferred := "0", "0"
+	if req.AllocateCode {
+		allocate = "1"
+	}
+	inTx := repository.InTransaction(ctx)
+	if inTx {
+		deferred = "1"
+	}
+	keys := []string{
+		stockKey(req.ActivityID, req.PrizeKey),
+		codesKey(req.ActivityID, req.PrizeKey),
+		pendingPrizesKey(req.ActivityID),
+		uncommittedPrizesKey(req.ActivityID),
+	}
+	args := []interface{}{
+		allocate, deferred, req.PrizeID, req.ActivityID, req.GameName, req.PrizeKey,
+		req.PrizeType, req.TotalNum, req.UserID,
+	}
+
+	for i := 0; i < stockInitRetries; i++ {
+		result, err := issueScript.Run(ctx, r.client, keys, args...).Slice()
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue prize %s: %w", req.PrizeKey, err)
+		}
+		switch result[0].(int64) {
+		case issueOK:
+			prizeID := result[1].(string)
+			if inTx {
+				r.deferIssue(ctx, req, prizeID)
+			}
+			return &models.PrizeGrant{PrizeType: req.PrizeType, PrizeID: prizeID}, nil
+		case issueEmpty:
+			return nil, models.ErrPrizeStockEmpty
+		}
+
+		meta := prizeMeta{PrizeType: req.PrizeType, TotalNum: req.TotalNum, AllocateCode: req.AllocateCode}
+		initialized, err := r.initStock(ctx, req.ActivityID, req.PrizeKey, meta)
+		if err != nil {
+			return nil, err
+		}
+		if !initialized {
+			// 其他请求正在初始化，稍后重试
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(stockInitInterval):
+			}
+		}
+	}
+	return nil, fmt.Errorf("timed out waiting for stock %s of activity %d to initialize", req.PrizeKey, req.ActivityID)
+}
+
+// deferIssue 注册事务结束后的处理：提交后写入待落库流，回滚后归还库存和折扣码
+func (r *PrizeRepository) deferIssue(ctx context.Context, req models.PrizeIssue, prizeID string) {
+	repository.AfterCommit(ctx, func(ctx context.Context) error {
+		keys := []string{pendingPrizesKey(req.ActivityID), uncommittedPrizesKey(req.ActivityID)}
+		args := []interface{}{
+			req.PrizeKey,
+			"activity_id", req.ActivityID, "game_name", req.GameName, "prize_key", req.PrizeKey,
+			"prize_type", req.PrizeType, "prize_id", prizeID, "total_num", req.TotalNum, "user_id", req.UserID,
+		}
+		if err := commitIssueScript.Run(ctx, r.client, keys, args...).Err(); err != nil {
+			return fmt.Errorf("failed to queue prize %s of %s: %w", req.PrizeKey, req.UserID, err)
+		}
+		return nil
+	})
+	repository.AfterRollback(ctx, func(ctx context.Context) error {
+		code := ""
+		if req.AllocateCode {
+			code = prizeID
+		}
+		keys := []string{
+			stockKey(req.ActivityID, req.PrizeKey),
+			codesKey(req.ActivityID, req.PrizeKey),
+			uncommittedPrizesKey(req.ActivityID),
+		}
+		if err := rollbackIssueScript.Run(ctx, r.client, keys, req.PrizeKey, code).Err(); err != nil {
+			return fmt.Errorf("failed to return prize %s of %s: %w", req.PrizeKey, req.UserID, err)
+		}
+		return nil
+	})
+}
+
+// Remain 查询Redis中的剩余库存，尚未初始化时查询MySQL
+func (r *PrizeRepository) Remain(ctx context.Context, activityID int64, prizeKey string) (int64, bool, error) {
+	remain, err := r.client.Get(ctx, stockKey(activityID, prizeKey)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return r.mysql.Remain(ctx, activityID, prizeKey)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return remain, true, nil
+}
+
+// FindUserPrizes 查询用户在某个玩法中获得的奖品，尚未落库的记录不包含在内
+func (r *PrizeRepository) FindUserPrizes(ctx context.Context, activityID int64, gameName, uid string) ([]*entity.PrizeRecord, error) {
+	return r.mysql.FindUserPrizes(ctx, activityID, gameName, uid)
+}
+
+// SaveFlushed 写入异步落库的发放记录
+func (r *PrizeRepository) SaveFlushed(ctx context.Context, record *entity.PrizeRecord, totalNum int64) error {
+	return r.mysql.SaveFlushed(ctx, record, totalNum)
+}
+
+// Warm 预先初始化活动中所有奖品的Redis库存，在活动开始前调用，避免开始时集中从MySQL加载
+func (r *PrizeRepository) Warm(ctx context.Context, activity models.ActivityInterface) error {
+	activityID := activity.Meta().ID
+	for key, prize := range models.StockPrizes(ctx, activity.Games()) {
+		meta := prizeMeta{PrizeType: models.PrizeTypeProduct, TotalNum: prize.StockTotal()}
+		if _, ok := prize.(*models.DiscountCodePrize); ok {
+			meta.PrizeType = models.PrizeTypeDiscountCode
+			meta.AllocateCode = true
+		}
+		if _, err := r.initStock(ctx, activityID, key, meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initStock 从MySQL加载剩余库存写入Redis，折扣码奖品预留对应数量的码
+// 多个请求同时初始化时只有获取到锁的请求执行，其余返回false；库存已存在时返回true
+func (r *PrizeRepository) initStock(ctx context.Context, activityID int64, prizeKey string, meta prizeMeta) (bool, error) {
+	lockKey := stockInitKey(activityID, prizeKey)
+	acquired, err := r.client.SetNX(ctx, lockKey, 1, stockInitLockTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to lock stock %s: %w", prizeKey, err)
+	}
+	if !acquired {
+		return false, nil
+	}
+	defer r.client.Del(context.Background(), lockKey)
+
+	stock := stockKey(activityID, prizeKey)
+	exists, err := r.client.Exists(ctx, stock).Result()
+	if err != nil {
+		return false, err
+	}
+	if exists == 1 {
+		return true, nil
+	}
+
+	remain, found, err := r.mysql.Remain(ctx, activityID, prizeKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to load stock %s: %w", prizeKey, err)
+	}
+	if !found {
+		remain = meta.TotalNum
+	}
+	if meta.AllocateCode {
+		if _, err := reserveCodes(ctx, r.client, r.codes, activityID, prizeKey, remain); err != nil {
+			return false, err
+		}
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return false, err
+	}
+	keys := []string{stock, prizeMetaKey(activityID)}
+	if err := initStockScript.Run(ctx, r.client, keys, remain, prizeKey, data, activityID).Err(); err != nil {
+		return false, fmt.Errorf("failed to init stock %s: %w", prizeKey, err)
+	}
+	return true, nil
+}
+
+// reserveCodes 从码池中预留至多n个折扣码并追加到Redis队列，返回追加的数量
+func reserveCodes(ctx context.Context, client *redis.Client, codes repository.DiscountCodeRepository, activityID int64, prizeKey string, n int64) (int, error) {
+	if n <= 0 {
+		return 0, nil
+	}
+	reserved, err := codes.Reserve(ctx, activityID, prizeKey, int(n))
+	if err != nil {
+		return 0, fmt.Errorf("failed to reserve discount codes of %s: %w", prizeKey, err)
+	}
+	if len(reserved) == 0 {
+		return 0, nil
+	}
+	values := make([]interface{}, len(reserved))
+	for i, code := range reserved {
+		values[i] = code
+	}
+	if err := client.RPush(ctx, codesKey(activityID, prizeKey), values...).Err(); err != nil {
+		return 0, err
+	}
+	return len(reserved), nil
+}
+
+// parseStreamTime 从流水ID中解析写入时间
+func parseStreamTime(id string) time.Time {
+	ms, _, _ := strings.Cut(id, "-")
+	millis, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.UnixMilli(millis)
+}
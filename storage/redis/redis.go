@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Config Redis配置
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+// NewClient 创建Redis连接
+func NewClient(cfg *Config) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return client, nil
+}
+
+// activitiesKey 使用了Redis库存或参与计数的活动ID集合，落库和对账按它遍历
+const activitiesKey = "activities"
+
+// 同一活动的键使用 {活动ID} 作为hash tag，集群部署时落在同一个槽，Lua脚本可以同时操作
+
+// stockKey 奖品剩余库存
+func stockKey(activityID int64, prizeKey string) string {
+	return fmt.Sprintf("activity:{%d}:stock:%s", activityID, prizeKey)
+}
+
+// stockInitKey 初始化库存时的互斥锁
+func stockInitKey(activityID int64, prizeKey string) string {
+	return fmt.Sprintf("activity:{%d}:stock_init:%s", activityID, prizeKey)
+}
+
+// codesKey 预留到Redis的折扣码队列
+func codesKey(activityID int64, prizeKey string) string {
+	return fmt.Sprintf("activity:{%d}:codes:%s", activityID, prizeKey)
+}
+
+// prizeMetaKey 已初始化库存的奖品及其配置，库存标识到prizeMeta的映射
+func prizeMetaKey(activityID int64) string {
+	return fmt.Sprintf("activity:{%d}:prize_meta", activityID)
+}
+
+// pendingPrizesKey 待落库的发放记录流
+func pendingPrizesKey(activityID int64) string {
+	return fmt.Sprintf("activity:{%d}:pending_prizes", activityID)
+}
+
+// uncommittedPrizesKey 已扣减库存但所在事务尚未结束的发放数，库存标识到数量的映射，对账时与待落库流一并扣除
+func uncommittedPrizesKey(activityID int64) string {
+	return fmt.Sprintf("activity:{%d}:uncommitted_prizes", activityID)
+}
+
+// participationsKey 玩法中每个用户的参与次数
+func participationsKey(activityID int64, gameName string) string {
+	return fmt.Sprintf("activity:{%d}:participations:%s", activityID, gameName)
+}
+
+// gamesKey 使用了参与计数的玩法集合
+func gamesKey(activityID int64) string {
+	return fmt.Sprintf("activity:{%d}:games", activityID)
+}
+
+// pendingParticipationsKey 待落库的参与记录流
+func pendingParticipationsKey(activityID int64) string {
+	return fmt.Sprintf("activity:{%d}:pending_participations", activityID)
+}
+
+// inflightKey 用户正在参与玩法的占位标记
+func inflightKey(activityID int64, gameName, uid string) string {
+	return fmt.Sprintf("activity:{%d}:inflight:%s:%s", activityID, gameName, uid)
+}
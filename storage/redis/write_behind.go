@@ -0,0 +1,343 @@
+package redis
+
+import (
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 落库参数
+const (
+	flushBatchSize           = 100         // 每次从流中读取的条数
+	defaultReconcileInterval = time.Minute // 默认对账间隔
+)
+
+// reconcileStockScript 按MySQL剩余库存修正Redis库存，扣除流中尚未落库和所在事务尚未结束的发放记录
+// KEYS: 库存、待落库流、未提交的发放数；ARGV: 库存标识、MySQL剩余库存；返回修正前后的库存，库存不存在时不修正
+var reconcileStockScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return {-1, -1}
+end
+local pending = tonumber(redis.call('HGET', KEYS[3], ARGV[1]) or '0')
+for _, entry in ipairs(redis.call('XRANGE', KEYS[2], '-', '+')) do
+	local fields = entry[2]
+	for i = 1, #fields, 2 do
+		if fields[i] == 'prize_key' and fields[i + 1] == ARGV[1] then
+			pending = pending + 1
+		end
+	end
+end
+local expected = tonumber(ARGV[2]) - pending
+if expected < 0 then
+	expected = 0
+end
+if tonumber(current) ~= expected then
+	redis.call('SET', KEYS[1], expected)
+end
+return {tonumber(current), expected}
+`)
+
+// raiseCountScript 参与次数小于期望值时调高，不会调低，避免放行已参与的用户
+// KEYS: 参与次数；ARGV: 用户ID、期望次数；返回是否修正
+var raiseCountScript = redis.NewScript(`
+local current = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+if current < tonumber(ARGV[2]) then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// ReconcileReport 一次对账的修正结果
+type ReconcileReport struct {
+	Stocks         int // 修正的库存数
+	Codes          int // 补充到Redis队列的折扣码数
+	Participations int // 修正的参与次数
+}
+
+// WriteBehind 将Redis中待落库的发放记录和参与记录写入MySQL，并定期按MySQL对账修正Redis中的库存和参与次数
+// 落库以流水ID去重，同一条记录重复落库只生效一次；由调度器的任务锁保证同一时刻只有一个实例执行
+type WriteBehind struct {
+	client            *redis.Client
+	prizes            repository.PrizeRepository
+	participations    repository.ParticipationRepository
+	codes             repository.DiscountCodeRepository
+	reconcileInterval time.Duration
+
+	mu            sync.Mutex
+	lastReconcile time.Time
+}
+
+// NewWriteBehind 创建落库与对账任务，prizes、participations为MySQL仓储，reconcileInterval不大于0时使用默认对账间隔
+func NewWriteBehind(client *redis.Client, prizes repository.PrizeRepository, participations repository.ParticipationRepository, codes repository.DiscountCodeRepository, reconcileInterval time.Duration) *WriteBehind {
+	if reconcileInterval <= 0 {
+		reconcileInterval = defaultReconcileInterval
+	}
+	return &WriteBehind{
+		client:            client,
+		prizes:            prizes,
+		participations:    participations,
+		codes:             codes,
+		reconcileInterval: reconcileInterval,
+	}
+}
+
+// Sync 落库，距上次对账超过对账间隔时在落库后对账，作为调度任务执行
+func (w *WriteBehind) Sync(ctx context.Context, now time.Time) error {
+	if err := w.Flush(ctx); err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	due := now.Sub(w.lastReconcile) >= w.reconcileInterval
+	if due {
+		w.lastReconcile = now
+	}
+	w.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	report, err := w.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	if report.Stocks > 0 || report.Codes > 0 || report.Participations > 0 {
+		log.Printf("redis: reconciled %d stocks, %d discount codes, %d participations", report.Stocks, report.Codes, report.Participations)
+	}
+	return nil
+}
+
+// Flush 将所有活动待落库的发放记录和参与记录写入MySQL，写入成功后从流中删除
+func (w *WriteBehind) Flush(ctx context.Context) error {
+	activityIDs, err := w.activityIDs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, activityID := range activityIDs {
+		if err := w.flushStream(ctx, pendingPrizesKey(activityID), func(msg redis.XMessage) error {
+			return w.savePrize(ctx, activityID, msg)
+		}); err != nil {
+			return fmt.Errorf("failed to flush prizes of activity %d: %w", activityID, err)
+		}
+		if err := w.flushStream(ctx, pendingParticipationsKey(activityID), func(msg redis.XMessage) error {
+			return w.saveParticipation(ctx, activityID, msg)
+		}); err != nil {
+			return fmt.Errorf("failed to flush participations of activity %d: %w", activityID, err)
+		}
+	}
+	return nil
+}
+
+// flushStream 按批读取流中的记录逐条保存，保存失败时停止，未删除的记录下次重试
+func (w *WriteBehind) flushStream(ctx context.Context, stream string, save func(msg redis.XMessage) error) error {
+	for {
+		messages, err := w.client.XRangeN(ctx, stream, "-", "+", flushBatchSize).Result()
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			if err := save(msg); err != nil {
+				return err
+			}
+			if err := w.client.XDel(ctx, stream, msg.ID).Err(); err != nil {
+				return err
+			}
+		}
+		if len(messages) < flushBatchSize {
+			return nil
+		}
+	}
+}
+
+// savePrize 写入一条发放记录
+func (w *WriteBehind) savePrize(ctx context.Context, activityID int64, msg redis.XMessage) error {
+	totalNum, err := strconv.ParseInt(field(msg, "total_num"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid total_num in %s: %w", msg.ID, err)
+	}
+	id := flushID(activityID, msg.ID)
+	record := &entity.PrizeRecord{
+		ActivityID: activityID,
+		UserID:     field(msg, "user_id"),
+		GameName:   field(msg, "game_name"),
+		PrizeKey:   field(msg, "prize_key"),
+		PrizeType:  field(msg, "prize_type"),
+		PrizeID:    field(msg, "prize_id"),
+		Status:     entity.PrizeRecordStatusIssued,
+		FlushID:    &id,
+		CreatedAt:  parseStreamTime(msg.ID),
+	}
+	return w.prizes.SaveFlushed(ctx, record, totalNum)
+}
+
+// saveParticipation 写入一条参与记录
+func (w *WriteBehind) saveParticipation(ctx context.Context, activityID int64, msg redis.XMessage) error {
+	id := flushID(activityID, msg.ID)
+	participation := &entity.ActivityParticipation{
+		ActivityID: activityID,
+		UserID:     field(msg, "user_id"),
+		GameType:   field(msg, "game_type"),
+		GameTarget: field(msg, "game_target"),
+		State:      field(msg, "state"),
+		Extra:      field(msg, "extra"),
+		FlushID:    &id,
+		CreatedAt:  parseStreamTime(msg.ID),
+	}
+	return w.participations.CreateFlushed(ctx, participation)
+}
+
+// Reconcile 按MySQL修正Redis：库存以MySQL剩余库存减去尚未落库（包括所在事务尚未结束）的发放数为准，折扣码队列不足时补充预留，
+// 参与次数低于MySQL记录数加尚未落库数时调高；应在Flush之后执行，此时流中的记录都是Flush之后新写入的
+func (w *WriteBehind) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	activityIDs, err := w.activityIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &ReconcileReport{}
+	for _, activityID := range activityIDs {
+		if err := w.reconcileStocks(ctx, activityID, report); err != nil {
+			return report, fmt.Errorf("failed to reconcile stocks of activity %d: %w", activityID, err)
+		}
+		if err := w.reconcileParticipations(ctx, activityID, report); err != nil {
+			return report, fmt.Errorf("failed to reconcile participations of activity %d: %w", activityID, err)
+		}
+	}
+	return report, nil
+}
+
+// reconcileStocks 修正活动中已初始化的奖品库存
+func (w *WriteBehind) reconcileStocks(ctx context.Context, activityID int64, report *ReconcileReport) error {
+	metas, err := w.client.HGetAll(ctx, prizeMetaKey(activityID)).Result()
+	if err != nil {
+		return err
+	}
+	for prizeKey, data := range metas {
+		var meta prizeMeta
+		if err := json.Unmarshal([]byte(data), &meta); err != nil {
+			return fmt.Errorf("invalid meta of %s: %w", prizeKey, err)
+		}
+		remain, found, err := w.prizes.Remain(ctx, activityID, prizeKey)
+		if err != nil {
+			return err
+		}
+		if !found {
+			remain = meta.TotalNum
+		}
+
+		keys := []string{stockKey(activityID, prizeKey), pendingPrizesKey(activityID), uncommittedPrizesKey(activityID)}
+		result, err := reconcileStockScript.Run(ctx, w.client, keys, prizeKey, remain).Int64Slice()
+		if err != nil {
+			return err
+		}
+		current, expected := result[0], result[1]
+		if current < 0 {
+			continue
+		}
+		if current != expected {
+			log.Printf("redis: stock %s of activity %d corrected from %d to %d", prizeKey, activityID, current, expected)
+			report.Stocks++
+		}
+
+		if !meta.AllocateCode {
+			continue
+		}
+		queued, err := w.client.LLen(ctx, codesKey(activityID, prizeKey)).Result()
+		if err != nil {
+			return err
+		}
+		reserved, err := reserveCodes(ctx, w.client, w.codes, activityID, prizeKey, expected-queued)
+		if err != nil {
+			return err
+		}
+		report.Codes += reserved
+	}
+	return nil
+}
+
+// reconcileParticipations 修正活动中各玩法的参与次数
+func (w *WriteBehind) reconcileParticipations(ctx context.Context, activityID int64, report *ReconcileReport) error {
+	games, err := w.client.SMembers(ctx, gamesKey(activityID)).Result()
+	if err != nil {
+		return err
+	}
+	pending, err := w.pendingParticipations(ctx, activityID)
+	if err != nil {
+		return err
+	}
+	for _, gameName := range games {
+		counts, err := w.participations.CountByGame(ctx, activityID, gameName)
+		if err != nil {
+			return err
+		}
+		for uid, n := range pending[gameName] {
+			counts[uid] += n
+		}
+		key := participationsKey(activityID, gameName)
+		for uid, expected := range counts {
+			raised, err := raiseCountScript.Run(ctx, w.client, []string{key}, uid, expected).Int64()
+			if err != nil {
+				return err
+			}
+			if raised == 1 {
+				log.Printf("redis: participations of %s in game %s of activity %d raised to %d", uid, gameName, activityID, expected)
+				report.Participations++
+			}
+		}
+	}
+	return nil
+}
+
+// pendingParticipations 统计流中尚未落库的参与记录，玩法名称到用户参与次数的映射
+func (w *WriteBehind) pendingParticipations(ctx context.Context, activityID int64) (map[string]map[string]int64, error) {
+	messages, err := w.client.XRange(ctx, pendingParticipationsKey(activityID), "-", "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string]map[string]int64)
+	for _, msg := range messages {
+		gameName := field(msg, "game_target")
+		if pending[gameName] == nil {
+			pending[gameName] = make(map[string]int64)
+		}
+		pending[gameName][field(msg, "user_id")]++
+	}
+	return pending, nil
+}
+
+// activityIDs 使用了Redis库存或参与计数的活动
+func (w *WriteBehind) activityIDs(ctx context.Context) ([]int64, error) {
+	members, err := w.client.SMembers(ctx, activitiesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseInt(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// flushID 落库去重使用的流水ID，流按活动区分，拼上活动ID保证全局唯一
+func flushID(activityID int64, streamID string) string {
+	return fmt.Sprintf("%d-%s", activityID, streamID)
+}
+
+// field 读取流记录中的字符串字段
+func field(msg redis.XMessage, name string) string {
+	value, _ := msg.Values[name].(string)
+	return value
+}
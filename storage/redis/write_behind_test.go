@@ -0,0 +1,458 @@
+package redis
+
+import (
+	"Activity/models"
+	"Activity/storage/mysql/entity"
+	"Activity/storage/mysql/repository"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqliteSchema 测试使用的表结构，与 migrations 中的 MySQL 定义保持一致
+var sqliteSchema = []string{
+	`CREATE TABLE prize_inventories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		prize_key TEXT NOT NULL,
+		prize_type TEXT NOT NULL,
+		total_num INTEGER NOT NULL,
+		remain_num INTEGER NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (activity_id, prize_key)
+	)`,
+	`CREATE TABLE prize_records (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		game_name TEXT NOT NULL DEFAULT '',
+		prize_key TEXT NOT NULL DEFAULT '',
+		prize_type TEXT NOT NULL,
+		prize_id TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		flush_id TEXT UNIQUE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		deleted_at DATETIME
+	)`,
+	`CREATE TABLE activity_participations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		user_id TEXT NOT NULL,
+		game_type TEXT NOT NULL,
+		game_target TEXT NOT NULL,
+		state TEXT NOT NULL,
+		seq INTEGER,
+		extra TEXT,
+		flush_id TEXT UNIQUE,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		deleted_at DATETIME
+	)`,
+	`CREATE TABLE discount_codes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		activity_id INTEGER NOT NULL,
+		prize_key TEXT NOT NULL,
+		code TEXT NOT NULL UNIQUE,
+		status INTEGER NOT NULL DEFAULT 0,
+		user_id TEXT NOT NULL DEFAULT '',
+		prize_record_id INTEGER NOT NULL DEFAULT 0,
+		expires_at DATETIME,
+		issued_at DATETIME,
+		redeemed_at DATETIME,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`,
+}
+
+// testStores 测试使用的MySQL仓储、Redis仓储和落库任务
+type testStores struct {
+	db             *gorm.DB
+	server         *miniredis.Miniredis
+	client         *redis.Client
+	codes          repository.DiscountCodeRepository
+	prizes         *PrizeRepository
+	participations *ParticipationRepository
+	writeBehind    *WriteBehind
+}
+
+func newTestStores(t *testing.T) *testStores {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "activity.db") + "?_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("failed to open sqlite: %v", err)
+	}
+	for _, ddl := range sqliteSchema {
+		if err := db.Exec(ddl).Error; err != nil {
+			t.Fatalf("failed to create schema: %v", err)
+		}
+	}
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	mysqlPrizes := repository.NewPrizeRepository(db)
+	mysqlParticipations := repository.NewParticipationRepository(db)
+	codes := repository.NewDiscountCodeRepository(db)
+	return &testStores{
+		db:             db,
+		server:         server,
+		client:         client,
+		codes:          codes,
+		prizes:         NewPrizeRepository(client, mysqlPrizes, codes),
+		participations: NewParticipationRepository(client, mysqlParticipations),
+		writeBehind:    NewWriteBehind(client, mysqlPrizes, mysqlParticipations, codes, time.Minute),
+	}
+}
+
+func (s *testStores) count(t *testing.T, table, where string, args ...interface{}) int64 {
+	t.Helper()
+	var n int64
+	if err := s.db.Table(table).Where(where, args...).Count(&n).Error; err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+// issueConcurrently 并发发奖，返回发放结果，库存不足以外的错误直接失败
+func issueConcurrently(t *testing.T, prizes *PrizeRepository, goroutines int, req models.PrizeIssue) []*models.PrizeGrant {
+	t.Helper()
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		grants []*models.PrizeGrant
+		others []error
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := req
+			req.UserID = fmt.Sprintf("user-%d", i)
+			grant, err := prizes.Issue(context.Background(), req)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				grants = append(grants, grant)
+			case !errors.Is(err, models.ErrPrizeStockEmpty):
+				others = append(others, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(others) > 0 {
+		t.Fatalf("unexpected errors: %v", others)
+	}
+	return grants
+}
+
+func TestPrizeRepositoryIssueAndFlush(t *testing.T) {
+	const (
+		totalNum   = 50
+		goroutines = 200
+	)
+	s := newTestStores(t)
+	ctx := context.Background()
+
+	grants := issueConcurrently(t, s.prizes, goroutines, models.PrizeIssue{
+		ActivityID: 1,
+		GameName:   "幸运抽奖",
+		PrizeKey:   "幸运抽奖_1",
+		PrizeType:  models.PrizeTypeProduct,
+		PrizeID:    "SKU001",
+		TotalNum:   totalNum,
+	})
+	if len(grants) != totalNum {
+		t.Fatalf("issued = %d, want %d", len(grants), totalNum)
+	}
+	if remain, _, _ := s.prizes.Remain(ctx, 1, "幸运抽奖_1"); remain != 0 {
+		t.Errorf("redis remain = %d, want 0", remain)
+	}
+	if n := s.count(t, "prize_records", "1 = 1"); n != 0 {
+		t.Fatalf("prize records before flush = %d, want 0", n)
+	}
+
+	// 模拟落库后删除流水前宕机：先单独保存一条，再完整落库
+	messages, err := s.client.XRange(ctx, pendingPrizesKey(1), "-", "+").Result()
+	if err != nil || len(messages) != totalNum {
+		t.Fatalf("pending prizes = %d, %v", len(messages), err)
+	}
+	if err := s.writeBehind.savePrize(ctx, 1, messages[0]); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.writeBehind.Flush(ctx); err != nil {
+			t.Fatalf("Flush() error = %v", err)
+		}
+	}
+
+	if n := s.count(t, "prize_records", "activity_id = ? AND prize_key = ?", 1, "幸运抽奖_1"); n != totalNum {
+		t.Errorf("prize records = %d, want %d", n, totalNum)
+	}
+	remain, exists, err := repository.NewPrizeRepository(s.db).Remain(ctx, 1, "幸运抽奖_1")
+	if err != nil || !exists || remain != 0 {
+		t.Errorf("mysql remain = %d, %v, %v, want 0", remain, exists, err)
+	}
+	if n, _ := s.client.XLen(ctx, pendingPrizesKey(1)).Result(); n != 0 {
+		t.Errorf("pending prizes after flush = %d, want 0", n)
+	}
+}
+
+func TestPrizeRepositoryIssuesReservedDiscountCodes(t *testing.T) {
+	s := newTestStores(t)
+	ctx := context.Background()
+
+	codes := []string{"CODE1", "CODE2", "CODE3", "CODE4", "CODE5"}
+	if _, err := s.codes.Import(ctx, 1, "发帖奖励", codes, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	grants := issueConcurrently(t, s.prizes, 20, models.PrizeIssue{
+		ActivityID:   1,
+		GameName:     "社区发帖",
+		PrizeKey:     "发帖奖励",
+		PrizeType:    models.PrizeTypeDiscountCode,
+		TotalNum:     10, // 配置的库存多于码池，以码池为准
+		AllocateCode: true,
+	})
+	seen := make(map[string]bool)
+	for _, grant := range grants {
+		if seen[grant.PrizeID] {
+			t.Errorf("code %s issued twice", grant.PrizeID)
+		}
+		seen[grant.PrizeID] = true
+	}
+	if len(seen) != len(codes) {
+		t.Fatalf("issued codes = %d, want %d", len(seen), len(codes))
+	}
+	if n := s.count(t, "discount_codes", "status = ?", entity.DiscountCodeStatusReserved); n != int64(len(codes)) {
+		t.Errorf("reserved codes = %d, want %d", n, len(codes))
+	}
+
+	if err := s.writeBehind.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if n := s.count(t, "discount_codes", "status = ? AND user_id <> '' AND prize_record_id > 0", entity.DiscountCodeStatusIssued); n != int64(len(codes)) {
+		t.Errorf("issued codes after flush = %d, want %d", n, len(codes))
+	}
+}
+
+func TestParticipationRepositoryDedupAndFlush(t *testing.T) {
+	s := newTestStores(t)
+	ctx := context.Background()
+
+	acquired, err := s.participations.Acquire(ctx, 1, "社区发帖", "user-1")
+	if err != nil || !acquired {
+		t.Fatalf("Acquire() = %v, %v, want true", acquired, err)
+	}
+	if acquired, _ := s.participations.Acquire(ctx, 1, "社区发帖", "user-1"); acquired {
+		t.Fatal("concurrent Acquire() succeeded for the same user")
+	}
+
+	participation := &entity.ActivityParticipation{ActivityID: 1, UserID: "user-1", GameType: "post", GameTarget: "社区发帖", State: models.UserStateCLOSED}
+	if err := s.participations.Create(ctx, participation); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.participations.Release(ctx, 1, "社区发帖", "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := s.participations.CountParticipations(ctx, 1, "社区发帖", "user-1"); count != 1 {
+		t.Errorf("count = %d, want 1", count)
+	}
+
+	if err := s.writeBehind.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if n := s.count(t, "activity_participations", "user_id = ? AND state = ?", "user-1", models.UserStateCLOSED); n != 1 {
+		t.Errorf("participations = %d, want 1", n)
+	}
+
+	// Redis数据丢失后从MySQL重新加载参与次数
+	s.server.FlushAll()
+	if count, _ := s.participations.CountParticipations(ctx, 1, "社区发帖", "user-1"); count != 1 {
+		t.Errorf("count after reload = %d, want 1", count)
+	}
+}
+
+func TestWriteBehindReconcile(t *testing.T) {
+	s := newTestStores(t)
+	ctx := context.Background()
+
+	issueConcurrently(t, s.prizes, 3, models.PrizeIssue{
+		ActivityID: 1,
+		GameName:   "幸运抽奖",
+		PrizeKey:   "幸运抽奖_1",
+		PrizeType:  models.PrizeTypeProduct,
+		PrizeID:    "SKU001",
+		TotalNum:   10,
+	})
+	for i := 0; i < 2; i++ {
+		participation := &entity.ActivityParticipation{ActivityID: 1, UserID: "user-1", GameType: "lottery", GameTarget: "幸运抽奖", State: models.UserStateOPEN}
+		if err := s.participations.Create(ctx, participation); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.writeBehind.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 再发一件尚未落库，对账时应扣除
+	issueConcurrently(t, s.prizes, 1, models.PrizeIssue{
+		ActivityID: 1,
+		GameName:   "幸运抽奖",
+		PrizeKey:   "幸运抽奖_1",
+		PrizeType:  models.PrizeTypeProduct,
+		PrizeID:    "SKU001",
+		TotalNum:   10,
+	})
+
+	// Redis中的库存和参与次数出现偏差
+	s.client.Set(ctx, stockKey(1, "幸运抽奖_1"), 100, 0)
+	s.client.HSet(ctx, participationsKey(1, "幸运抽奖"), "user-1", 0)
+
+	report, err := s.writeBehind.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if report.Stocks != 1 || report.Participations != 1 {
+		t.Errorf("report = %+v, want one stock and one participation corrected", report)
+	}
+	if remain, _, _ := s.prizes.Remain(ctx, 1, "幸运抽奖_1"); remain != 6 {
+		t.Errorf("remain = %d, want 6", remain)
+	}
+	if count, _ := s.participations.CountParticipations(ctx, 1, "幸运抽奖", "user-1"); count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+
+	// 参与次数高于MySQL时不调低
+	s.client.HSet(ctx, participationsKey(1, "幸运抽奖"), "user-1", 5)
+	if _, err := s.writeBehind.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if count, _ := s.participations.CountParticipations(ctx, 1, "幸运抽奖", "user-1"); count != 5 {
+		t.Errorf("count = %d, want 5 kept", count)
+	}
+}
+
+func TestIssueInTransaction(t *testing.T) {
+	s := newTestStores(t)
+	transactor := repository.NewTransactor(s.db)
+	ctx := context.Background()
+
+	if _, err := s.codes.Import(ctx, 1, "签到奖励", []string{"CODE1", "CODE2"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	// 与Warm一样在事务之外初始化库存，sqlite事务期间独占写锁，预留折扣码无法在事务外写入
+	if _, err := s.prizes.initStock(ctx, 1, "签到奖励", prizeMeta{PrizeType: models.PrizeTypeDiscountCode, TotalNum: 2, AllocateCode: true}); err != nil {
+		t.Fatal(err)
+	}
+	perform := func(ctx context.Context, uid string) error {
+		if _, err := s.prizes.Issue(ctx, models.PrizeIssue{
+			ActivityID:   1,
+			GameName:     "每日签到",
+			PrizeKey:     "签到奖励",
+			PrizeType:    models.PrizeTypeDiscountCode,
+			TotalNum:     2,
+			UserID:       uid,
+			AllocateCode: true,
+		}); err != nil {
+			return err
+		}
+		participation := &entity.ActivityParticipation{ActivityID: 1, UserID: uid, GameType: "checkin", GameTarget: "每日签到", State: models.UserStateCLOSED}
+		return s.participations.Create(ctx, participation)
+	}
+	pending := func(stream string) int64 {
+		n, err := s.client.XLen(ctx, stream).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// 发奖之后的步骤失败，事务回滚时库存、折扣码和参与次数都归还，待落库流中没有记录
+	failed := errors.New("failed to save checkin")
+	err := transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := perform(ctx, "user-1"); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Transaction() error = %v, want %v", err, failed)
+	}
+	if remain, _, _ := s.prizes.Remain(ctx, 1, "签到奖励"); remain != 2 {
+		t.Errorf("remain after rollback = %d, want 2", remain)
+	}
+	if codes, _ := s.client.LRange(ctx, codesKey(1, "签到奖励"), 0, -1).Result(); len(codes) != 2 {
+		t.Errorf("queued codes after rollback = %v, want 2 codes", codes)
+	}
+	if n := pending(pendingPrizesKey(1)); n != 0 {
+		t.Errorf("pending prizes after rollback = %d, want 0", n)
+	}
+	if n := pending(pendingParticipationsKey(1)); n != 0 {
+		t.Errorf("pending participations after rollback = %d, want 0", n)
+	}
+	if count, _ := s.participations.CountParticipations(ctx, 1, "每日签到", "user-1"); count != 0 {
+		t.Errorf("count after rollback = %d, want 0", count)
+	}
+	if err := s.writeBehind.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.count(t, "prize_records", "1 = 1"); n != 0 {
+		t.Errorf("prize records after rollback = %d, want 0", n)
+	}
+
+	// 事务尚未结束时不写入待落库流，对账也不会补回已扣减的库存；提交后才写入
+	err = transactor.Transaction(ctx, func(ctx context.Context) error {
+		if err := perform(ctx, "user-2"); err != nil {
+			return err
+		}
+		if n := pending(pendingPrizesKey(1)); n != 0 {
+			t.Errorf("pending prizes before commit = %d, want 0", n)
+		}
+		if _, err := s.writeBehind.Reconcile(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if remain, _, _ := s.prizes.Remain(ctx, 1, "签到奖励"); remain != 1 {
+			t.Errorf("remain after reconcile in transaction = %d, want 1", remain)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if n := pending(pendingPrizesKey(1)); n != 1 {
+		t.Errorf("pending prizes after commit = %d, want 1", n)
+	}
+	if n := pending(pendingParticipationsKey(1)); n != 1 {
+		t.Errorf("pending participations after commit = %d, want 1", n)
+	}
+	if err := s.writeBehind.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.count(t, "prize_records", "user_id = ?", "user-2"); n != 1 {
+		t.Errorf("prize records after commit = %d, want 1", n)
+	}
+	if _, err := s.writeBehind.Reconcile(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if remain, _, _ := s.prizes.Remain(ctx, 1, "签到奖励"); remain != 1 {
+		t.Errorf("remain after commit = %d, want 1", remain)
+	}
+}